@@ -20,11 +20,14 @@ import (
 	"github.com/weibaohui/k8m/pkg/cb"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/controller/admin/ai_prompt"
+	"github.com/weibaohui/k8m/pkg/controller/admin/approval"
 	"github.com/weibaohui/k8m/pkg/controller/admin/cluster"
 	"github.com/weibaohui/k8m/pkg/controller/admin/config"
 	"github.com/weibaohui/k8m/pkg/controller/admin/inspection"
 	"github.com/weibaohui/k8m/pkg/controller/admin/mcp"
 	"github.com/weibaohui/k8m/pkg/controller/admin/menu"
+	"github.com/weibaohui/k8m/pkg/controller/admin/runbook"
+	"github.com/weibaohui/k8m/pkg/controller/admin/terminal"
 	"github.com/weibaohui/k8m/pkg/controller/admin/user"
 	"github.com/weibaohui/k8m/pkg/controller/chat"
 	"github.com/weibaohui/k8m/pkg/controller/cluster_status"
@@ -59,6 +62,7 @@ import (
 	"github.com/weibaohui/k8m/pkg/lua"
 	"github.com/weibaohui/k8m/pkg/middleware"
 	_ "github.com/weibaohui/k8m/pkg/models" // 注册模型
+	"github.com/weibaohui/k8m/pkg/retention"
 	"github.com/weibaohui/k8m/pkg/service"
 	_ "github.com/weibaohui/k8m/swagger"
 	"github.com/weibaohui/kom/callbacks"
@@ -102,6 +106,9 @@ func Init() {
 	// 初始化ChatService
 	service.AIService().SetVars(InnerApiKey, InnerApiUrl, InnerModel)
 
+	// 启动审计日志外发
+	service.AuditService().Init()
+
 	go func() {
 		// 初始化kom
 		// 先注册回调，后面集群连接后，需要执行回调
@@ -156,17 +163,29 @@ func Init() {
 				RenewDeadline: 50 * time.Second, // 增加到50秒
 				RetryPeriod:   10 * time.Second, // 增加到10秒
 				OnStartedLeading: func(ctx context.Context) {
-					klog.V(2).Infof("[leader] 成为Leader，启动定时任务（集群巡检、Helm仓库更新）")
+					klog.V(2).Infof("[leader] 成为Leader，启动定时任务（集群巡检、Helm仓库更新、过期授权回收、日志清理、审批执行中断回收）")
 					lua.InitClusterInspection()
 					// 启动helm 更新repo定时任务
 					helm2.StartUpdateHelmRepoInBackground()
+					// 启动过期临时授权回收任务
+					service.ClusterAccessService().StartCleanupInBackground()
+					// 启动日志保留策略清理任务
+					retention.StartPurgeInBackground()
+					// 启动审批执行中断回收任务
+					service.ApprovalService().StartReconcileInBackground()
 				},
 				OnStoppedLeading: func() {
-					klog.V(2).Infof("[leader] 不再是Leader，停止定时任务（集群巡检、Helm仓库更新）")
+					klog.V(2).Infof("[leader] 不再是Leader，停止定时任务（集群巡检、Helm仓库更新、过期授权回收、日志清理、审批执行中断回收）")
 					// 停止集群巡检任务
 					lua.StopClusterInspection()
 					// 停止helm更新任务
 					helm2.StopUpdateHelmRepoInBackground()
+					// 停止过期临时授权回收任务
+					service.ClusterAccessService().StopCleanupInBackground()
+					// 停止日志保留策略清理任务
+					retention.StopPurgeInBackground()
+					// 停止审批执行中断回收任务
+					service.ApprovalService().StopReconcileInBackground()
 				},
 			}
 
@@ -219,13 +238,19 @@ func main() {
 	})
 
 	// MCP Server
-	sseServer := GetMcpSSEServer("/mcp/k8m/")
+	sseServer, httpServer := GetMcpServers("/mcp/k8m/")
 	r.GET("/mcp/k8m/sse", adapt(sseServer.SSEHandler))
 	r.POST("/mcp/k8m/sse", adapt(sseServer.SSEHandler))
 	r.POST("/mcp/k8m/message", adapt(sseServer.MessageHandler))
 	r.GET("/mcp/k8m/:key/sse", adapt(sseServer.SSEHandler))
 	r.POST("/mcp/k8m/:key/sse", adapt(sseServer.SSEHandler))
 	r.POST("/mcp/k8m/:key/message", adapt(sseServer.MessageHandler))
+	// Streamable HTTP 传输，GET 建立通知流，POST 发送请求，DELETE 结束会话
+	for _, path := range []string{"/mcp/k8m/http", "/mcp/k8m/:key/http"} {
+		r.GET(path, gin.WrapH(httpServer))
+		r.POST(path, gin.WrapH(httpServer))
+		r.DELETE(path, gin.WrapH(httpServer))
+	}
 
 	// @title           k8m API
 	// @version         1.0
@@ -372,6 +397,8 @@ func main() {
 		cluster.RegisterUserClusterRoutes(mgm)
 		// helm chart
 		helm.RegisterHelmChartRoutes(mgm)
+		// 高危操作审批
+		approval.RegisterApprovalRoutes(mgm)
 	}
 
 	admin := r.Group("/admin", middleware.PlatformAuthMiddleware())
@@ -386,8 +413,17 @@ func main() {
 		config.RegisterConfigRoutes(admin)
 		// 大模型列表管理
 		config.RegisterAIModelConfigRoutes(admin)
+		config.RegisterAIModelRouteRoutes(admin)
+		config.RegisterAIUsageRoutes(admin)
+		config.RegisterRedactionRoutes(admin)
+		// 审计日志外发
+		config.RegisterAuditSinkRoutes(admin)
+		// 日志保留策略
+		config.RegisterRetentionRoutes(admin)
 		// AI提示词管理
 		ai_prompt.RegisterAdminAIPromptRoutes(admin)
+		// 运维手册管理
+		runbook.RegisterAdminRunbookRoutes(admin)
 		// 集群巡检定时任务
 		inspection.RegisterAdminScheduleRoutes(admin)
 		// 集群巡检记录
@@ -401,6 +437,12 @@ func main() {
 		mcp.RegisterMCPToolRoutes(admin)
 		// 集群授权相关
 		user.RegisterClusterPermissionRoutes(admin)
+		// 自定义集群角色
+		user.RegisterAdminCustomRoleRoutes(admin)
+		// 高危操作审批策略
+		approval.RegisterAdminApprovalRoutes(admin)
+		// 进行中的终端会话观察、强制终止
+		terminal.RegisterAdminLiveSessionRoutes(admin)
 		// 用户管理相关
 		user.RegisterAdminUserRoutes(admin)
 		// 用户组管理相关
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	mcp2 "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"github.com/weibaohui/kom/kom"
+	komutils "github.com/weibaohui/kom/utils"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// k8m MCP 资源 URI，URI 中的集群ID使用不带填充的 URL 安全 Base64 编码，与页面路由一致
+const (
+	mcpURIClusters           = "k8m://clusters"
+	mcpURITemplateNamespaces = "k8m://{cluster}/namespaces"
+	mcpURITemplateResource   = "k8m://{cluster}/{namespace}/{kind}/{name}"
+	mcpURIInspections        = "k8m://inspection/records"
+	mcpURITemplateInspection = "k8m://inspection/records/{id}"
+)
+
+// mcpClusterScoped 集群级资源在 URI 中使用的命名空间占位
+const mcpClusterScoped = "_"
+
+// mcpInspectionLimit 巡检记录资源返回的最近记录数
+const mcpInspectionLimit = 20
+
+// mcpKnownKinds 常用资源类型对应的 group/version，其他类型在 URI 中使用 Kind.version.group 形式指定
+var mcpKnownKinds = map[string][2]string{
+	"Pod":                     {"", "v1"},
+	"Service":                 {"", "v1"},
+	"ConfigMap":               {"", "v1"},
+	"Secret":                  {"", "v1"},
+	"Node":                    {"", "v1"},
+	"Namespace":               {"", "v1"},
+	"Event":                   {"", "v1"},
+	"ServiceAccount":          {"", "v1"},
+	"PersistentVolume":        {"", "v1"},
+	"PersistentVolumeClaim":   {"", "v1"},
+	"Deployment":              {"apps", "v1"},
+	"StatefulSet":             {"apps", "v1"},
+	"DaemonSet":               {"apps", "v1"},
+	"ReplicaSet":              {"apps", "v1"},
+	"Job":                     {"batch", "v1"},
+	"CronJob":                 {"batch", "v1"},
+	"Ingress":                 {"networking.k8s.io", "v1"},
+	"NetworkPolicy":           {"networking.k8s.io", "v1"},
+	"StorageClass":            {"storage.k8s.io", "v1"},
+	"HorizontalPodAutoscaler": {"autoscaling", "v2"},
+}
+
+// promptVarPattern 提示词模板中的变量引用
+var promptVarPattern = regexp.MustCompile(`\$\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}`)
+
+// registerMcpResources 注册 MCP 资源：集群列表、命名空间、资源 YAML 及最近的巡检结果
+// 所有资源均按 MCP Key 对应用户的集群授权过滤，资源读取经过 kom 回调的权限校验
+func registerMcpResources(serv *server.MCPServer) {
+	serv.AddResource(mcp2.NewResource(mcpURIClusters, "集群列表",
+		mcp2.WithResourceDescription("当前用户有权限访问的已连接集群，id 用于拼接其他资源的 URI"),
+		mcp2.WithMIMEType("application/json"),
+	), readClustersResource)
+
+	serv.AddResourceTemplate(mcp2.NewResourceTemplate(mcpURITemplateNamespaces, "命名空间列表",
+		mcp2.WithTemplateDescription("集群中的命名空间，cluster 为集群列表中的 id"),
+		mcp2.WithTemplateMIMEType("application/json"),
+	), readNamespacesResource)
+
+	serv.AddResourceTemplate(mcp2.NewResourceTemplate(mcpURITemplateResource, "资源YAML",
+		mcp2.WithTemplateDescription("资源的 YAML。集群级资源的 namespace 为 _；常用类型直接使用 Kind（如 Deployment），其他类型使用 Kind.version.group（如 Certificate.v1.cert-manager.io）"),
+		mcp2.WithTemplateMIMEType("application/yaml"),
+	), readK8sResource)
+
+	serv.AddResource(mcp2.NewResource(mcpURIInspections, "最近巡检记录",
+		mcp2.WithResourceDescription(fmt.Sprintf("有权限访问的集群最近 %d 次巡检的状态、失败数及 AI 总结", mcpInspectionLimit)),
+		mcp2.WithMIMEType("application/json"),
+	), readInspectionsResource)
+
+	serv.AddResourceTemplate(mcp2.NewResourceTemplate(mcpURITemplateInspection, "巡检结果",
+		mcp2.WithTemplateDescription("指定巡检记录的失败检查项"),
+		mcp2.WithTemplateMIMEType("application/json"),
+	), readInspectionResource)
+}
+
+// registerMcpPrompts 将内置 AI 提示词注册为 MCP 提示词，渲染时按调用用户及集群选择提示词变体
+func registerMcpPrompts(serv *server.MCPServer) {
+	registered := map[constants.AIPromptType]bool{}
+	for _, p := range models.BuiltinAIPrompts {
+		if registered[p.PromptType] {
+			continue
+		}
+		registered[p.PromptType] = true
+
+		promptType := p.PromptType
+		opts := []mcp2.PromptOption{
+			mcp2.WithPromptDescription(p.Description),
+			mcp2.WithArgument("cluster", mcp2.ArgumentDescription("集群ID（集群列表中的 id），用于选择集群专属的提示词")),
+		}
+		seen := map[string]bool{}
+		for _, m := range promptVarPattern.FindAllStringSubmatch(p.Content, -1) {
+			if name := m[1]; !seen[name] {
+				seen[name] = true
+				opts = append(opts, mcp2.WithArgument(name))
+			}
+		}
+		serv.AddPrompt(mcp2.NewPrompt(string(promptType), opts...), func(ctx context.Context, request mcp2.GetPromptRequest) (*mcp2.GetPromptResult, error) {
+			return getMcpPrompt(ctx, promptType, request)
+		})
+	}
+}
+
+func getMcpPrompt(ctx context.Context, promptType constants.AIPromptType, request mcp2.GetPromptRequest) (*mcp2.GetPromptResult, error) {
+	username, err := mcpUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]any{}
+	for k, v := range request.Params.Arguments {
+		values[k] = v
+	}
+	if encoded := request.Params.Arguments["cluster"]; encoded != "" {
+		cluster, err := mcpCluster(ctx, username, encoded)
+		if err != nil {
+			return nil, err
+		}
+		values[models.AIPromptVarCluster] = cluster
+		ctx = service.AIUsageService().WithCluster(ctx, cluster)
+	}
+	text, err := service.PromptService().RenderPrompt(ctx, promptType, values)
+	if err != nil {
+		return nil, err
+	}
+	return mcp2.NewGetPromptResult("", []mcp2.PromptMessage{
+		mcp2.NewPromptMessage(mcp2.RoleUser, mcp2.NewTextContent(text)),
+	}), nil
+}
+
+func readClustersResource(ctx context.Context, request mcp2.ReadResourceRequest) ([]mcp2.ResourceContents, error) {
+	username, err := mcpUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var items []map[string]string
+	for _, cluster := range mcpClusters(ctx, username) {
+		id := base64.RawURLEncoding.EncodeToString([]byte(cluster))
+		items = append(items, map[string]string{
+			"id":             id,
+			"cluster":        cluster,
+			"namespaces_uri": "k8m://" + id + "/namespaces",
+		})
+	}
+	return jsonResource(request.Params.URI, items), nil
+}
+
+func readNamespacesResource(ctx context.Context, request mcp2.ReadResourceRequest) ([]mcp2.ResourceContents, error) {
+	username, err := mcpUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cluster, err := mcpCluster(ctx, username, resourceArg(request, "cluster"))
+	if err != nil {
+		return nil, err
+	}
+	var list []v1.Namespace
+	if err = kom.Cluster(cluster).WithContext(ctx).Resource(&v1.Namespace{}).List(&list).Error; err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(list))
+	for _, ns := range list {
+		names = append(names, ns.Name)
+	}
+	return jsonResource(request.Params.URI, names), nil
+}
+
+func readK8sResource(ctx context.Context, request mcp2.ReadResourceRequest) ([]mcp2.ResourceContents, error) {
+	username, err := mcpUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cluster, err := mcpCluster(ctx, username, resourceArg(request, "cluster"))
+	if err != nil {
+		return nil, err
+	}
+	ns := resourceArg(request, "namespace")
+	if ns == mcpClusterScoped {
+		ns = ""
+	}
+	group, version, kind, err := mcpResourceGVK(resourceArg(request, "kind"))
+	if err != nil {
+		return nil, err
+	}
+
+	var obj *unstructured.Unstructured
+	err = kom.Cluster(cluster).WithContext(ctx).RemoveManagedFields().Name(resourceArg(request, "name")).Namespace(ns).CRD(group, version, kind).Get(&obj).Error
+	if err != nil {
+		return nil, err
+	}
+	yamlStr, err := komutils.ConvertUnstructuredToYAML(obj)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp2.ResourceContents{mcp2.TextResourceContents{
+		URI:      request.Params.URI,
+		MIMEType: "application/yaml",
+		Text:     yamlStr,
+	}}, nil
+}
+
+func readInspectionsResource(ctx context.Context, request mcp2.ReadResourceRequest) ([]mcp2.ResourceContents, error) {
+	username, err := mcpUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var records []*models.InspectionRecord
+	err = dao.DB().Select("id", "schedule_name", "cluster", "trigger_type", "status", "start_time", "end_time", "error_count", "ai_summary").
+		Where("cluster in ?", mcpClusters(ctx, username)).
+		Order("id desc").Limit(mcpInspectionLimit).Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return jsonResource(request.Params.URI, records), nil
+}
+
+func readInspectionResource(ctx context.Context, request mcp2.ReadResourceRequest) ([]mcp2.ResourceContents, error) {
+	username, err := mcpUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var record models.InspectionRecord
+	if err = dao.DB().Where("id = ?", utils.ToUInt(resourceArg(request, "id"))).First(&record).Error; err != nil {
+		return nil, fmt.Errorf("巡检记录不存在")
+	}
+	if !slices.Contains(mcpClusters(ctx, username), record.Cluster) {
+		return nil, fmt.Errorf("无权限访问集群: %s", record.Cluster)
+	}
+	var events []*models.InspectionCheckEvent
+	err = dao.DB().Where("record_id = ? AND event_status <> ?", record.ID, constants.LuaEventStatusNormal).Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return jsonResource(request.Params.URI, map[string]any{
+		"record": record,
+		"events": events,
+	}), nil
+}
+
+// mcpUser 获取 MCP 调用方的用户名，未认证或MCP密钥已失效时返回错误
+func mcpUser(ctx context.Context) (string, error) {
+	username, _ := ctx.Value(constants.JwtUserName).(string)
+	if username == "" {
+		return "", fmt.Errorf("未认证的 MCP 调用，请使用 MCP Key 或登录令牌访问")
+	}
+	if _, err := service.McpKeyFromCtx(ctx); err != nil {
+		return "", err
+	}
+	return username, nil
+}
+
+// mcpClusters 获取用户有权限访问的已连接集群，使用MCP密钥时再按密钥允许的集群过滤
+func mcpClusters(ctx context.Context, username string) []string {
+	admin := service.UserService().IsUserPlatformAdmin(username)
+	var allowed []string
+	if !admin {
+		allowed, _ = service.UserService().GetClusterNames(username)
+	}
+	key, _ := service.McpKeyFromCtx(ctx)
+	var result []string
+	for _, c := range service.ClusterService().ConnectedClusters() {
+		id := c.GetClusterID()
+		if !admin && !slices.Contains(allowed, id) {
+			continue
+		}
+		if key != nil && !key.AllowCluster(id) {
+			continue
+		}
+		result = append(result, id)
+	}
+	return result
+}
+
+// mcpCluster 解析 URI 中编码的集群ID，并校验用户对该集群的访问权限
+func mcpCluster(ctx context.Context, username, encoded string) (string, error) {
+	b, err := utils.UrlSafeBase64Decode(encoded)
+	if err != nil || len(b) == 0 {
+		return "", fmt.Errorf("集群ID无效: %s", encoded)
+	}
+	cluster := string(b)
+	if !slices.Contains(mcpClusters(ctx, username), cluster) {
+		return "", fmt.Errorf("无权限访问集群或集群未连接: %s", cluster)
+	}
+	return cluster, nil
+}
+
+// mcpResourceGVK 解析 URI 中的资源类型，支持常用 Kind 及 Kind.version.group
+func mcpResourceGVK(s string) (group, version, kind string, err error) {
+	parts := strings.SplitN(s, ".", 3)
+	if len(parts) == 1 {
+		gv, ok := mcpKnownKinds[parts[0]]
+		if !ok {
+			return "", "", "", fmt.Errorf("未知的资源类型 %s，请使用 Kind.version.group 形式", s)
+		}
+		return gv[0], gv[1], parts[0], nil
+	}
+	if len(parts) == 2 {
+		// Kind.version 表示核心组
+		return "", parts[1], parts[0], nil
+	}
+	return parts[2], parts[1], parts[0], nil
+}
+
+func resourceArg(request mcp2.ReadResourceRequest, name string) string {
+	switch v := request.Params.Arguments[name].(type) {
+	case string:
+		return v
+	case []string:
+		if len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+func jsonResource(uri string, v any) []mcp2.ResourceContents {
+	return []mcp2.ResourceContents{mcp2.TextResourceContents{
+		URI:      uri,
+		MIMEType: "application/json",
+		Text:     utils.ToJSON(v),
+	}}
+}
@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/gin-gonic/gin"
@@ -17,6 +19,7 @@ import (
 	"github.com/weibaohui/k8m/pkg/service"
 	"github.com/weibaohui/kom/mcp"
 	"github.com/weibaohui/kom/mcp/tools"
+	"gorm.io/gorm"
 	"k8s.io/klog/v2"
 )
 
@@ -26,36 +29,7 @@ import (
 func createServerConfig(basePath string) *mcp.ServerConfig {
 	cfg := flag.Init()
 
-	var ctxFn = func(ctx context.Context, r *http.Request) context.Context {
-		newCtx := context.Background()
-
-		mcpKey := extractKey(r.URL.Path)
-		if mcpKey != "" {
-			username, err := service.UserService().GetUserByMCPKey(mcpKey)
-			if err != nil {
-				klog.V(6).Infof("Failed to extract username from mcpKey: %v", err)
-			}
-			if username != "" {
-				newCtx = context.WithValue(newCtx, constants.JwtUserName, username)
-				return newCtx
-			}
-
-		}
-
-		auth := r.Header.Get("Authorization")
-		// 处理 Bearer 前缀
-		if strings.HasPrefix(auth, "Bearer ") {
-			auth = strings.TrimPrefix(auth, "Bearer ")
-		}
-		klog.V(6).Infof("Authorization: %v", auth)
-		if username, err := utils.GetUsernameFromToken(auth, cfg.JwtTokenSecret); err == nil {
-			klog.V(6).Infof("Extracted username from token: %v", username)
-			newCtx = context.WithValue(newCtx, constants.JwtUserName, username)
-		} else {
-			klog.V(6).Infof("Failed to extract username from token: %v", err)
-		}
-		return newCtx
-	}
+	ctxFn := mcpContextFunc(cfg.JwtTokenSecret)
 
 	var errFn = func(ctx context.Context, id any, method mcp2.MCPMethod, message any, err error) {
 		if request, ok := message.(*mcp2.CallToolRequest); ok {
@@ -106,10 +80,11 @@ func createServerConfig(basePath string) *mcp.ServerConfig {
 		Name:    "k8m mcp server",
 		Version: cfg.Version,
 		ServerOptions: []server.ServerOption{
-			server.WithResourceCapabilities(false, false),
-			server.WithPromptCapabilities(false),
+			server.WithResourceCapabilities(false, true),
+			server.WithPromptCapabilities(true),
 			server.WithLogging(),
 			server.WithHooks(hooks),
+			server.WithToolHandlerMiddleware(mcpKeyToolMiddleware),
 		},
 		SSEOption: []server.SSEOption{
 			server.WithDynamicBasePath(func(r *http.Request, sessionID string) string {
@@ -120,12 +95,71 @@ func createServerConfig(basePath string) *mcp.ServerConfig {
 				return basePath + "/" + key
 			}),
 			server.WithStaticBasePath(basePath),
-			server.WithSSEContextFunc(ctxFn),
+			server.WithSSEContextFunc(server.SSEContextFunc(ctxFn)),
 		},
 		AuthKey: constants.JwtUserName,
 	}
 }
 
+// mcpContextFunc 返回 MCP 请求的上下文函数，SSE 与 Streamable HTTP 传输共用。
+// 优先使用路径中的 MCP Key 识别用户，其次解析 Authorization 头中的 JWT。
+func mcpContextFunc(jwtSecret string) server.HTTPContextFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		newCtx := context.Background()
+
+		mcpKey := extractKey(r.URL.Path)
+		if mcpKey != "" {
+			key, err := service.UserService().GetMcpKey(func(db *gorm.DB) *gorm.DB {
+				return db.Where("mcp_key = ?", mcpKey)
+			})
+			if err != nil {
+				klog.V(6).Infof("Failed to extract username from mcpKey: %v", err)
+			}
+			if key != nil {
+				newCtx = context.WithValue(newCtx, constants.JwtUserName, key.Username)
+				return service.WithMcpKey(newCtx, key)
+			}
+
+		}
+
+		auth := r.Header.Get("Authorization")
+		// 处理 Bearer 前缀
+		if strings.HasPrefix(auth, "Bearer ") {
+			auth = strings.TrimPrefix(auth, "Bearer ")
+		}
+		klog.V(6).Infof("Authorization: %v", auth)
+		if username, err := utils.GetUsernameFromToken(auth, jwtSecret); err == nil {
+			klog.V(6).Infof("Extracted username from token: %v", username)
+			// MCP密钥的令牌同样受密钥限制，停用或过期的密钥令牌不再可用
+			key, err := service.UserService().GetMcpKey(func(db *gorm.DB) *gorm.DB {
+				return db.Where("jwt = ?", auth)
+			})
+			switch {
+			case err == nil:
+				newCtx = service.WithMcpKey(newCtx, key)
+			case !errors.Is(err, gorm.ErrRecordNotFound):
+				klog.V(6).Infof("MCP key token rejected: %v", err)
+				return newCtx
+			}
+			newCtx = context.WithValue(newCtx, constants.JwtUserName, username)
+		} else {
+			klog.V(6).Infof("Failed to extract username from token: %v", err)
+		}
+		return newCtx
+	}
+}
+
+// mcpKeyToolMiddleware 在工具执行前按MCP密钥的工具名单、只读、集群及有效期限制进行检查，
+// 拒绝时返回错误，由 OnError 钩子记录调用日志。
+func mcpKeyToolMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp2.CallToolRequest) (*mcp2.CallToolResult, error) {
+		if err := service.CheckMcpKeyToolCall(ctx, request.Params.Name, request.GetArguments()); err != nil {
+			return nil, err
+		}
+		return next(ctx, request)
+	}
+}
+
 // SaveYamlTemplateTool 返回一个用于保存 Kubernetes YAML 模板的 MCP 工具定义。
 func SaveYamlTemplateTool() mcp2.Tool {
 	return mcp2.NewTool(
@@ -175,12 +209,21 @@ func SaveYamlTemplateToolHandler(ctx context.Context, request mcp2.CallToolReque
 	return tools.TextResult("保存成功", nil)
 }
 
-// GetMcpSSEServer 创建并返回一个集成了“保存K8s YAML模板”工具的MCP SSE服务器实例，支持基于JWT的用户身份提取与Gin框架适配。
-func GetMcpSSEServer(basePath string) *server.SSEServer {
+// GetMcpServers 创建集成了“保存K8s YAML模板”工具及资源、提示词的 MCP 服务器，
+// 并返回共用该服务器的 SSE 与 Streamable HTTP 传输，二者使用相同的认证上下文与调用日志钩子。
+func GetMcpServers(basePath string) (*server.SSEServer, *server.StreamableHTTPServer) {
 	sc := createServerConfig(basePath)
 	serv := mcp.GetMCPServerWithOption(sc)
 	serv.AddTool(SaveYamlTemplateTool(), SaveYamlTemplateToolHandler)
-	return mcp.GetMCPSSEServerWithServerAndOption(serv, sc)
+	registerMcpResources(serv)
+	registerMcpPrompts(serv)
+
+	cfg := flag.Init()
+	httpServer := server.NewStreamableHTTPServer(serv,
+		server.WithHTTPContextFunc(mcpContextFunc(cfg.JwtTokenSecret)),
+		server.WithHeartbeatInterval(30*time.Second),
+	)
+	return mcp.GetMCPSSEServerWithServerAndOption(serv, sc), httpServer
 }
 
 // adapt 将标准的 http.Handler 适配为 Gin 框架可用的处理函数。
@@ -191,10 +234,10 @@ func adapt(fn func() http.Handler) gin.HandlerFunc {
 	}
 }
 
-// 手动提取路径中 key（/mcp/k8m/:key/sse）
+// 手动提取路径中 key（/mcp/k8m/:key/sse、/mcp/k8m/:key/message、/mcp/k8m/:key/http）
 func extractKey(path string) string {
 	parts := strings.Split(path, "/")
-	endpoints := []string{"sse", "message"}
+	endpoints := []string{"sse", "message", "http"}
 	if len(parts) >= 5 && parts[1] == "mcp" && parts[2] == "k8m" && slice.Contain(endpoints, parts[4]) {
 		return parts[3]
 	}
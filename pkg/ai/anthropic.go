@@ -0,0 +1,353 @@
+package ai
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const anthropicClientName = "anthropic"
+
+const (
+	anthropicDefaultBaseURL   = "https://api.anthropic.com/v1"
+	anthropicVersion          = "2023-06-01"
+	anthropicDefaultMaxTokens = 4096
+)
+
+// AnthropicClient 使用 Anthropic Messages API 的客户端
+// 对话请求仍由 go-openai 发出，经 anthropicTransport 转换为 /v1/messages 请求
+type AnthropicClient struct {
+	OpenAIClient
+}
+
+func (c *AnthropicClient) GetName() string {
+	return anthropicClientName
+}
+
+func (c *AnthropicClient) Configure(config IAIConfig) error {
+	baseURL := strings.TrimRight(config.GetBaseURL(), "/")
+	if baseURL == "" {
+		baseURL = anthropicDefaultBaseURL
+	}
+	if !strings.HasSuffix(baseURL, "/v1") {
+		baseURL += "/v1"
+	}
+	maxTokens := config.GetMaxTokens()
+	if maxTokens <= 0 {
+		maxTokens = anthropicDefaultMaxTokens
+	}
+	cfg := openai.DefaultConfig("")
+	cfg.BaseURL = baseURL
+	return c.configure(anthropicClientName, config, cfg, func(origin http.RoundTripper) http.RoundTripper {
+		return &anthropicTransport{
+			origin:    origin,
+			endpoint:  baseURL + "/messages",
+			apiKey:    config.GetPassword(),
+			maxTokens: maxTokens,
+		}
+	})
+}
+
+// anthropicTransport 将 OpenAI 对话请求转换为 Anthropic Messages API 请求
+type anthropicTransport struct {
+	origin    http.RoundTripper
+	endpoint  string
+	apiKey    string
+	maxTokens int
+}
+
+type anthropicContent struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string             `json:"role"`
+	Content []anthropicContent `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature float32            `json:"temperature,omitempty"`
+	TopP        float32            `json:"top_p,omitempty"`
+	Stream      bool               `json:"stream,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	ID         string             `json:"id"`
+	Model      string             `json:"model"`
+	Content    []anthropicContent `json:"content"`
+	StopReason string             `json:"stop_reason"`
+	Usage      anthropicUsage     `json:"usage"`
+}
+
+// anthropicStreamEvent Anthropic 流式事件，不同事件类型使用其中不同的字段
+type anthropicStreamEvent struct {
+	Type         string             `json:"type"`
+	Index        int                `json:"index"`
+	Message      *anthropicResponse `json:"message,omitempty"`
+	ContentBlock *anthropicContent  `json:"content_block,omitempty"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *anthropicUsage `json:"usage,omitempty"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func (t *anthropicTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	chatReq, err := readChatCompletionRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	body := t.convertRequest(chatReq)
+	upstream, err := newUpstreamRequest(req, t.endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	upstream.Header.Set("x-api-key", t.apiKey)
+	upstream.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := t.origin.RoundTrip(upstream)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return upstreamError(req, resp)
+	}
+	if chatReq.Stream {
+		return streamResponse(req, chatReq.Model, func(w *sseWriter) error {
+			return t.convertStream(resp, w)
+		}), nil
+	}
+
+	defer resp.Body.Close()
+	var result anthropicResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return jsonResponse(req, http.StatusOK, t.convertResponse(&result))
+}
+
+// convertRequest 转换对话请求
+// 系统消息合并为 system 字段，工具结果作为 user 消息的 tool_result，连续相同角色的消息合并为一条
+func (t *anthropicTransport) convertRequest(chatReq *openai.ChatCompletionRequest) *anthropicRequest {
+	out := &anthropicRequest{
+		Model:     chatReq.Model,
+		MaxTokens: t.maxTokens,
+		Stream:    chatReq.Stream,
+	}
+	if chatReq.MaxTokens > 0 {
+		out.MaxTokens = chatReq.MaxTokens
+	}
+	if chatReq.Temperature > 0 {
+		out.Temperature = chatReq.Temperature
+	}
+	if chatReq.TopP > 0 && chatReq.TopP < 1 {
+		out.TopP = chatReq.TopP
+	}
+
+	var system []string
+	for _, m := range chatReq.Messages {
+		var role string
+		var content []anthropicContent
+		switch m.Role {
+		case openai.ChatMessageRoleSystem:
+			system = append(system, m.Content)
+			continue
+		case openai.ChatMessageRoleAssistant:
+			role = "assistant"
+			if m.Content != "" {
+				content = append(content, anthropicContent{Type: "text", Text: m.Content})
+			}
+			for _, call := range m.ToolCalls {
+				content = append(content, anthropicContent{
+					Type:  "tool_use",
+					ID:    call.ID,
+					Name:  call.Function.Name,
+					Input: toolArguments(call.Function.Arguments),
+				})
+			}
+		case openai.ChatMessageRoleTool:
+			role = "user"
+			content = append(content, anthropicContent{Type: "tool_result", ToolUseID: m.ToolCallID, Content: m.Content})
+		default:
+			role = "user"
+			content = append(content, anthropicContent{Type: "text", Text: m.Content})
+		}
+		if len(content) == 0 {
+			continue
+		}
+		if n := len(out.Messages); n > 0 && out.Messages[n-1].Role == role {
+			out.Messages[n-1].Content = append(out.Messages[n-1].Content, content...)
+			continue
+		}
+		out.Messages = append(out.Messages, anthropicMessage{Role: role, Content: content})
+	}
+	out.System = strings.Join(system, "\n\n")
+
+	for _, tool := range chatReq.Tools {
+		if tool.Function == nil {
+			continue
+		}
+		out.Tools = append(out.Tools, anthropicTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: toolParameters(tool),
+		})
+	}
+	return out
+}
+
+// convertResponse 转换非流式响应
+func (t *anthropicTransport) convertResponse(result *anthropicResponse) *openai.ChatCompletionResponse {
+	msg := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+	var texts []string
+	for _, block := range result.Content {
+		switch block.Type {
+		case "text":
+			texts = append(texts, block.Text)
+		case "tool_use":
+			msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+				ID:   block.ID,
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      block.Name,
+					Arguments: string(toolArguments(string(block.Input))),
+				},
+			})
+		}
+	}
+	msg.Content = strings.Join(texts, "")
+	return &openai.ChatCompletionResponse{
+		ID:     result.ID,
+		Object: "chat.completion",
+		Model:  result.Model,
+		Choices: []openai.ChatCompletionChoice{{
+			Index:        0,
+			Message:      msg,
+			FinishReason: anthropicFinishReason(result.StopReason),
+		}},
+		Usage: openai.Usage{
+			PromptTokens:     result.Usage.InputTokens,
+			CompletionTokens: result.Usage.OutputTokens,
+			TotalTokens:      result.Usage.InputTokens + result.Usage.OutputTokens,
+		},
+	}
+}
+
+// convertStream 转换流式响应
+// 文本增量转为 content 增量，tool_use 内容块转为按序号递增的 tool_calls 增量
+func (t *anthropicTransport) convertStream(resp *http.Response, w *sseWriter) error {
+	defer resp.Body.Close()
+	var usage openai.Usage
+	// Anthropic 内容块序号 -> OpenAI 工具调用序号
+	toolIndex := map[int]int{}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+			continue
+		}
+		var err error
+		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				usage.PromptTokens = event.Message.Usage.InputTokens
+			}
+		case "content_block_start":
+			if event.ContentBlock != nil && event.ContentBlock.Type == "tool_use" {
+				idx := len(toolIndex)
+				toolIndex[event.Index] = idx
+				err = w.chunk(openai.ChatCompletionStreamChoiceDelta{
+					ToolCalls: []openai.ToolCall{{
+						Index:    &idx,
+						ID:       event.ContentBlock.ID,
+						Type:     openai.ToolTypeFunction,
+						Function: openai.FunctionCall{Name: event.ContentBlock.Name},
+					}},
+				}, "", nil)
+			}
+		case "content_block_delta":
+			switch event.Delta.Type {
+			case "text_delta":
+				err = w.chunk(openai.ChatCompletionStreamChoiceDelta{Content: event.Delta.Text}, "", nil)
+			case "input_json_delta":
+				idx, ok := toolIndex[event.Index]
+				if ok && event.Delta.PartialJSON != "" {
+					err = w.chunk(openai.ChatCompletionStreamChoiceDelta{
+						ToolCalls: []openai.ToolCall{{
+							Index:    &idx,
+							Function: openai.FunctionCall{Arguments: event.Delta.PartialJSON},
+						}},
+					}, "", nil)
+				}
+			}
+		case "message_delta":
+			if event.Usage != nil {
+				usage.CompletionTokens = event.Usage.OutputTokens
+			}
+			usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+			err = w.chunk(openai.ChatCompletionStreamChoiceDelta{}, anthropicFinishReason(event.Delta.StopReason), &usage)
+		case "message_stop":
+			return nil
+		case "error":
+			if event.Error != nil {
+				return fmt.Errorf("%s: %s", event.Error.Type, event.Error.Message)
+			}
+			return fmt.Errorf("anthropic stream error")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// anthropicFinishReason 转换结束原因
+func anthropicFinishReason(reason string) openai.FinishReason {
+	switch reason {
+	case "tool_use":
+		return openai.FinishReasonToolCalls
+	case "max_tokens":
+		return openai.FinishReasonLength
+	case "":
+		return ""
+	default:
+		return openai.FinishReasonStop
+	}
+}
@@ -0,0 +1,37 @@
+package ai
+
+import (
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const azureClientName = "azure"
+
+// AzureOpenAIClient 使用 Azure OpenAI 的客户端
+// 请求地址为 <endpoint>/openai/deployments/<deployment>/chat/completions?api-version=<version>
+type AzureOpenAIClient struct {
+	OpenAIClient
+}
+
+func (c *AzureOpenAIClient) GetName() string {
+	return azureClientName
+}
+
+func (c *AzureOpenAIClient) Configure(config IAIConfig) error {
+	baseURL := config.GetBaseURL()
+	if baseURL == "" {
+		return fmt.Errorf("azure openai 需要配置 endpoint 地址")
+	}
+	cfg := openai.DefaultAzureConfig(config.GetPassword(), baseURL)
+	if version := config.GetAPIVersion(); version != "" {
+		cfg.APIVersion = version
+	}
+	// 部署名称未配置时沿用 go-openai 的默认规则，由模型名称推导
+	if deployment := config.GetEngine(); deployment != "" {
+		cfg.AzureModelMapperFunc = func(model string) string {
+			return deployment
+		}
+	}
+	return c.configure(azureClientName, config, cfg, nil)
+}
@@ -0,0 +1,97 @@
+package ai
+
+import (
+	"context"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"k8s.io/klog/v2"
+)
+
+// baseClient 各厂商客户端均内嵌 OpenAIClient，通过该接口取得底层客户端
+type baseClient interface {
+	base() *OpenAIClient
+}
+
+func (c *OpenAIClient) base() *OpenAIClient {
+	return c
+}
+
+// WithFallback 为主客户端设置备用客户端，主模型请求失败或超时后按顺序使用备用模型
+// 对话历史、工具仅使用主客户端的，备用客户端只提供模型连接；timeout 为单个模型的请求超时，0表示不限制
+func WithFallback(primary IAI, timeout time.Duration, fallbacks ...IAI) IAI {
+	p, ok := primary.(baseClient)
+	if !ok {
+		return primary
+	}
+	c := p.base()
+	c.timeout = timeout
+	c.fallbacks = nil
+	for _, f := range fallbacks {
+		if fb, ok := f.(baseClient); ok {
+			c.fallbacks = append(c.fallbacks, fb.base())
+		}
+	}
+	return primary
+}
+
+// candidates 依次尝试的客户端：自身及备用客户端
+func (c *OpenAIClient) candidates() []*OpenAIClient {
+	return append([]*OpenAIClient{c}, c.fallbacks...)
+}
+
+// attemptContext 单个模型请求的 ctx，超时后自动取消
+// 流式请求在建立连接后需调用 stop 停止计时，使后续读取不受超时影响
+func (c *OpenAIClient) attemptContext(ctx context.Context) (context.Context, func() bool, context.CancelFunc) {
+	attemptCtx, cancel := context.WithCancel(ctx)
+	if c.timeout <= 0 {
+		return attemptCtx, func() bool { return true }, cancel
+	}
+	timer := time.AfterFunc(c.timeout, cancel)
+	return attemptCtx, timer.Stop, cancel
+}
+
+// createChatCompletion 依次使用主模型及备用模型请求，build 按客户端的模型参数构造请求
+func (c *OpenAIClient) createChatCompletion(ctx context.Context, build func(cand *OpenAIClient) openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	var lastErr error
+	for _, cand := range c.candidates() {
+		attemptCtx, stop, cancel := c.attemptContext(ctx)
+		resp, err := cand.client.CreateChatCompletion(attemptCtx, build(cand))
+		stop()
+		cancel()
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+		klog.Warningf("模型 %s 请求失败: %v", cand.model, err)
+	}
+	return openai.ChatCompletionResponse{}, lastErr
+}
+
+// createChatCompletionStream 流式请求，建立连接失败或超时时使用下一个模型
+func (c *OpenAIClient) createChatCompletionStream(ctx context.Context, build func(cand *OpenAIClient) openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+	var lastErr error
+	for _, cand := range c.candidates() {
+		attemptCtx, stop, cancel := c.attemptContext(ctx)
+		stream, err := cand.client.CreateChatCompletionStream(attemptCtx, build(cand))
+		if err == nil && stop() {
+			// 流读取期间需保持 ctx 有效，调用方关闭流或父 ctx 结束时释放
+			return stream, nil
+		}
+		if err == nil {
+			// 计时器已触发，连接随 ctx 取消而失效
+			_ = stream.Close()
+			err = context.DeadlineExceeded
+		}
+		cancel()
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+		klog.Warningf("模型 %s 流式请求失败: %v", cand.model, err)
+	}
+	return nil, lastErr
+}
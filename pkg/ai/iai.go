@@ -55,11 +55,22 @@ type IAIConfig interface {
 	GetCompartmentId() string
 	GetOrganizationId() string
 	GetCustomHeaders() []http.Header
+	GetAPIVersion() string
+	GetModelConfigID() uint
 }
 
+// NewClient 按厂商创建客户端，未知厂商按 OpenAI 兼容接口处理
 func NewClient(provider string) IAI {
-	// default client
-	return &OpenAIClient{}
+	switch provider {
+	case anthropicClientName:
+		return &AnthropicClient{}
+	case ollamaClientName:
+		return &OllamaClient{}
+	case azureClientName:
+		return &AzureOpenAIClient{}
+	default:
+		return &OpenAIClient{}
+	}
 }
 
 type Configuration struct {
@@ -86,6 +97,8 @@ type Provider struct {
 	MaxTokens      int
 	OrganizationId string
 	CustomHeaders  []http.Header
+	APIVersion     string
+	ModelConfigID  uint // 对应的模型配置ID，用于按模型配置的单价计算费用
 }
 
 func (p *Provider) GetBaseURL() string {
@@ -150,6 +163,14 @@ func (p *Provider) GetCustomHeaders() []http.Header {
 	return p.CustomHeaders
 }
 
+func (p *Provider) GetAPIVersion() string {
+	return p.APIVersion
+}
+
+func (p *Provider) GetModelConfigID() uint {
+	return p.ModelConfigID
+}
+
 var passwordlessProviders = []string{"localai", "ollama", "amazonsagemaker", "amazonbedrock", "googlevertexai", "oci"}
 
 func NeedPassword(backend string) bool {
@@ -1,55 +1,97 @@
 package ai
 
 import (
+	"context"
 	"sync"
 
 	"github.com/sashabaranov/go-openai"
+	"github.com/weibaohui/k8m/pkg/constants"
 )
 
+// HistoryStore 对话历史存储
+// 由实现方根据 ctx 中的用户名或会话ID隔离历史，GetHistory 的 limit 大于0时仅返回最近 limit 条
+type HistoryStore interface {
+	GetHistory(ctx context.Context, limit int) []openai.ChatCompletionMessage
+	AppendHistory(ctx context.Context, msgs ...openai.ChatCompletionMessage)
+	ClearHistory(ctx context.Context) error
+}
+
+// sessionStore 持久化的会话历史存储，ctx 中携带会话ID时使用
+var sessionStore HistoryStore
+
+// RegisterSessionStore 注册持久化的会话历史存储
+func RegisterSessionStore(store HistoryStore) {
+	sessionStore = store
+}
+
+// WithSessionID 在 ctx 中携带会话ID，对话历史将保存到该会话
+func WithSessionID(ctx context.Context, id uint) context.Context {
+	return context.WithValue(ctx, constants.ChatSessionID, id)
+}
+
+// SessionIDFromContext 获取 ctx 中携带的会话ID，未携带时返回0
+func SessionIDFromContext(ctx context.Context) uint {
+	id, _ := ctx.Value(constants.ChatSessionID).(uint)
+	return id
+}
+
+// WithReferences 在 ctx 中携带参考资料，请求大模型时作为系统消息附在系统提示之后，不写入对话历史
+func WithReferences(ctx context.Context, references string) context.Context {
+	if references == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, constants.ChatReferences, references)
+}
+
 // MemoryService 用于按用户隔离存储和获取对话历史
 // 线程安全，适合多用户在线服务场景
-// 历史数据以用户名为 key 进行隔离
+// 历史数据以用户名为 key 进行隔离，仅保存在当前进程内，用于不需要持久化的一次性对话
 
 type memoryService struct {
 	mu      sync.RWMutex
 	storage map[string][]openai.ChatCompletionMessage // 用户名 -> 对话历史
+	limit   int                                       // 每个用户最多保留的条数，0表示不限制
 }
 
 // NewMemoryService 创建 MemoryService 实例
-func NewMemoryService() *memoryService {
+func NewMemoryService(limit int) *memoryService {
 
 	return &memoryService{
 		storage: make(map[string][]openai.ChatCompletionMessage),
+		limit:   limit,
 	}
 }
 
-// GetUserHistory 获取指定用户的对话历史
-func (m *memoryService) GetUserHistory(username string) []openai.ChatCompletionMessage {
+// GetHistory 获取当前用户的对话历史
+func (m *memoryService) GetHistory(ctx context.Context, limit int) []openai.ChatCompletionMessage {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	history := m.storage[username]
+	history := m.storage[getUsernameFromContext(ctx)]
+	if limit > 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
 	// 返回副本，避免外部修改
 	copied := make([]openai.ChatCompletionMessage, len(history))
 	copy(copied, history)
 	return copied
 }
 
-// AppendUserHistory 向指定用户追加一条历史记录
-func (m *memoryService) AppendUserHistory(username string, msg openai.ChatCompletionMessage) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.storage[username] = append(m.storage[username], msg)
-}
-
-// ClearUserHistory 清空指定用户的历史记录
-func (m *memoryService) ClearUserHistory(username string) {
+// AppendHistory 向当前用户追加历史记录，超出上限时丢弃最早的记录
+func (m *memoryService) AppendHistory(ctx context.Context, msgs ...openai.ChatCompletionMessage) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	delete(m.storage, username)
+	username := getUsernameFromContext(ctx)
+	history := append(m.storage[username], msgs...)
+	if m.limit > 0 && len(history) > m.limit {
+		history = append([]openai.ChatCompletionMessage(nil), history[len(history)-m.limit:]...)
+	}
+	m.storage[username] = history
 }
 
-func (m *memoryService) SetUserHistory(username string, history []openai.ChatCompletionMessage) {
+// ClearHistory 清空当前用户的历史记录
+func (m *memoryService) ClearHistory(ctx context.Context) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.storage[username] = history
+	delete(m.storage, getUsernameFromContext(ctx))
+	return nil
 }
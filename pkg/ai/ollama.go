@@ -0,0 +1,258 @@
+package ai
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const ollamaClientName = "ollama"
+
+const ollamaDefaultBaseURL = "http://localhost:11434"
+
+// OllamaClient 使用 Ollama 原生 /api/chat 接口的客户端
+// 对话请求仍由 go-openai 发出，经 ollamaTransport 转换
+type OllamaClient struct {
+	OpenAIClient
+}
+
+func (c *OllamaClient) GetName() string {
+	return ollamaClientName
+}
+
+func (c *OllamaClient) Configure(config IAIConfig) error {
+	baseURL := strings.TrimSuffix(strings.TrimRight(config.GetBaseURL(), "/"), "/v1")
+	if baseURL == "" {
+		baseURL = ollamaDefaultBaseURL
+	}
+	cfg := openai.DefaultConfig("")
+	cfg.BaseURL = baseURL
+	return c.configure(ollamaClientName, config, cfg, func(origin http.RoundTripper) http.RoundTripper {
+		return &ollamaTransport{
+			origin:    origin,
+			endpoint:  baseURL + "/api/chat",
+			apiKey:    config.GetPassword(),
+			topK:      config.GetTopK(),
+			maxTokens: config.GetMaxTokens(),
+		}
+	})
+}
+
+// ollamaTransport 将 OpenAI 对话请求转换为 Ollama /api/chat 请求
+type ollamaTransport struct {
+	origin    http.RoundTripper
+	endpoint  string
+	apiKey    string // Ollama 本身无需认证，部署在鉴权代理之后时使用
+	topK      int32
+	maxTokens int
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []openai.Tool   `json:"tools,omitempty"`
+	Stream   bool            `json:"stream"`
+	Options  map[string]any  `json:"options,omitempty"`
+}
+
+type ollamaResponse struct {
+	Model           string        `json:"model"`
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+func (t *ollamaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	chatReq, err := readChatCompletionRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	upstream, err := newUpstreamRequest(req, t.endpoint, t.convertRequest(chatReq))
+	if err != nil {
+		return nil, err
+	}
+	if t.apiKey != "" {
+		upstream.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+
+	resp, err := t.origin.RoundTrip(upstream)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return upstreamError(req, resp)
+	}
+	if chatReq.Stream {
+		return streamResponse(req, chatReq.Model, func(w *sseWriter) error {
+			return t.convertStream(resp, w)
+		}), nil
+	}
+
+	defer resp.Body.Close()
+	var result ollamaResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("ollama error: %s", result.Error)
+	}
+	msg := openai.ChatCompletionMessage{
+		Role:      openai.ChatMessageRoleAssistant,
+		Content:   result.Message.Content,
+		ToolCalls: ollamaToolCalls(result.Message.ToolCalls, 0),
+	}
+	return jsonResponse(req, http.StatusOK, &openai.ChatCompletionResponse{
+		ID:     fmt.Sprintf("chatcmpl-%s", result.Model),
+		Object: "chat.completion",
+		Model:  result.Model,
+		Choices: []openai.ChatCompletionChoice{{
+			Index:        0,
+			Message:      msg,
+			FinishReason: ollamaFinishReason(result.DoneReason, len(msg.ToolCalls) > 0),
+		}},
+		Usage: ollamaUsage(&result),
+	})
+}
+
+// convertRequest 转换对话请求，工具定义与 OpenAI 格式一致，工具调用参数由字符串转为 JSON 对象
+func (t *ollamaTransport) convertRequest(chatReq *openai.ChatCompletionRequest) *ollamaRequest {
+	out := &ollamaRequest{
+		Model:  chatReq.Model,
+		Tools:  chatReq.Tools,
+		Stream: chatReq.Stream,
+	}
+	options := map[string]any{}
+	if chatReq.Temperature > 0 {
+		options["temperature"] = chatReq.Temperature
+	}
+	if chatReq.TopP > 0 {
+		options["top_p"] = chatReq.TopP
+	}
+	if t.topK > 0 {
+		options["top_k"] = t.topK
+	}
+	if chatReq.MaxTokens > 0 {
+		options["num_predict"] = chatReq.MaxTokens
+	} else if t.maxTokens > 0 {
+		options["num_predict"] = t.maxTokens
+	}
+	if len(options) > 0 {
+		out.Options = options
+	}
+
+	// 工具调用ID -> 工具名称，Ollama 的工具结果消息以工具名称关联
+	toolNames := map[string]string{}
+	for _, m := range chatReq.Messages {
+		msg := ollamaMessage{Role: m.Role, Content: m.Content}
+		for _, call := range m.ToolCalls {
+			var tc ollamaToolCall
+			tc.Function.Name = call.Function.Name
+			tc.Function.Arguments = toolArguments(call.Function.Arguments)
+			msg.ToolCalls = append(msg.ToolCalls, tc)
+			toolNames[call.ID] = call.Function.Name
+		}
+		if m.Role == openai.ChatMessageRoleTool {
+			msg.ToolName = toolNames[m.ToolCallID]
+		}
+		out.Messages = append(out.Messages, msg)
+	}
+	return out
+}
+
+// convertStream 转换流式响应，Ollama 流式响应为逐行 JSON
+func (t *ollamaTransport) convertStream(resp *http.Response, w *sseWriter) error {
+	defer resp.Body.Close()
+	toolCount := 0
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var result ollamaResponse
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			continue
+		}
+		if result.Error != "" {
+			return fmt.Errorf("ollama error: %s", result.Error)
+		}
+		delta := openai.ChatCompletionStreamChoiceDelta{Content: result.Message.Content}
+		if len(result.Message.ToolCalls) > 0 {
+			delta.ToolCalls = ollamaToolCalls(result.Message.ToolCalls, toolCount)
+			toolCount += len(delta.ToolCalls)
+		}
+		if delta.Content != "" || len(delta.ToolCalls) > 0 {
+			if err := w.chunk(delta, "", nil); err != nil {
+				return err
+			}
+		}
+		if result.Done {
+			usage := ollamaUsage(&result)
+			return w.chunk(openai.ChatCompletionStreamChoiceDelta{}, ollamaFinishReason(result.DoneReason, toolCount > 0), &usage)
+		}
+	}
+	return scanner.Err()
+}
+
+// ollamaToolCalls 转换工具调用，Ollama 不返回调用ID，按序号生成
+func ollamaToolCalls(calls []ollamaToolCall, offset int) []openai.ToolCall {
+	var out []openai.ToolCall
+	for i, call := range calls {
+		idx := offset + i
+		arguments := "{}"
+		if len(call.Function.Arguments) > 0 {
+			arguments = string(call.Function.Arguments)
+		}
+		out = append(out, openai.ToolCall{
+			Index: &idx,
+			ID:    fmt.Sprintf("call_%d", idx),
+			Type:  openai.ToolTypeFunction,
+			Function: openai.FunctionCall{
+				Name:      call.Function.Name,
+				Arguments: arguments,
+			},
+		})
+	}
+	return out
+}
+
+func ollamaUsage(result *ollamaResponse) openai.Usage {
+	return openai.Usage{
+		PromptTokens:     result.PromptEvalCount,
+		CompletionTokens: result.EvalCount,
+		TotalTokens:      result.PromptEvalCount + result.EvalCount,
+	}
+}
+
+// ollamaFinishReason 转换结束原因，返回了工具调用时为 tool_calls
+func ollamaFinishReason(reason string, hasToolCalls bool) openai.FinishReason {
+	if hasToolCalls {
+		return openai.FinishReasonToolCalls
+	}
+	if reason == "length" {
+		return openai.FinishReasonLength
+	}
+	return openai.FinishReasonStop
+}
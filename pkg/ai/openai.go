@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/sashabaranov/go-openai"
 	"k8s.io/klog/v2"
@@ -19,7 +20,10 @@ type OpenAIClient struct {
 	topP        float32
 	tools       []openai.Tool
 	maxHistory  int32
-	memory      *memoryService
+	memory      HistoryStore
+	fallbacks   []*OpenAIClient // 备用模型，主模型请求失败或超时后依次使用
+	timeout     time.Duration   // 单个模型的请求超时，0表示不限制
+	streamUsage bool            // 流式请求是否携带 stream_options 要求返回用量
 
 	// organizationId string
 }
@@ -58,13 +62,23 @@ func (c *OpenAIClient) Configure(config IAIConfig) error {
 	token := config.GetPassword()
 	cfg := openai.DefaultConfig(token)
 	orgId := config.GetOrganizationId()
-	proxyEndpoint := config.GetProxyEndpoint()
 
 	baseURL := config.GetBaseURL()
 	if baseURL != "" {
 		cfg.BaseURL = baseURL
 	}
 
+	if orgId != "" {
+		cfg.OrgID = orgId
+	}
+	return c.configure(openAIClientName, config, cfg, nil)
+}
+
+// configure 按 go-openai 配置创建客户端，provider 为厂商名称，用于用量统计
+// wrap 不为空时包装底层 HTTP Transport，用于将 OpenAI 协议的请求转换为其他厂商的原生协议
+func (c *OpenAIClient) configure(provider string, config IAIConfig, cfg openai.ClientConfig, wrap func(origin http.RoundTripper) http.RoundTripper) error {
+	proxyEndpoint := config.GetProxyEndpoint()
+
 	transport := &http.Transport{}
 	if proxyEndpoint != "" {
 		proxyUrl, err := url.Parse(proxyEndpoint)
@@ -77,16 +91,22 @@ func (c *OpenAIClient) Configure(config IAIConfig) error {
 		transport.Proxy = http.ProxyFromEnvironment
 	}
 
-	if orgId != "" {
-		cfg.OrgID = orgId
-	}
-
 	customHeaders := config.GetCustomHeaders()
+	var roundTripper http.RoundTripper = &OpenAIHeaderTransport{
+		Origin:  transport,
+		Headers: customHeaders,
+	}
+	if wrap != nil {
+		roundTripper = wrap(roundTripper)
+	}
+	roundTripper = &redactTransport{origin: roundTripper}
+	roundTripper = &usageTransport{
+		origin:        roundTripper,
+		provider:      provider,
+		modelConfigID: config.GetModelConfigID(),
+	}
 	cfg.HTTPClient = &http.Client{
-		Transport: &OpenAIHeaderTransport{
-			Origin:  transport,
-			Headers: customHeaders,
-		},
+		Transport: roundTripper,
 	}
 
 	client := openai.NewClientWithConfig(cfg)
@@ -99,6 +119,27 @@ func (c *OpenAIClient) Configure(config IAIConfig) error {
 	c.temperature = config.GetTemperature()
 	c.topP = config.GetTopP()
 	c.maxHistory = config.GetMaxHistory()
-	c.memory = NewMemoryService()
+	c.memory = NewMemoryService(int(c.maxHistory))
+	c.streamUsage = supportsStreamUsage(provider, cfg.APIVersion)
 	return nil
 }
+
+// azureStreamUsageVersion Azure OpenAI 自该 api-version 起支持 stream_options
+const azureStreamUsageVersion = "2024-09-01"
+
+// supportsStreamUsage 判断厂商是否接受 stream_options 参数
+// Azure 较早的 api-version（包括默认的 2023-05-15）会以 400 拒绝该参数，此时由用量统计按内容估算
+func supportsStreamUsage(provider, apiVersion string) bool {
+	if provider == azureClientName {
+		return apiVersion >= azureStreamUsageVersion
+	}
+	return true
+}
+
+// streamOptions 流式请求的 stream_options，厂商不支持时返回 nil
+func (c *OpenAIClient) streamOptions() *openai.StreamOptions {
+	if !c.streamUsage {
+		return nil
+	}
+	return &openai.StreamOptions{IncludeUsage: true}
+}
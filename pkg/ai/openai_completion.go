@@ -27,33 +27,41 @@ func (c *OpenAIClient) processThinkFlag(contents ...any) []any {
 }
 
 func (c *OpenAIClient) GetCompletion(ctx context.Context, contents ...any) (string, error) {
+	if err := checkQuota(ctx); err != nil {
+		return "", err
+	}
 	contents = c.processThinkFlag(contents...)
-	c.fillChatHistory(ctx, contents)
+	messages := c.fillChatHistory(ctx, contents)
 
 	// Create a completion request
-	resp, err := c.client.CreateChatCompletion(ctx,
-		openai.ChatCompletionRequest{
-			Model:    c.model,
-			Messages: c.GetHistory(ctx),
-		})
+	resp, err := c.createChatCompletion(ctx, func(cand *OpenAIClient) openai.ChatCompletionRequest {
+		return openai.ChatCompletionRequest{
+			Model:    cand.model,
+			Messages: messages,
+		}
+	})
 	if err != nil {
 		return "", err
 	}
 	return resp.Choices[0].Message.Content, nil
 }
 func (c *OpenAIClient) GetCompletionWithTools(ctx context.Context, contents ...any) ([]openai.ToolCall, string, error) {
+	if err := checkQuota(ctx); err != nil {
+		return nil, "", err
+	}
 	contents = c.processThinkFlag(contents...)
 
 	// Create a completion request
-	c.fillChatHistory(ctx, contents)
-	resp, err := c.client.CreateChatCompletion(ctx,
-		openai.ChatCompletionRequest{
-			Model:       c.model,
-			Messages:    c.GetHistory(ctx),
-			Temperature: c.temperature,
-			TopP:        c.topP,
+	messages := c.fillChatHistory(ctx, contents)
+	resp, err := c.createChatCompletion(ctx, func(cand *OpenAIClient) openai.ChatCompletionRequest {
+		return openai.ChatCompletionRequest{
+			Model:       cand.model,
+			Messages:    messages,
+			Temperature: cand.temperature,
+			TopP:        cand.topP,
 			Tools:       c.tools,
-		})
+		}
+	})
 	if err != nil {
 		return nil, "", err
 	}
@@ -61,29 +69,42 @@ func (c *OpenAIClient) GetCompletionWithTools(ctx context.Context, contents ...a
 }
 
 func (c *OpenAIClient) GetStreamCompletion(ctx context.Context, contents ...any) (*openai.ChatCompletionStream, error) {
+	if err := checkQuota(ctx); err != nil {
+		return nil, err
+	}
 	contents = c.processThinkFlag(contents...)
 
-	c.fillChatHistory(ctx, contents)
-	stream, err := c.client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
-		Model:       c.model,
-		Messages:    c.GetHistory(ctx),
-		Temperature: c.temperature,
-		TopP:        c.topP,
-		Stream:      true,
+	messages := c.fillChatHistory(ctx, contents)
+	stream, err := c.createChatCompletionStream(ctx, func(cand *OpenAIClient) openai.ChatCompletionRequest {
+		return openai.ChatCompletionRequest{
+			Model:       cand.model,
+			Messages:    messages,
+			Temperature: cand.temperature,
+			TopP:        cand.topP,
+			Stream:      true,
+			// 流式响应默认不含用量，需显式要求在最后一个分片中返回
+			StreamOptions: cand.streamOptions(),
+		}
 	})
 	return stream, err
 }
 func (c *OpenAIClient) GetStreamCompletionWithTools(ctx context.Context, contents ...any) (*openai.ChatCompletionStream, error) {
+	if err := checkQuota(ctx); err != nil {
+		return nil, err
+	}
 	contents = c.processThinkFlag(contents...)
 
-	c.fillChatHistory(ctx, contents)
-	stream, err := c.client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
-		Model:    c.model,
-		Messages: c.GetHistory(ctx),
-		Tools:    c.tools,
-		Stream:   true,
+	messages := c.fillChatHistory(ctx, contents)
+	stream, err := c.createChatCompletionStream(ctx, func(cand *OpenAIClient) openai.ChatCompletionRequest {
+		return openai.ChatCompletionRequest{
+			Model:         cand.model,
+			Messages:      messages,
+			Tools:         c.tools,
+			Stream:        true,
+			StreamOptions: cand.streamOptions(),
+		}
 	})
-	klog.V(6).Infof("GetStreamCompletionWithTools 携带 history length: %d", len(c.GetHistory(ctx)))
-	klog.V(8).Infof("GetStreamCompletionWithTools c.history: %v", utils.ToJSON(c.GetHistory(ctx)))
+	klog.V(6).Infof("GetStreamCompletionWithTools 携带 history length: %d", len(messages))
+	klog.V(8).Infof("GetStreamCompletionWithTools c.history: %v", utils.ToJSON(messages))
 	return stream, err
 }
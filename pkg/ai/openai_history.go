@@ -21,44 +21,50 @@ func getUsernameFromContext(ctx context.Context) string {
 	return username
 }
 
+// historyStore 选择对话历史存储：ctx 中携带会话ID时使用持久化的会话存储，否则使用进程内存储
+func (c *OpenAIClient) historyStore(ctx context.Context) HistoryStore {
+	if sessionStore != nil && SessionIDFromContext(ctx) > 0 {
+		return sessionStore
+	}
+	return c.memory
+}
+
 func (c *OpenAIClient) SaveAIHistory(ctx context.Context, contents string) {
-	username := getUsernameFromContext(ctx)
-	c.memory.AppendUserHistory(username, openai.ChatCompletionMessage{
+	c.historyStore(ctx).AppendHistory(ctx, openai.ChatCompletionMessage{
 		Role:    openai.ChatMessageRoleAssistant,
 		Content: contents,
 	})
 }
 
 func (c *OpenAIClient) GetHistory(ctx context.Context) []openai.ChatCompletionMessage {
-	username := getUsernameFromContext(ctx)
-	return c.memory.GetUserHistory(username)
+	return c.historyStore(ctx).GetHistory(ctx, 0)
 }
 
 func (c *OpenAIClient) ClearHistory(ctx context.Context) error {
-	username := getUsernameFromContext(ctx)
-	c.memory.ClearUserHistory(username)
-	return nil
+	return c.historyStore(ctx).ClearHistory(ctx)
 }
-func (c *OpenAIClient) fillChatHistory(ctx context.Context, contents ...any) {
-	history := c.GetHistory(ctx)
+
+// fillChatHistory 将本轮输入追加到对话历史，并返回发送给大模型的消息：系统提示 + 参考资料 + 最近 maxHistory 条历史
+func (c *OpenAIClient) fillChatHistory(ctx context.Context, contents ...any) []openai.ChatCompletionMessage {
+	var messages []openai.ChatCompletionMessage
 	for _, content := range contents {
 		switch item := content.(type) {
 		case string:
 			klog.V(2).Infof("Adding user message to history: %v", item)
-			history = append(history, openai.ChatCompletionMessage{
+			messages = append(messages, openai.ChatCompletionMessage{
 				Role:    openai.ChatMessageRoleUser,
 				Content: item,
 			})
 		case models.MCPToolCallResult:
 			klog.V(2).Infof("Adding user message to history: %v", item)
-			history = append(history, openai.ChatCompletionMessage{
+			messages = append(messages, openai.ChatCompletionMessage{
 				Role:    openai.ChatMessageRoleUser,
 				Content: utils.ToJSON(item),
 			})
 		case []string:
 			klog.V(2).Infof("Adding string array to history: %v", item)
 			for _, m := range item {
-				history = append(history, openai.ChatCompletionMessage{
+				messages = append(messages, openai.ChatCompletionMessage{
 					Role:    openai.ChatMessageRoleUser,
 					Content: m,
 				})
@@ -66,14 +72,14 @@ func (c *OpenAIClient) fillChatHistory(ctx context.Context, contents ...any) {
 		case []models.MCPToolCallResult:
 			klog.V(2).Infof("Adding MCPToolCallResult array to history: %v", item)
 			for _, m := range item {
-				history = append(history, openai.ChatCompletionMessage{
+				messages = append(messages, openai.ChatCompletionMessage{
 					Role:    openai.ChatMessageRoleUser,
 					Content: utils.ToJSON(m),
 				})
 			}
 		case []any:
 			for _, m := range item {
-				history = append(history, openai.ChatCompletionMessage{
+				messages = append(messages, openai.ChatCompletionMessage{
 					Role:    openai.ChatMessageRoleUser,
 					Content: utils.ToJSON(m),
 				})
@@ -83,29 +89,26 @@ func (c *OpenAIClient) fillChatHistory(ctx context.Context, contents ...any) {
 		}
 	}
 
-	// 保留最后 maxHistory 条（含系统提示）
-	if c.maxHistory > 0 && int32(len(history)) > c.maxHistory {
-		keep := history[len(history)-int(c.maxHistory):]
-		history = keep
+	store := c.historyStore(ctx)
+	if len(messages) > 0 {
+		store.AppendHistory(ctx, messages...)
 	}
 
-	system := slice.Filter(history, func(index int, item openai.ChatCompletionMessage) bool {
-		if item.Role == openai.ChatMessageRoleSystem {
-			return true
-		}
-		return false
+	// 保留最后 maxHistory 条，系统提示不计入历史，每次请求时置于最前面
+	history := store.GetHistory(ctx, int(c.maxHistory))
+	history = slice.Filter(history, func(index int, item openai.ChatCompletionMessage) bool {
+		return item.Role != openai.ChatMessageRoleSystem
 	})
-
-	if len(system) == 0 {
-		// 创建系统消息
-		sysMsg := openai.ChatCompletionMessage{
+	sysMsg := openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: sysPrompt,
+	}
+	messages = []openai.ChatCompletionMessage{sysMsg}
+	if refs, _ := ctx.Value(constants.ChatReferences).(string); refs != "" {
+		messages = append(messages, openai.ChatCompletionMessage{
 			Role:    openai.ChatMessageRoleSystem,
-			Content: sysPrompt,
-		}
-		// 将系统消息插入到历史记录最前面
-		history = append([]openai.ChatCompletionMessage{sysMsg}, history...)
+			Content: refs,
+		})
 	}
-	username := getUsernameFromContext(ctx)
-	c.memory.SetUserHistory(username, history)
-
+	return append(messages, history...)
 }
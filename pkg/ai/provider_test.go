@@ -0,0 +1,410 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/weibaohui/k8m/pkg/redact"
+)
+
+var testTools = []openai.Tool{{
+	Type: openai.ToolTypeFunction,
+	Function: &openai.FunctionDefinition{
+		Name:        "list_pods",
+		Description: "列出Pod",
+		Parameters:  map[string]any{"type": "object", "properties": map[string]any{"namespace": map[string]any{"type": "string"}}},
+	},
+}}
+
+// readStream 读取流式响应，返回拼接的文本及合并后的工具调用
+func readStream(t *testing.T, stream *openai.ChatCompletionStream) (string, []openai.ToolCall) {
+	t.Helper()
+	defer stream.Close()
+	var sb strings.Builder
+	calls := map[int]*openai.ToolCall{}
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Choices) == 0 {
+			t.Fatal("stream chunk without choices")
+		}
+		sb.WriteString(resp.Choices[0].Delta.Content)
+		for _, call := range resp.Choices[0].Delta.ToolCalls {
+			if existing, ok := calls[*call.Index]; ok {
+				existing.Function.Arguments += call.Function.Arguments
+				continue
+			}
+			c := call
+			calls[*call.Index] = &c
+		}
+	}
+	var out []openai.ToolCall
+	for i := 0; i < len(calls); i++ {
+		out = append(out, *calls[i])
+	}
+	return sb.String(), out
+}
+
+func TestAnthropicClient(t *testing.T) {
+	var got anthropicRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "key" || r.Header.Get("anthropic-version") == "" {
+			http.Error(w, "bad request "+r.URL.Path, http.StatusBadRequest)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		if !got.Stream {
+			_, _ = io.WriteString(w, `{"id":"msg_1","model":"claude","stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":5},
+				"content":[{"type":"text","text":"查看一下"},{"type":"tool_use","id":"tu_1","name":"list_pods","input":{"namespace":"default"}}]}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type":"message_start","message":{"id":"msg_2","usage":{"input_tokens":10}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"你好"}}`,
+			`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"tu_2","name":"list_pods"}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"namespace\":"}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"kube-system\"}"}}`,
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}`,
+			`{"type":"message_stop"}`,
+		}
+		for _, e := range events {
+			_, _ = fmt.Fprintf(w, "event: x\ndata: %s\n\n", e)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("anthropic")
+	if err := client.Configure(&Provider{BaseURL: srv.URL, Password: "key", Model: "claude", MaxHistory: 10}); err != nil {
+		t.Fatal(err)
+	}
+	client.SetTools(testTools)
+
+	calls, content, err := client.GetCompletionWithTools(context.Background(), "列出default下的Pod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "查看一下" || len(calls) != 1 || calls[0].Function.Name != "list_pods" || calls[0].Function.Arguments != `{"namespace":"default"}` {
+		t.Fatalf("unexpected completion: %q %+v", content, calls)
+	}
+	if got.System == "" || len(got.Messages) != 1 || got.Messages[0].Role != "user" || len(got.Tools) != 1 || got.MaxTokens != anthropicDefaultMaxTokens {
+		t.Fatalf("unexpected request: %+v", got)
+	}
+
+	stream, err := client.GetStreamCompletionWithTools(context.Background(), "列出kube-system下的Pod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, calls := readStream(t, stream)
+	if text != "你好" || len(calls) != 1 || calls[0].ID != "tu_2" || calls[0].Function.Arguments != `{"namespace":"kube-system"}` {
+		t.Fatalf("unexpected stream: %q %+v", text, calls)
+	}
+}
+
+func TestAnthropicClientError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient("anthropic")
+	if err := client.Configure(&Provider{BaseURL: srv.URL + "/v1", Password: "bad", Model: "claude"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := client.GetCompletion(context.Background(), "hi")
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusUnauthorized || !strings.Contains(apiErr.Message, "invalid x-api-key") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestOllamaClient(t *testing.T) {
+	var got ollamaRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		lines := []string{
+			`{"model":"qwen","message":{"role":"assistant","content":"好的"},"done":false}`,
+			`{"model":"qwen","message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"list_pods","arguments":{"namespace":"default"}}}]},"done":false}`,
+			`{"model":"qwen","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":3,"eval_count":4}`,
+		}
+		for _, l := range lines {
+			_, _ = io.WriteString(w, l+"\n")
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("ollama")
+	if err := client.Configure(&Provider{BaseURL: srv.URL + "/v1", Model: "qwen", Temperature: 0.5, MaxHistory: 10}); err != nil {
+		t.Fatal(err)
+	}
+	client.SetTools(testTools)
+	stream, err := client.GetStreamCompletionWithTools(context.Background(), "列出Pod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, calls := readStream(t, stream)
+	if text != "好的" || len(calls) != 1 || calls[0].Function.Name != "list_pods" || calls[0].Function.Arguments != `{"namespace":"default"}` {
+		t.Fatalf("unexpected stream: %q %+v", text, calls)
+	}
+	if !got.Stream || got.Model != "qwen" || len(got.Tools) != 1 || len(got.Messages) != 2 {
+		t.Fatalf("unexpected request: %+v", got)
+	}
+}
+
+func TestAzureOpenAIClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/gpt4o-prod/chat/completions" || r.URL.Query().Get("api-version") != "2024-06-01" || r.Header.Get("api-key") != "key" {
+			http.Error(w, `{"error":{"message":"bad request `+r.URL.String()+`"}}`, http.StatusBadRequest)
+			return
+		}
+		_, _ = io.WriteString(w, `{"id":"1","choices":[{"index":0,"message":{"role":"assistant","content":"pong"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient("azure")
+	if err := client.Configure(&Provider{BaseURL: srv.URL, Password: "key", Model: "gpt-4o", Engine: "gpt4o-prod", APIVersion: "2024-06-01"}); err != nil {
+		t.Fatal(err)
+	}
+	content, err := client.GetCompletion(context.Background(), "ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "pong" {
+		t.Fatalf("unexpected content: %q", content)
+	}
+}
+
+func TestWithFallback(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"overloaded"}}`, http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	var gotModel string
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		gotModel = req.Model
+		_, _ = io.WriteString(w, `{"id":"1","choices":[{"index":0,"message":{"role":"assistant","content":"from backup"},"finish_reason":"stop"}]}`)
+	}))
+	defer backup.Close()
+
+	main := NewClient("openai")
+	if err := main.Configure(&Provider{BaseURL: primary.URL, Password: "key", Model: "strong", MaxHistory: 10}); err != nil {
+		t.Fatal(err)
+	}
+	fallback := NewClient("openai")
+	if err := fallback.Configure(&Provider{BaseURL: backup.URL, Password: "key", Model: "cheap"}); err != nil {
+		t.Fatal(err)
+	}
+	client := WithFallback(main, time.Second, fallback)
+
+	content, err := client.GetCompletion(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "from backup" || gotModel != "cheap" {
+		t.Fatalf("unexpected completion: %q model=%s", content, gotModel)
+	}
+	// 失败重试不应重复写入历史
+	if history := client.GetHistory(context.Background()); len(history) != 1 {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+}
+
+type testUsageTracker struct {
+	quotaErr error
+	records  []*UsageRecord
+}
+
+func (t *testUsageTracker) CheckQuota(ctx context.Context) error {
+	return t.quotaErr
+}
+
+func (t *testUsageTracker) RecordUsage(ctx context.Context, record *UsageRecord) {
+	t.records = append(t.records, record)
+}
+
+func TestUsageTracker(t *testing.T) {
+	tracker := &testUsageTracker{}
+	RegisterUsageTracker(tracker)
+	defer RegisterUsageTracker(nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			_, _ = io.WriteString(w, `{"id":"1","model":"m1","choices":[{"index":0,"message":{"role":"assistant","content":"ok"}}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`)
+			return
+		}
+		if req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			http.Error(w, `{"error":{"message":"include_usage not set"}}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"model\":\"m1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"ok\"}}]}\n\n")
+		_, _ = io.WriteString(w, "data: {\"model\":\"m1\",\"choices\":[],\"usage\":{\"prompt_tokens\":4,\"completion_tokens\":1,\"total_tokens\":5}}\n\n")
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	client := NewClient("openai")
+	if err := client.Configure(&Provider{BaseURL: srv.URL, Password: "key", Model: "m1", MaxHistory: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetCompletion(context.Background(), "hi"); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := client.GetStreamCompletion(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, err = stream.Recv(); err != nil {
+			break
+		}
+	}
+	_ = stream.Close()
+
+	if len(tracker.records) != 2 {
+		t.Fatalf("unexpected records: %+v", tracker.records)
+	}
+	if r := tracker.records[0]; r.Stream || r.TotalTokens != 5 || r.Provider != "openai" || r.Model != "m1" {
+		t.Fatalf("unexpected record: %+v", r)
+	}
+	if r := tracker.records[1]; !r.Stream || r.PromptTokens != 4 || r.CompletionTokens != 1 {
+		t.Fatalf("unexpected stream record: %+v", r)
+	}
+
+	tracker.quotaErr = errors.New("quota exceeded")
+	if _, err = client.GetCompletion(context.Background(), "hi"); err == nil || err.Error() != "quota exceeded" {
+		t.Fatalf("expected quota error, got %v", err)
+	}
+}
+
+func TestAzureStreamUsageEstimated(t *testing.T) {
+	tracker := &testUsageTracker{}
+	RegisterUsageTracker(tracker)
+	defer RegisterUsageTracker(nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		// 较早的 api-version 不接受 stream_options
+		if req.StreamOptions != nil {
+			http.Error(w, `{"error":{"message":"Unrecognized request argument supplied: stream_options"}}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"pong pong\"}}]}\n\n")
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	client := NewClient("azure")
+	if err := client.Configure(&Provider{BaseURL: srv.URL, Password: "key", Model: "gpt-4o", Engine: "gpt4o-prod", ModelConfigID: 7}); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := client.GetStreamCompletion(context.Background(), "ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := readStream(t, stream)
+	if content != "pong pong" {
+		t.Fatalf("unexpected content: %q", content)
+	}
+	if len(tracker.records) != 1 {
+		t.Fatalf("unexpected records: %+v", tracker.records)
+	}
+	if r := tracker.records[0]; !r.Estimated || r.ModelConfigID != 7 || r.PromptTokens == 0 || r.CompletionTokens != 3 {
+		t.Fatalf("unexpected estimated record: %+v", r)
+	}
+}
+
+type testRedaction struct {
+	audits []map[string]int
+}
+
+func (t *testRedaction) NewRedactor() *redact.Redactor {
+	return redact.New([]*redact.Rule{redact.Builtin(redact.BuiltinIP)})
+}
+
+func (t *testRedaction) Audit(ctx context.Context, stats map[string]int) {
+	t.audits = append(t.audits, stats)
+}
+
+func TestRedaction(t *testing.T) {
+	red := &testRedaction{}
+	RegisterRedaction(red)
+	defer RegisterRedaction(nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "10.1.2.3") || !strings.Contains(string(body), "[REDACTED_IP_1]") {
+			http.Error(w, `{"error":{"message":"not redacted"}}`, http.StatusBadRequest)
+			return
+		}
+		var req openai.ChatCompletionRequest
+		_ = json.Unmarshal(body, &req)
+		if !req.Stream {
+			_, _ = io.WriteString(w, `{"id":"1","choices":[{"index":0,"message":{"role":"assistant","content":"节点 [REDACTED_IP_1] 异常",
+				"tool_calls":[{"id":"c1","type":"function","function":{"name":"ping","arguments":"{\"ip\":\"[REDACTED_IP_1]\"}"}}]}}]}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"choices":[{"index":0,"delta":{"content":"节点 [REDAC"}}]}`,
+			`{"choices":[{"index":0,"delta":{"content":"TED_IP_1] 异常"}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"c1","type":"function","function":{"name":"ping","arguments":"{\"ip\":\"[REDACTED_I"}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		} {
+			_, _ = io.WriteString(w, "data: "+chunk+"\n\n")
+		}
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	client := NewClient("openai")
+	if err := client.Configure(&Provider{BaseURL: srv.URL, Password: "key", Model: "m1", MaxHistory: 10}); err != nil {
+		t.Fatal(err)
+	}
+	calls, content, err := client.GetCompletionWithTools(context.Background(), "10.1.2.3 无法访问")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "节点 10.1.2.3 异常" || len(calls) != 1 || calls[0].Function.Arguments != `{"ip":"10.1.2.3"}` {
+		t.Fatalf("unexpected restore: %q %+v", content, calls)
+	}
+
+	stream, err := client.GetStreamCompletion(context.Background(), "10.1.2.3 无法访问")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, calls = readStream(t, stream)
+	// 流结束时暂存的工具参数原样输出（未闭合的占位符无法还原）
+	if content != "节点 10.1.2.3 异常" || len(calls) != 1 || calls[0].Function.Arguments != `{"ip":"[REDACTED_I` {
+		t.Fatalf("unexpected stream restore: %q %+v", content, calls)
+	}
+	if len(red.audits) != 2 || red.audits[0]["IP"] == 0 {
+		t.Fatalf("unexpected audits: %+v", red.audits)
+	}
+}
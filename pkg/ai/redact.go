@@ -0,0 +1,290 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/redact"
+)
+
+// Redaction 发送给大模型的内容脱敏
+// NewRedactor 返回按当前规则创建的脱敏器，未启用脱敏时返回nil；Audit 记录一次请求中各类别的脱敏次数
+type Redaction interface {
+	NewRedactor() *redact.Redactor
+	Audit(ctx context.Context, stats map[string]int)
+}
+
+// redaction 内容脱敏，未注册时不脱敏
+var redaction Redaction
+
+// RegisterRedaction 注册内容脱敏
+func RegisterRedaction(r Redaction) {
+	redaction = r
+}
+
+// WithRedactor 在 ctx 中携带脱敏器，未携带时每次请求使用新的脱敏器
+func WithRedactor(ctx context.Context, r *redact.Redactor) context.Context {
+	return context.WithValue(ctx, constants.ChatRedactor, r)
+}
+
+// NewRedactor 按当前规则创建脱敏器，未启用脱敏时返回nil
+func NewRedactor() *redact.Redactor {
+	if redaction == nil {
+		return nil
+	}
+	return redaction.NewRedactor()
+}
+
+func redactorFromContext(ctx context.Context) *redact.Redactor {
+	if r, ok := ctx.Value(constants.ChatRedactor).(*redact.Redactor); ok && r != nil {
+		return r
+	}
+	return NewRedactor()
+}
+
+// redactTransport 对发往大模型的对话请求脱敏，并将响应中的占位符还原为原值
+// 位于厂商协议转换之前，请求中的提示词、历史及工具执行结果均在此统一处理；
+// 响应中的工具调用参数同样会被还原，保证工具使用真实值执行
+type redactTransport struct {
+	origin http.RoundTripper
+}
+
+func (t *redactTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if redaction == nil || req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/chat/completions") {
+		return t.origin.RoundTrip(req)
+	}
+	ctx := req.Context()
+	r := redactorFromContext(ctx)
+	if r == nil {
+		return t.origin.RoundTrip(req)
+	}
+
+	chatReq, err := readChatCompletionRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[string]int)
+	redactMessages(r, chatReq.Messages, stats)
+	data, err := json.Marshal(chatReq)
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) > 0 {
+		redaction.Audit(ctx, stats)
+	}
+
+	cloned := req.Clone(ctx)
+	cloned.Body = io.NopCloser(bytes.NewReader(data))
+	cloned.ContentLength = int64(len(data))
+	cloned.Header.Del("Content-Length")
+	resp, err := t.origin.RoundTrip(cloned)
+	if err != nil || resp.StatusCode >= http.StatusBadRequest {
+		return resp, err
+	}
+
+	if strings.Contains(resp.Header.Get("Content-Type"), "event-stream") {
+		resp.Body = newRestoreStreamReader(resp.Body, r)
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	var result openai.ChatCompletionResponse
+	if json.Unmarshal(body, &result) == nil {
+		for i := range result.Choices {
+			msg := &result.Choices[i].Message
+			msg.Content = r.Restore(msg.Content)
+			for j := range msg.ToolCalls {
+				msg.ToolCalls[j].Function.Arguments = r.RestoreJSON(msg.ToolCalls[j].Function.Arguments)
+			}
+		}
+		if restored, err := json.Marshal(result); err == nil {
+			body = restored
+		}
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// redactMessages 脱敏消息内容及其中的工具调用参数
+func redactMessages(r *redact.Redactor, messages []openai.ChatCompletionMessage, stats map[string]int) {
+	for i := range messages {
+		msg := &messages[i]
+		msg.Content = r.Redact(msg.Content, stats)
+		for j := range msg.MultiContent {
+			msg.MultiContent[j].Text = r.Redact(msg.MultiContent[j].Text, stats)
+		}
+		for j := range msg.ToolCalls {
+			msg.ToolCalls[j].Function.Arguments = r.Redact(msg.ToolCalls[j].Function.Arguments, stats)
+		}
+	}
+}
+
+// restoreStreamReader 还原流式响应中的占位符
+// 占位符可能被拆分到多个分片，未闭合的部分暂存，在后续分片、结束分片或 [DONE] 之前输出
+type restoreStreamReader struct {
+	io.ReadCloser
+	r       *redact.Redactor
+	in      []byte
+	out     []byte
+	err     error
+	last    openai.ChatCompletionStreamResponse
+	content map[int]*redact.StreamRestorer
+	args    map[int]map[int]*redact.StreamRestorer // choice -> tool call -> 还原器
+}
+
+func newRestoreStreamReader(body io.ReadCloser, r *redact.Redactor) *restoreStreamReader {
+	return &restoreStreamReader{
+		ReadCloser: body,
+		r:          r,
+		content:    make(map[int]*redact.StreamRestorer),
+		args:       make(map[int]map[int]*redact.StreamRestorer),
+	}
+}
+
+func (s *restoreStreamReader) Read(p []byte) (int, error) {
+	buf := make([]byte, 4096)
+	for len(s.out) == 0 && s.err == nil {
+		n, err := s.ReadCloser.Read(buf)
+		s.in = append(s.in, buf[:n]...)
+		for {
+			i := bytes.IndexByte(s.in, '\n')
+			if i < 0 {
+				break
+			}
+			s.line(s.in[:i])
+			s.in = s.in[i+1:]
+		}
+		if err != nil {
+			if len(s.in) > 0 {
+				s.line(s.in)
+				s.in = nil
+			}
+			s.err = err
+		}
+	}
+	if len(s.out) == 0 {
+		return 0, s.err
+	}
+	n := copy(p, s.out)
+	s.out = s.out[n:]
+	return n, nil
+}
+
+// line 处理一行 SSE 数据
+func (s *restoreStreamReader) line(line []byte) {
+	trimmed := bytes.TrimSpace(line)
+	if !bytes.HasPrefix(trimmed, []byte("data:")) {
+		s.out = append(append(s.out, line...), '\n')
+		return
+	}
+	data := bytes.TrimSpace(trimmed[len("data:"):])
+	if string(data) == "[DONE]" {
+		s.flush(-1)
+		s.out = append(append(s.out, line...), '\n')
+		return
+	}
+	var chunk openai.ChatCompletionStreamResponse
+	if json.Unmarshal(data, &chunk) != nil {
+		s.out = append(append(s.out, line...), '\n')
+		return
+	}
+	s.last = chunk
+	for i := range chunk.Choices {
+		choice := &chunk.Choices[i]
+		choice.Delta.Content = s.restorer(choice.Index, -1).Write(choice.Delta.Content)
+		for j := range choice.Delta.ToolCalls {
+			call := &choice.Delta.ToolCalls[j]
+			call.Function.Arguments = s.restorer(choice.Index, toolIndex(call)).Write(call.Function.Arguments)
+		}
+		if choice.FinishReason != "" {
+			// 结束前输出暂存内容，本分片中已有的工具调用直接追加，其余的在本分片之前单独输出
+			choice.Delta.Content += s.restorer(choice.Index, -1).Flush()
+			for j := range choice.Delta.ToolCalls {
+				call := &choice.Delta.ToolCalls[j]
+				call.Function.Arguments += s.restorer(choice.Index, toolIndex(call)).Flush()
+			}
+			s.flush(choice.Index)
+		}
+	}
+	s.writeChunk(chunk)
+}
+
+// flush 以单独的分片输出暂存内容，choice 小于0时输出所有选项
+func (s *restoreStreamReader) flush(choice int) {
+	var choices []openai.ChatCompletionStreamChoice
+	for idx, cr := range s.content {
+		if choice >= 0 && idx != choice {
+			continue
+		}
+		delta := openai.ChatCompletionStreamChoiceDelta{Content: cr.Flush()}
+		for toolIdx, ar := range s.args[idx] {
+			if !ar.Pending() {
+				continue
+			}
+			index := toolIdx
+			delta.ToolCalls = append(delta.ToolCalls, openai.ToolCall{
+				Index:    &index,
+				Function: openai.FunctionCall{Arguments: ar.Flush()},
+			})
+		}
+		if delta.Content != "" || len(delta.ToolCalls) > 0 {
+			choices = append(choices, openai.ChatCompletionStreamChoice{Index: idx, Delta: delta})
+		}
+	}
+	if len(choices) == 0 {
+		return
+	}
+	s.writeChunk(openai.ChatCompletionStreamResponse{
+		ID:      s.last.ID,
+		Object:  s.last.Object,
+		Created: s.last.Created,
+		Model:   s.last.Model,
+		Choices: choices,
+	})
+	s.out = append(s.out, '\n')
+}
+
+func (s *restoreStreamReader) writeChunk(chunk openai.ChatCompletionStreamResponse) {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return
+	}
+	s.out = append(s.out, "data: "...)
+	s.out = append(s.out, data...)
+	s.out = append(s.out, '\n')
+}
+
+// restorer 获取选项的内容还原器，tool 不小于0时获取对应工具调用参数的还原器
+func (s *restoreStreamReader) restorer(choice, tool int) *redact.StreamRestorer {
+	if _, ok := s.content[choice]; !ok {
+		s.content[choice] = s.r.NewStreamRestorer()
+	}
+	if tool < 0 {
+		return s.content[choice]
+	}
+	if s.args[choice] == nil {
+		s.args[choice] = make(map[int]*redact.StreamRestorer)
+	}
+	if _, ok := s.args[choice][tool]; !ok {
+		s.args[choice][tool] = s.r.NewJSONStreamRestorer()
+	}
+	return s.args[choice][tool]
+}
+
+func toolIndex(call *openai.ToolCall) int {
+	if call.Index == nil {
+		return 0
+	}
+	return *call.Index
+}
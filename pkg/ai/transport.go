@@ -0,0 +1,159 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// 其他厂商的原生协议通过包装 go-openai 的 HTTP Transport 实现：
+// 拦截 /chat/completions 请求，转换为厂商原生请求发出，再将响应（含流式响应）转换回 OpenAI 格式，
+// 这样 IAI 接口及 go-openai 的 ChatCompletionStream 均可原样复用。
+
+// readChatCompletionRequest 读取 go-openai 发出的对话请求，非对话请求返回错误
+func readChatCompletionRequest(req *http.Request) (*openai.ChatCompletionRequest, error) {
+	if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/chat/completions") {
+		return nil, fmt.Errorf("不支持的接口: %s %s", req.Method, req.URL.Path)
+	}
+	defer req.Body.Close()
+	var chatReq openai.ChatCompletionRequest
+	if err := json.NewDecoder(req.Body).Decode(&chatReq); err != nil {
+		return nil, err
+	}
+	return &chatReq, nil
+}
+
+// newUpstreamRequest 创建发往厂商原生接口的请求，保留原请求中的自定义请求头
+func newUpstreamRequest(req *http.Request, endpoint string, body any) (*http.Request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	upstream, err := http.NewRequestWithContext(req.Context(), http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	upstream.Header = req.Header.Clone()
+	upstream.Header.Del("Authorization")
+	upstream.Header.Del("Content-Length")
+	upstream.Header.Set("Content-Type", "application/json")
+	return upstream, nil
+}
+
+// jsonResponse 构造 OpenAI 格式的 JSON 响应
+func jsonResponse(req *http.Request, status int, body any) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode:    status,
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
+
+// upstreamError 将厂商接口的错误响应转换为 OpenAI 格式的错误响应
+func upstreamError(req *http.Request, resp *http.Response) (*http.Response, error) {
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	return jsonResponse(req, resp.StatusCode, openai.ErrorResponse{
+		Error: &openai.APIError{
+			Type:           "upstream_error",
+			Message:        strings.TrimSpace(string(data)),
+			HTTPStatusCode: resp.StatusCode,
+		},
+	})
+}
+
+// sseWriter 以 OpenAI SSE 格式输出流式响应
+type sseWriter struct {
+	pw      *io.PipeWriter
+	id      string
+	model   string
+	created int64
+}
+
+// streamResponse 构造 OpenAI 格式的流式响应，produce 在独立协程中写入数据，返回后自动结束流
+func streamResponse(req *http.Request, model string, produce func(w *sseWriter) error) *http.Response {
+	pr, pw := io.Pipe()
+	w := &sseWriter{
+		pw:      pw,
+		id:      fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano()),
+		model:   model,
+		created: time.Now().Unix(),
+	}
+	go func() {
+		if err := produce(w); err != nil {
+			w.error(err)
+		} else {
+			_, _ = io.WriteString(pw, "data: [DONE]\n\n")
+		}
+		_ = pw.Close()
+	}()
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+		Body:       pr,
+		Request:    req,
+	}
+}
+
+// chunk 输出一个流式分片，go-openai 的调用方会直接读取 Choices[0]，因此每个分片都带一个 choice
+func (w *sseWriter) chunk(delta openai.ChatCompletionStreamChoiceDelta, finishReason openai.FinishReason, usage *openai.Usage) error {
+	data, err := json.Marshal(openai.ChatCompletionStreamResponse{
+		ID:      w.id,
+		Object:  "chat.completion.chunk",
+		Created: w.created,
+		Model:   w.model,
+		Choices: []openai.ChatCompletionStreamChoice{{
+			Index:        0,
+			Delta:        delta,
+			FinishReason: finishReason,
+		}},
+		Usage: usage,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w.pw, "data: %s\n\n", data)
+	return err
+}
+
+// error 输出流式错误，go-openai 读取后返回给调用方
+func (w *sseWriter) error(err error) {
+	data, _ := json.Marshal(openai.ErrorResponse{
+		Error: &openai.APIError{
+			Type:    "upstream_error",
+			Message: err.Error(),
+		},
+	})
+	_, _ = fmt.Fprintf(w.pw, "data: %s\n\n", data)
+}
+
+// toolArguments 将 OpenAI 工具调用的参数字符串转为 JSON 对象，无法解析时返回空对象
+func toolArguments(arguments string) json.RawMessage {
+	arguments = strings.TrimSpace(arguments)
+	if arguments == "" || !json.Valid([]byte(arguments)) {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(arguments)
+}
+
+// toolParameters 获取工具参数的 JSON Schema，未定义时返回空对象 Schema
+func toolParameters(tool openai.Tool) any {
+	if tool.Function == nil || tool.Function.Parameters == nil {
+		return map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	return tool.Function.Parameters
+}
@@ -0,0 +1,239 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// UsageRecord 一次大模型请求的 token 用量
+type UsageRecord struct {
+	Provider         string
+	ModelConfigID    uint // 处理请求的模型配置ID，未使用模型配置（如内置模型）时为 0
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	Stream           bool
+	Estimated        bool // 厂商未返回用量，按请求及响应内容估算
+}
+
+// UsageTracker 用量记录及配额检查
+// CheckQuota 在请求大模型前调用，返回错误时不再发出请求；RecordUsage 在收到含用量的响应后调用
+type UsageTracker interface {
+	CheckQuota(ctx context.Context) error
+	RecordUsage(ctx context.Context, record *UsageRecord)
+}
+
+// usageTracker 用量记录及配额检查，未注册时不做统计
+var usageTracker UsageTracker
+
+// RegisterUsageTracker 注册用量记录及配额检查
+func RegisterUsageTracker(tracker UsageTracker) {
+	usageTracker = tracker
+}
+
+// checkQuota 检查 ctx 中用户的 token 配额
+func checkQuota(ctx context.Context) error {
+	if usageTracker == nil {
+		return nil
+	}
+	return usageTracker.CheckQuota(ctx)
+}
+
+// usageTransport 从 OpenAI 格式的对话响应中提取 token 用量
+// 位于厂商协议转换之后，因此各厂商的用量均在此统一统计；请求的 ctx 中携带用户、功能等信息
+type usageTransport struct {
+	origin        http.RoundTripper
+	provider      string
+	modelConfigID uint
+}
+
+func (t *usageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.origin.RoundTrip(req)
+	if err != nil || usageTracker == nil || resp.StatusCode >= http.StatusBadRequest ||
+		!strings.HasSuffix(req.URL.Path, "/chat/completions") {
+		return resp, err
+	}
+	ctx := req.Context()
+	if strings.Contains(resp.Header.Get("Content-Type"), "event-stream") {
+		reader := &usageStreamReader{ReadCloser: resp.Body}
+		reader.onDone = func(model string, usage *openai.Usage) {
+			if usage == nil && reader.completionTokens > 0 {
+				// 厂商不支持在流式响应中返回用量时按内容估算
+				t.recordEstimated(ctx, model, estimateRequestTokens(req), reader.completionTokens)
+				return
+			}
+			t.record(ctx, model, usage, true)
+		}
+		resp.Body = reader
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	var result openai.ChatCompletionResponse
+	if json.Unmarshal(body, &result) == nil {
+		t.record(ctx, result.Model, &result.Usage, false)
+	}
+	return resp, nil
+}
+
+func (t *usageTransport) record(ctx context.Context, model string, usage *openai.Usage, stream bool) {
+	if usage == nil || (usage.TotalTokens == 0 && usage.PromptTokens == 0 && usage.CompletionTokens == 0) {
+		return
+	}
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.PromptTokens + usage.CompletionTokens
+	}
+	usageTracker.RecordUsage(ctx, &UsageRecord{
+		Provider:         t.provider,
+		ModelConfigID:    t.modelConfigID,
+		Model:            model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      total,
+		Stream:           stream,
+	})
+}
+
+func (t *usageTransport) recordEstimated(ctx context.Context, model string, promptTokens, completionTokens int) {
+	usageTracker.RecordUsage(ctx, &UsageRecord{
+		Provider:         t.provider,
+		ModelConfigID:    t.modelConfigID,
+		Model:            model,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+		Stream:           true,
+		Estimated:        true,
+	})
+}
+
+// estimateRequestTokens 按请求中的消息及工具定义估算输入 token 数
+func estimateRequestTokens(req *http.Request) int {
+	if req.GetBody == nil {
+		return 0
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return 0
+	}
+	defer body.Close()
+	var payload struct {
+		Messages []struct {
+			Content any `json:"content"`
+		} `json:"messages"`
+		Tools json.RawMessage `json:"tools"`
+	}
+	if json.NewDecoder(body).Decode(&payload) != nil {
+		return 0
+	}
+	tokens := estimateTokens(string(payload.Tools))
+	for _, m := range payload.Messages {
+		switch content := m.Content.(type) {
+		case string:
+			tokens += estimateTokens(content)
+		case nil:
+		default:
+			bs, _ := json.Marshal(content)
+			tokens += estimateTokens(string(bs))
+		}
+	}
+	return tokens
+}
+
+// estimateTokens 粗略估算文本的 token 数：ASCII 字符约 4 个一个 token，其他字符（如中文）约一个字符一个 token
+func estimateTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// usageStreamReader 在流式响应被读取时解析其中的用量，读取结束或关闭时回调一次
+// 同时按输出内容估算 token 数，供厂商未返回用量时使用
+type usageStreamReader struct {
+	io.ReadCloser
+	line             []byte
+	model            string
+	usage            *openai.Usage
+	completionTokens int
+	once             sync.Once
+	onDone           func(model string, usage *openai.Usage)
+}
+
+func (r *usageStreamReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.scan(p[:n])
+	if err != nil {
+		r.done()
+	}
+	return n, err
+}
+
+func (r *usageStreamReader) Close() error {
+	r.done()
+	return r.ReadCloser.Close()
+}
+
+// scan 按行解析 SSE 数据，记录模型名称及最后一次出现的用量
+func (r *usageStreamReader) scan(data []byte) {
+	r.line = append(r.line, data...)
+	for {
+		i := bytes.IndexByte(r.line, '\n')
+		if i < 0 {
+			return
+		}
+		line := bytes.TrimSpace(r.line[:i])
+		r.line = r.line[i+1:]
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		var chunk struct {
+			Model   string        `json:"model"`
+			Usage   *openai.Usage `json:"usage"`
+			Choices []struct {
+				Delta openai.ChatCompletionStreamChoiceDelta `json:"delta"`
+			} `json:"choices"`
+		}
+		if json.Unmarshal(bytes.TrimSpace(line[len("data:"):]), &chunk) != nil {
+			continue
+		}
+		if chunk.Model != "" {
+			r.model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			r.usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			r.completionTokens += estimateTokens(choice.Delta.Content)
+			for _, call := range choice.Delta.ToolCalls {
+				r.completionTokens += estimateTokens(call.Function.Name + call.Function.Arguments)
+			}
+		}
+	}
+}
+
+func (r *usageStreamReader) done() {
+	r.once.Do(func() {
+		r.onDone(r.model, r.usage)
+	})
+}
@@ -0,0 +1,153 @@
+package audit
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileSinkRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.log")
+	sink, err := NewFileSink(path, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.maxSize = 200
+
+	var events []*Event
+	for i := 0; i < 10; i++ {
+		events = append(events, &Event{Source: "shell", User: "admin", Action: "exec", Record: strings.Repeat("x", 50)})
+	}
+	if _, err = sink.Write(events); err != nil {
+		t.Fatal(err)
+	}
+	_ = sink.Close()
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		if _, err = os.Stat(name); err != nil {
+			t.Errorf("expected %s to exist: %v", name, err)
+		}
+	}
+	if _, err = os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected at most 2 backups")
+	}
+
+	f, _ := os.Open(path)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if !strings.HasPrefix(scanner.Text(), `{"source":"shell"`) {
+			t.Errorf("unexpected line: %s", scanner.Text())
+		}
+	}
+}
+
+func TestSyslogFormat(t *testing.T) {
+	sink, err := NewSyslogSink("tcp", "127.0.0.1:514", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.hostname = "host1"
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 6000, time.UTC)
+
+	msg, _ := sink.format(&Event{Source: "operation", Time: ts, Success: true})
+	prefix := "<110>1 2025-01-02T03:04:05.000006Z host1 k8m "
+	if !strings.HasPrefix(string(msg), prefix) {
+		t.Errorf("got %q, want prefix %q", msg, prefix)
+	}
+	if !strings.Contains(string(msg), " operation - {") {
+		t.Errorf("msgid or body missing: %q", msg)
+	}
+
+	msg, _ = sink.format(&Event{Source: "mcp", Time: ts})
+	if !strings.HasPrefix(string(msg), "<108>1 ") {
+		t.Errorf("failed event should use warning severity: %q", msg)
+	}
+
+	if _, err = NewSyslogSink("tls", "127.0.0.1:514", ""); err == nil {
+		t.Errorf("expected error for unsupported network")
+	}
+}
+
+type flakySink struct {
+	mu       sync.Mutex
+	failures int
+	partial  int // 失败时仍写入的条数
+	received []*Event
+}
+
+func (f *flakySink) Name() string { return "flaky" }
+func (f *flakySink) Close() error { return nil }
+func (f *flakySink) Write(events []*Event) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		n := min(f.partial, len(events))
+		f.received = append(f.received, events[:n]...)
+		return n, errors.New("unavailable")
+	}
+	f.received = append(f.received, events...)
+	return len(events), nil
+}
+
+func (f *flakySink) actions() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var actions []string
+	for _, e := range f.received {
+		actions = append(actions, e.Action)
+	}
+	return actions
+}
+
+func TestDispatcherRetry(t *testing.T) {
+	// 首次推送只写入1条即失败，重试时不应重复推送已写入的日志
+	sink := &flakySink{failures: 1, partial: 1}
+	d := NewDispatcher(sink, Options{BatchSize: 2, FlushInterval: 10 * time.Millisecond})
+	for _, a := range []string{"a", "b", "c"} {
+		d.Emit(&Event{Source: "shell", Action: a})
+	}
+	// 首次推送失败后1秒重试
+	time.Sleep(1500 * time.Millisecond)
+	d.Close()
+
+	if got := strings.Join(sink.actions(), ","); got != "a,b,c" {
+		t.Errorf("expected each event delivered once in order, got %s", got)
+	}
+}
+
+func TestDispatcherSpool(t *testing.T) {
+	spoolPath := SpoolPath(t.TempDir(), 1)
+	down := &flakySink{failures: 1 << 30}
+	d := NewDispatcher(down, Options{BufferSize: 1, BatchSize: 1, FlushInterval: time.Hour, SpoolPath: spoolPath})
+	for _, a := range []string{"a", "b", "c"} {
+		if !d.Emit(&Event{Source: "shell", Action: a, Record: map[string]string{"cmd": a}}) {
+			t.Fatalf("emit %s should spill instead of dropping", a)
+		}
+	}
+	d.Close()
+	if d.Dropped() != 0 {
+		t.Errorf("expected nothing dropped, got %d", d.Dropped())
+	}
+
+	up := &flakySink{}
+	d = NewDispatcher(up, Options{BatchSize: 2, FlushInterval: 10 * time.Millisecond, SpoolPath: spoolPath})
+	time.Sleep(100 * time.Millisecond)
+	d.Close()
+
+	got := up.actions()
+	slices.Sort(got)
+	if strings.Join(got, ",") != "a,b,c" {
+		t.Errorf("expected spooled events replayed once, got %v", got)
+	}
+	if _, err := os.Stat(spoolPath + ".replay"); !os.IsNotExist(err) {
+		t.Errorf("replay file should be removed after replay")
+	}
+}
@@ -0,0 +1,197 @@
+package audit
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Options 外发缓冲与重试参数
+type Options struct {
+	BufferSize    int           // 内存缓冲队列长度
+	BatchSize     int           // 每批最大条数
+	FlushInterval time.Duration // 未满一批时的推送间隔
+	MaxBackoff    time.Duration // 重试的最大退避时间
+	SpoolPath     string        // 磁盘溢出文件路径，内存队列写满或关闭时未推送的日志写入该文件稍后补发，为空时丢弃
+}
+
+func (o *Options) normalize() {
+	if o.BufferSize <= 0 {
+		o.BufferSize = 10000
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = 5 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Minute
+	}
+}
+
+// Dispatcher 为单个 Sink 提供异步缓冲、批量推送与失败重试
+// 推送失败时只重试未写入的部分，按指数退避持续重试，直至成功或 Dispatcher 关闭，期间新日志在队列中缓冲；
+// 队列写满时新日志写入磁盘溢出文件，队列空闲时按顺序补发，避免外部平台故障拖慢业务请求或丢失日志。
+// 未配置溢出文件或写入溢出文件失败时才丢弃日志并计数
+type Dispatcher struct {
+	sink    Sink
+	opts    Options
+	queue   chan *Event
+	spool   *spool
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Int64
+}
+
+func NewDispatcher(sink Sink, opts Options) *Dispatcher {
+	opts.normalize()
+	d := &Dispatcher{
+		sink:  sink,
+		opts:  opts,
+		queue: make(chan *Event, opts.BufferSize),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	if opts.SpoolPath != "" {
+		d.spool = getSpool(opts.SpoolPath)
+	}
+	go d.loop()
+	return d
+}
+
+// Emit 将事件放入缓冲队列，队列已满时写入磁盘溢出文件，两者都失败时返回false
+func (d *Dispatcher) Emit(e *Event) bool {
+	select {
+	case d.queue <- e:
+		return true
+	default:
+	}
+	if d.spill([]*Event{e}) {
+		return true
+	}
+	if n := d.dropped.Add(1); n == 1 || n%1000 == 0 {
+		klog.Warningf("审计日志外发[%s]缓冲队列已满，累计丢弃 %d 条", d.sink.Name(), n)
+	}
+	return false
+}
+
+// Dropped 因队列已满且无法写入溢出文件被丢弃的日志条数
+func (d *Dispatcher) Dropped() int64 {
+	return d.dropped.Load()
+}
+
+// spill 将日志写入磁盘溢出文件
+func (d *Dispatcher) spill(events []*Event) bool {
+	if d.spool == nil || len(events) == 0 {
+		return false
+	}
+	if err := d.spool.Append(events); err != nil {
+		klog.Errorf("审计日志外发[%s]写入溢出文件失败: %v", d.sink.Name(), err)
+		return false
+	}
+	return true
+}
+
+// Close 停止接收新日志，尽力推送队列中剩余的日志后关闭 Sink
+func (d *Dispatcher) Close() {
+	d.once.Do(func() {
+		close(d.stop)
+		<-d.done
+	})
+}
+
+func (d *Dispatcher) loop() {
+	defer close(d.done)
+	ticker := time.NewTicker(d.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Event, 0, d.opts.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		d.deliver(batch)
+		batch = make([]*Event, 0, d.opts.BatchSize)
+	}
+
+	for {
+		select {
+		case e := <-d.queue:
+			batch = append(batch, e)
+			if len(batch) >= d.opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+			// 内存队列空闲时补发溢出文件中的日志
+			if len(d.queue) == 0 && d.spool != nil && d.spool.Pending() {
+				if err := d.spool.Replay(d.opts.BatchSize, d.deliver); err != nil {
+					klog.Errorf("审计日志外发[%s]补发溢出文件失败: %v", d.sink.Name(), err)
+				}
+			}
+		case <-d.stop:
+			d.drain(batch)
+			if err := d.sink.Close(); err != nil {
+				klog.Errorf("关闭审计日志外发[%s]失败: %v", d.sink.Name(), err)
+			}
+			return
+		}
+	}
+}
+
+// deliver 推送一批日志，失败时按指数退避重试未写入的部分，直至成功或 Dispatcher 关闭
+// 关闭时仍未推送的部分写入溢出文件
+func (d *Dispatcher) deliver(batch []*Event) {
+	backoff := time.Second
+	for {
+		n, err := d.sink.Write(batch)
+		batch = batch[min(max(n, 0), len(batch)):]
+		if err == nil {
+			return
+		}
+		klog.Errorf("审计日志外发[%s]失败，%s 后重试（%d 条）: %v", d.sink.Name(), backoff, len(batch), err)
+		if len(batch) == 0 {
+			return
+		}
+		select {
+		case <-time.After(backoff):
+		case <-d.stop:
+			if !d.spill(batch) {
+				klog.Errorf("审计日志外发[%s]关闭前推送失败，丢弃 %d 条", d.sink.Name(), len(batch))
+			}
+			return
+		}
+		backoff *= 2
+		if backoff > d.opts.MaxBackoff {
+			backoff = d.opts.MaxBackoff
+		}
+	}
+}
+
+// drain 关闭时将当前批次与队列中剩余日志做最后一次推送，推送失败的部分写入溢出文件
+func (d *Dispatcher) drain(batch []*Event) {
+	for {
+		select {
+		case e := <-d.queue:
+			batch = append(batch, e)
+			continue
+		default:
+		}
+		break
+	}
+	for len(batch) > 0 {
+		n := min(len(batch), d.opts.BatchSize)
+		written, err := d.sink.Write(batch[:n])
+		batch = batch[min(max(written, 0), n):]
+		if err != nil {
+			if !d.spill(batch) {
+				klog.Errorf("审计日志外发[%s]关闭前推送失败，丢弃 %d 条: %v", d.sink.Name(), len(batch), err)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileSink 以JSON Lines格式写入本地文件，超过大小上限后滚动
+// 滚动后的文件依次命名为 path.1、path.2 ...，最多保留 maxBackups 个
+type FileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func NewFileSink(path string, maxSizeMB int, maxBackups int) (*FileSink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("文件路径不能为空")
+	}
+	if maxSizeMB <= 0 {
+		maxSizeMB = 100
+	}
+	if maxBackups < 0 {
+		maxBackups = 0
+	}
+	return &FileSink{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+	}, nil
+}
+
+func (f *FileSink) Name() string {
+	return "file:" + f.path
+}
+
+func (f *FileSink) Write(events []*Event) (int, error) {
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	for i, e := range events {
+		line, err := e.JSON()
+		if err != nil {
+			continue
+		}
+		line = append(line, '\n')
+		if f.size > 0 && f.size+int64(len(line)) > f.maxSize {
+			if err = f.rotate(); err != nil {
+				return i, err
+			}
+		}
+		n, err := f.file.Write(line)
+		if err != nil {
+			// 写入不完整时截掉残缺的行，重试时整行重新写入
+			if n > 0 {
+				if truncErr := f.file.Truncate(f.size); truncErr != nil {
+					_ = f.Close()
+				}
+			}
+			return i, err
+		}
+		f.size += int64(n)
+	}
+	// 数据已写入文件，同步失败时不再重试，避免重复写入
+	return len(events), f.file.Sync()
+}
+
+func (f *FileSink) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *FileSink) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *FileSink) rotate() error {
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if f.maxBackups == 0 {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return f.open()
+	}
+	for i := f.maxBackups - 1; i >= 1; i-- {
+		src := fmt.Sprintf("%s.%d", f.path, i)
+		if _, err := os.Stat(src); err == nil {
+			if err = os.Rename(src, fmt.Sprintf("%s.%d", f.path, i+1)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.Rename(f.path, f.path+".1"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return f.open()
+}
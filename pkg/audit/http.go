@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTPSink 以JSON数组批量POST到指定地址，非2xx响应视为整批失败
+type HTTPSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func NewHTTPSink(url string, headers map[string]string) (*HTTPSink, error) {
+	if url == "" {
+		return nil, fmt.Errorf("推送地址不能为空")
+	}
+	return &HTTPSink{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (h *HTTPSink) Name() string {
+	return "http:" + h.url
+}
+
+func (h *HTTPSink) Write(events []*Event) (int, error) {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest(http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return 0, fmt.Errorf("推送失败，状态码: %d，响应: %s", resp.StatusCode, string(respBody))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return len(events), nil
+}
+
+func (h *HTTPSink) Close() error {
+	h.client.CloseIdleConnections()
+	return nil
+}
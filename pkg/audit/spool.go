@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// spool 磁盘溢出队列
+// 内存队列写满、或关闭时仍未推送成功的日志追加到文件，队列空闲时按顺序补发，避免丢失日志。
+// 补发时先将文件改名为 path.replay，已补发到的位置记录在 path.replay.offset 中，重启后从该位置继续，不会重复推送。
+type spool struct {
+	path      string
+	mu        sync.Mutex // 保护文件追加及改名
+	replaying sync.Mutex // 同一时间只有一个推送任务补发
+}
+
+var (
+	spoolsMu sync.Mutex
+	spools   = map[string]*spool{}
+)
+
+// getSpool 按路径获取溢出队列，配置重新加载时新旧推送任务共用同一个队列
+func getSpool(path string) *spool {
+	spoolsMu.Lock()
+	defer spoolsMu.Unlock()
+	if s, ok := spools[path]; ok {
+		return s
+	}
+	s := &spool{path: path}
+	spools[path] = s
+	return s
+}
+
+// SpoolPath 外发目标的溢出文件路径
+func SpoolPath(dir string, id uint) string {
+	return filepath.Join(dir, "sink-"+strconv.FormatUint(uint64(id), 10)+".jsonl")
+}
+
+// Append 追加日志到溢出文件
+func (s *spool) Append(events []*Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	var buf []byte
+	for _, e := range events {
+		line, err := e.JSON()
+		if err != nil {
+			continue
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+	if _, err = f.Write(buf); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Replay 按批读取溢出文件中的日志交给 deliver，每批处理后记录位置，全部处理完后删除文件
+// deliver 负责推送或重新写回溢出文件；已有其他任务在补发时直接返回
+func (s *spool) Replay(batchSize int, deliver func(events []*Event)) error {
+	if !s.replaying.TryLock() {
+		return nil
+	}
+	defer s.replaying.Unlock()
+
+	replayPath := s.path + ".replay"
+	offsetPath := replayPath + ".offset"
+	if _, err := os.Stat(replayPath); os.IsNotExist(err) {
+		s.mu.Lock()
+		err = os.Rename(s.path, replayPath)
+		s.mu.Unlock()
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		_ = os.Remove(offsetPath)
+	}
+
+	f, err := os.Open(replayPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var offset int64
+	if bs, err := os.ReadFile(offsetPath); err == nil {
+		offset, _ = strconv.ParseInt(strings.TrimSpace(string(bs)), 10, 64)
+	}
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(f)
+	for {
+		var batch []*Event
+		read := int64(0)
+		for len(batch) < batchSize {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 && line[len(line)-1] == '\n' {
+				read += int64(len(line))
+				if e, err := decodeEvent(line); err == nil {
+					batch = append(batch, e)
+				}
+				continue
+			}
+			// 末尾未写完整的行忽略
+			if err != nil {
+				break
+			}
+		}
+		if read == 0 {
+			break
+		}
+		if len(batch) > 0 {
+			deliver(batch)
+		}
+		offset += read
+		if err = os.WriteFile(offsetPath, []byte(strconv.FormatInt(offset, 10)), 0o640); err != nil {
+			return err
+		}
+	}
+	_ = f.Close()
+	if err = os.Remove(replayPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	_ = os.Remove(offsetPath)
+	return nil
+}
+
+// Pending 溢出文件中是否有待补发的日志
+func (s *spool) Pending() bool {
+	for _, p := range []string{s.path, s.path + ".replay"} {
+		if info, err := os.Stat(p); err == nil && info.Size() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeEvent 解析溢出文件中的一行，原始日志记录保持原样输出
+func decodeEvent(line []byte) (*Event, error) {
+	var e Event
+	if err := json.Unmarshal(line, &e); err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Record json.RawMessage `json:"record"`
+	}
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return nil, err
+	}
+	e.Record = raw.Record
+	return &e, nil
+}
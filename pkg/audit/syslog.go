@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// syslogFacility 使用 log audit(13) facility
+	syslogFacility = 13
+	// 严重程度：操作成功记为 informational，失败记为 warning
+	syslogSeverityInfo    = 6
+	syslogSeverityWarning = 4
+
+	syslogTimeout = 10 * time.Second
+)
+
+// SyslogSink 以 RFC5424 格式发送到 syslog 服务
+// TCP 使用 RFC6587 octet-counting 分帧，UDP 每条日志一个数据报
+type SyslogSink struct {
+	network  string
+	address  string
+	appName  string
+	hostname string
+	conn     net.Conn
+}
+
+func NewSyslogSink(network, address, appName string) (*SyslogSink, error) {
+	if network == "" {
+		network = "udp"
+	}
+	if network != "tcp" && network != "udp" {
+		return nil, fmt.Errorf("不支持的syslog协议[%s]，仅支持tcp/udp", network)
+	}
+	if address == "" {
+		return nil, fmt.Errorf("syslog地址不能为空")
+	}
+	if appName == "" {
+		appName = "k8m"
+	}
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	return &SyslogSink{
+		network:  network,
+		address:  address,
+		appName:  appName,
+		hostname: hostname,
+	}, nil
+}
+
+func (s *SyslogSink) Name() string {
+	return "syslog:" + s.network + "://" + s.address
+}
+
+func (s *SyslogSink) Write(events []*Event) (int, error) {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, syslogTimeout)
+		if err != nil {
+			return 0, err
+		}
+		s.conn = conn
+	}
+	for i, e := range events {
+		msg, err := s.format(e)
+		if err != nil {
+			continue
+		}
+		if s.network == "tcp" {
+			msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+		}
+		_ = s.conn.SetWriteDeadline(time.Now().Add(syslogTimeout))
+		if _, err = s.conn.Write(msg); err != nil {
+			// 连接异常时丢弃连接，下次从该条开始重试时重新建立
+			_ = s.Close()
+			return i, err
+		}
+	}
+	return len(events), nil
+}
+
+func (s *SyslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// format 生成 RFC5424 消息：<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+// MSGID 为日志来源，MSG 为事件JSON
+func (s *SyslogSink) format(e *Event) ([]byte, error) {
+	body, err := e.JSON()
+	if err != nil {
+		return nil, err
+	}
+	severity := syslogSeverityInfo
+	if !e.Success {
+		severity = syslogSeverityWarning
+	}
+	header := fmt.Sprintf("<%d>1 %s %s %s %d %s - ",
+		syslogFacility*8+severity,
+		e.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		s.hostname,
+		s.appName,
+		os.Getpid(),
+		e.Source,
+	)
+	return append([]byte(header), body...), nil
+}
@@ -0,0 +1,31 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event 推送到外部日志平台的审计事件
+type Event struct {
+	Source  string    `json:"source"`            // 日志来源 operation/shell/mcp
+	Time    time.Time `json:"time"`              // 事件发生时间
+	Host    string    `json:"host"`              // 产生日志的k8m实例
+	User    string    `json:"user"`              // 操作人
+	Cluster string    `json:"cluster,omitempty"` // 集群
+	Action  string    `json:"action,omitempty"`  // 操作，如 delete、exec、工具名称
+	Success bool      `json:"success"`           // 操作是否成功
+	Record  any       `json:"record"`            // 数据库中保存的原始日志记录
+}
+
+// JSON 序列化为单行JSON
+func (e *Event) JSON() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// Sink 审计日志外发目标
+// Write 返回已成功写入的条数，返回错误时调用方只重试未写入的部分，避免重复推送
+type Sink interface {
+	Name() string
+	Write(events []*Event) (int, error)
+	Close() error
+}
@@ -5,10 +5,12 @@ import (
 	"strings"
 
 	"github.com/weibaohui/k8m/pkg/comm"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/constants"
 	"github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/service"
 	"github.com/weibaohui/kom/kom"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/klog/v2"
 )
 
@@ -44,12 +46,23 @@ func RegisterDefaultCallbacks(cluster *service.ClusterConfig) func() {
 
 	streamExecCallback := kom.Cluster(selectedCluster).Callback().StreamExec()
 	_ = streamExecCallback.Before("*").Register("k8m:pod-stream-exec", handleExec)
+
+	// 授权限定了资源名称或标签时，按授权范围过滤查询结果
+	_ = getCallback.After("kom:get").Register("k8m:scope-get", handleScopeGet)
+	_ = listCallback.After("kom:list").Register("k8m:scope-list", handleScopeList)
+
+	// 查询结果脱敏，在kom执行查询之后处理
+	_ = getCallback.After("kom:get").Register("k8m:redact-get", handleRedact)
+	_ = listCallback.After("kom:list").Register("k8m:redact-list", handleRedact)
+	_ = describeCallback.After("kom:describe").Register("k8m:redact-describe", handleRedactDescribe)
 	klog.V(6).Infof("registered callbacks for cluster %s", selectedCluster)
 	return nil
 }
 
 // handleCommonLogic 根据用户在指定集群上的角色和命名空间权限，校验其是否有执行指定 Kubernetes 操作（如读取、变更、Exec 等）的权限。
 // 平台管理员拥有所有权限，集群管理员拥有全部操作权限，特定操作（如 Exec、只读）需具备对应角色及命名空间权限。
+// 内置角色不满足时，按用户引用的自定义角色规则（动作 × 资源组/类型 × 命名空间）校验。
+// 授权限定了资源名称或标签时，变更、Exec、日志及 Describe 操作会校验目标资源的名称、标签。
 // 若为内部监听（如 node watch），则跳过权限校验。
 //
 // 参数：
@@ -61,18 +74,137 @@ func RegisterDefaultCallbacks(cluster *service.ClusterConfig) func() {
 //
 //	权限不足或异常时的错误信息。
 func handleCommonLogic(k8s *kom.Kubectl, action string) error {
+	return checkPermission(k8s, action, newLiveTarget(k8s))
+}
+
+// checkPermission 同 handleCommonLogic，live 为本次操作共用的目标资源读取器，避免重复读取
+// 授权限定了资源名称或标签时，按 scopedLabelSets 确定的目标资源标签逐一校验
+func checkPermission(k8s *kom.Kubectl, action string, live *liveTarget) error {
 	stmt := k8s.Statement
 	cluster := k8s.ID
 	ctx := stmt.Context
-	nsList := stmt.NamespaceList
+	nsList := statementNsList(k8s)
 	ns := stmt.Namespace
-	if ns != "" {
-		nsList = append(nsList, ns)
+	group, kind := stmt.GVK.Group, stmt.GVK.Kind
+
+	if comm.HasScopedGrant(ctx, cluster) {
+		name, sets, unscoped, err := scopedLabelSets(action, stmt.Name, stmt.Dest, live, func() (map[string]string, error) {
+			return changedLabels(k8s, action, live)
+		})
+		if err != nil {
+			return fmt.Errorf("用户[%v]的授权限定了资源范围，无法确定资源[%s/%s]的标签: %v", ctx.Value(constants.JwtUserName), ns, stmt.Name, err)
+		}
+		if unscoped {
+			return comm.CheckPermissionUnscoped(ctx, cluster, nsList, ns, action, group, kind)
+		}
+		if len(sets) > 0 {
+			for _, lbs := range sets {
+				if err = comm.CheckPermissionWithLabels(ctx, cluster, nsList, ns, name, action, group, kind, lbs); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return comm.CheckPermissionWithLabels(ctx, cluster, nsList, ns, stmt.Name, action, group, kind, nil)
+}
+
+// labelSource 目标资源当前标签的来源
+type labelSource interface {
+	Labels() (map[string]string, error)
+}
+
+// scopedLabelSets 授权限定了资源名称或标签时，确定校验使用的资源名称及需逐一满足的标签集合
+//   - create 按提交对象的名称、标签校验
+//   - update/patch 变更前后的标签都须在授权范围内，避免将资源改出授权范围
+//   - delete/exec/logs/describe 按目标资源当前的标签校验，标签无法读取时返回错误
+//   - 以上操作未指定名称时无法逐个校验，返回 unscoped，仅未限定范围的授权生效
+//   - get/list 返回空集合，不按标签预先过滤，由查询结果过滤兜底
+func scopedLabelSets(action, name string, dest any, live labelSource, changed func() (map[string]string, error)) (string, []map[string]string, bool, error) {
+	switch action {
+	case "create":
+		destName, destLabels := comm.DestNameAndLabels(dest)
+		if name == "" {
+			name = destName
+		}
+		return name, []map[string]string{destLabels}, false, nil
+	case "update", "patch", "delete", "exec", "logs", "describe":
+		if name == "" && action == "update" {
+			name, _ = comm.DestNameAndLabels(dest)
+		}
+		if name == "" {
+			return name, nil, true, nil
+		}
+		current, err := live.Labels()
+		if err != nil {
+			return name, nil, false, err
+		}
+		if action != "update" && action != "patch" {
+			return name, []map[string]string{current}, false, nil
+		}
+		after, err := changed()
+		if err != nil {
+			return name, nil, false, err
+		}
+		return name, []map[string]string{current, after}, false, nil
+	}
+	return name, nil, false, nil
+}
+
+// changedLabels 资源变更后的标签，update 取提交的对象，patch 在变更前的对象上应用补丁
+func changedLabels(k8s *kom.Kubectl, action string, live *liveTarget) (map[string]string, error) {
+	stmt := k8s.Statement
+	if action == "update" {
+		_, lbs := comm.DestNameAndLabels(stmt.Dest)
+		return lbs, nil
+	}
+	obj, err := live.Get()
+	if err != nil {
+		return nil, err
+	}
+	return comm.PatchedLabels(stmt.GVK, obj.Object, stmt.PatchType, stmt.PatchData)
+}
+
+// liveTarget 以平台管理员身份读取目标资源在变更前的状态
+// 同一次操作的权限校验、脱敏还原及操作日志共用读取结果，只读取一次
+type liveTarget struct {
+	k8s    *kom.Kubectl
+	loaded bool
+	obj    *unstructured.Unstructured
+	err    error
+}
+
+func newLiveTarget(k8s *kom.Kubectl) *liveTarget {
+	return &liveTarget{k8s: k8s}
+}
+
+// Get 读取目标资源，首次调用时访问集群，之后返回缓存结果
+func (t *liveTarget) Get() (*unstructured.Unstructured, error) {
+	if !t.loaded {
+		t.loaded = true
+		stmt := t.k8s.Statement
+		var obj unstructured.Unstructured
+		t.err = kom.Cluster(t.k8s.ID).WithContext(utils.GetContextWithAdmin()).
+			CRD(stmt.GVK.Group, stmt.GVK.Version, stmt.GVK.Kind).
+			Namespace(stmt.Namespace).Name(stmt.Name).Get(&obj).Error
+		t.obj = &obj
+	}
+	return t.obj, t.err
+}
+
+// Labels 目标资源当前的标签，资源读取失败时返回错误
+func (t *liveTarget) Labels() (map[string]string, error) {
+	obj, err := t.Get()
+	if err != nil {
+		return nil, err
+	}
+	if obj.GetLabels() == nil {
+		return map[string]string{}, nil
 	}
-	name := stmt.Name
-	return comm.CheckPermissionLogic(ctx, cluster, nsList, ns, name, action)
+	return obj.GetLabels(), nil
 }
-func saveLog2DB(k8s *kom.Kubectl, action string, err error) {
+
+func saveLog2DB(k8s *kom.Kubectl, action string, live *liveTarget, err error) {
 	stmt := k8s.Statement
 	cluster := k8s.ID
 	ctx := stmt.Context
@@ -94,6 +226,8 @@ func saveLog2DB(k8s *kom.Kubectl, action string, err error) {
 
 	if err != nil {
 		log.ActionResult = err.Error()
+	} else {
+		log.Params, log.Diff = operationDetail(k8s, action, live)
 	}
 	if roleErr != nil {
 		log.ActionResult = roleErr.Error()
@@ -103,32 +237,61 @@ func saveLog2DB(k8s *kom.Kubectl, action string, err error) {
 	service.OperationLogService().Add(&log)
 
 }
+
+// operationDetail 记录提交的资源对象，update/patch 还会与变更前的资源对象比对生成差异
+func operationDetail(k8s *kom.Kubectl, action string, live *liveTarget) (string, string) {
+	stmt := k8s.Statement
+	switch action {
+	case "create":
+		return comm.OperationDetail(stmt.GVK, nil, stmt.Dest, "", "")
+	case "update":
+		if obj, err := live.Get(); err == nil {
+			return comm.OperationDetail(stmt.GVK, obj, stmt.Dest, "", "")
+		}
+		return comm.OperationDetail(stmt.GVK, nil, stmt.Dest, "", "")
+	case "patch":
+		if obj, err := live.Get(); err == nil {
+			return comm.OperationDetail(stmt.GVK, obj, nil, stmt.PatchType, stmt.PatchData)
+		}
+		return comm.OperationDetail(stmt.GVK, nil, nil, stmt.PatchType, stmt.PatchData)
+	}
+	return "", ""
+}
+
 func handleDelete(k8s *kom.Kubectl) error {
-	err := handleCommonLogic(k8s, "delete")
-	saveLog2DB(k8s, "delete", err)
+	live := newLiveTarget(k8s)
+	err := checkPermission(k8s, "delete", live)
+	saveLog2DB(k8s, "delete", live, err)
 	return err
 }
 
 func handleUpdate(k8s *kom.Kubectl) error {
-	err := handleCommonLogic(k8s, "update")
-	saveLog2DB(k8s, "update", err)
+	live := newLiveTarget(k8s)
+	err := checkPermission(k8s, "update", live)
+	if err == nil {
+		err = restoreRedacted(k8s, live)
+	}
+	saveLog2DB(k8s, "update", live, err)
 	return err
 }
 
 func handlePatch(k8s *kom.Kubectl) error {
-	err := handleCommonLogic(k8s, "patch")
-	saveLog2DB(k8s, "patch", err)
+	live := newLiveTarget(k8s)
+	err := checkPermission(k8s, "patch", live)
+	saveLog2DB(k8s, "patch", live, err)
 	return err
 }
 
 func handleCreate(k8s *kom.Kubectl) error {
-	err := handleCommonLogic(k8s, "create")
-	saveLog2DB(k8s, "create", err)
+	live := newLiveTarget(k8s)
+	err := checkPermission(k8s, "create", live)
+	saveLog2DB(k8s, "create", live, err)
 	return err
 }
 func handleExec(k8s *kom.Kubectl) error {
-	err := handleCommonLogic(k8s, "exec")
-	saveLog2DB(k8s, "exec", err)
+	live := newLiveTarget(k8s)
+	err := checkPermission(k8s, "exec", live)
+	saveLog2DB(k8s, "exec", live, err)
 	return err
 }
 
@@ -148,3 +311,94 @@ func handleGet(k8s *kom.Kubectl) error {
 	err := handleCommonLogic(k8s, "get")
 	return err
 }
+
+// handleScopeGet 读取的资源不在用户授权的名称、标签范围内时，拒绝访问
+func handleScopeGet(k8s *kom.Kubectl) error {
+	stmt := k8s.Statement
+	if stmt.Dest == nil {
+		return nil
+	}
+	grants, restricted := comm.ResourceScopes(stmt.Context, k8s.ID, statementNsList(k8s), "get", stmt.GVK.Group, stmt.GVK.Kind)
+	if !restricted || comm.DestInScope(stmt.Dest, grants) {
+		return nil
+	}
+	return fmt.Errorf("用户[%v]没有资源[%s/%s]的访问权限-不在授权的名称或标签范围内", stmt.Context.Value(constants.JwtUserName), stmt.Namespace, stmt.Name)
+}
+
+// handleScopeList 过滤列表结果中不在用户授权的名称、标签范围内的资源
+func handleScopeList(k8s *kom.Kubectl) error {
+	stmt := k8s.Statement
+	if stmt.Dest == nil {
+		return nil
+	}
+	grants, restricted := comm.ResourceScopes(stmt.Context, k8s.ID, statementNsList(k8s), "list", stmt.GVK.Group, stmt.GVK.Kind)
+	if !restricted {
+		return nil
+	}
+	if err := comm.FilterDestByScope(stmt.Dest, grants); err != nil {
+		klog.V(6).Infof("filter %s list by scope failed: %v", stmt.GVK.Kind, err)
+		return err
+	}
+	return nil
+}
+
+// handleRedact 对Get/List结果中的敏感数据进行脱敏
+// 没有查看Secret权限的用户，Secret的data/stringData、命中敏感关键字的ConfigMap key及环境变量值将被替换
+// 读取后回写集群的内部读取通过 comm.WithoutRedact 标记跳过脱敏
+func handleRedact(k8s *kom.Kubectl) error {
+	stmt := k8s.Statement
+	if stmt.Dest == nil || !comm.NeedRedact(stmt.GVK.Kind) || comm.RedactSkipped(stmt.Context) {
+		return nil
+	}
+	if comm.CanViewSensitiveData(stmt.Context, k8s.ID, statementNsList(k8s), stmt.GVK.Kind) {
+		return nil
+	}
+	if err := comm.RedactDest(stmt.Dest, stmt.GVK.Kind); err != nil {
+		klog.V(6).Infof("redact %s result failed: %v", stmt.GVK.Kind, err)
+		return err
+	}
+	return nil
+}
+
+// handleRedactDescribe 对Describe文本结果中的敏感数据进行脱敏
+func handleRedactDescribe(k8s *kom.Kubectl) error {
+	stmt := k8s.Statement
+	if stmt.Dest == nil || !comm.NeedRedact(stmt.GVK.Kind) || comm.RedactSkipped(stmt.Context) {
+		return nil
+	}
+	if comm.CanViewSensitiveData(stmt.Context, k8s.ID, statementNsList(k8s), stmt.GVK.Kind) {
+		return nil
+	}
+	comm.RedactDescribeDest(stmt.Dest)
+	return nil
+}
+
+// restoreRedacted 没有查看敏感数据权限的用户提交的对象中仍为脱敏占位值的字段，还原为集群中的原值，
+// 避免基于脱敏结果编辑后把占位值写回集群
+func restoreRedacted(k8s *kom.Kubectl, live *liveTarget) error {
+	stmt := k8s.Statement
+	if stmt.Dest == nil || !comm.NeedRedact(stmt.GVK.Kind) {
+		return nil
+	}
+	if comm.CanViewSensitiveData(stmt.Context, k8s.ID, statementNsList(k8s), stmt.GVK.Kind) {
+		return nil
+	}
+	obj, err := live.Get()
+	if err != nil {
+		return nil
+	}
+	if err = comm.RestoreRedactedDest(stmt.Dest, obj.Object); err != nil {
+		klog.V(6).Infof("restore redacted %s failed: %v", stmt.GVK.Kind, err)
+		return err
+	}
+	return nil
+}
+
+func statementNsList(k8s *kom.Kubectl) []string {
+	stmt := k8s.Statement
+	nsList := stmt.NamespaceList
+	if stmt.Namespace != "" {
+		nsList = append(nsList, stmt.Namespace)
+	}
+	return nsList
+}
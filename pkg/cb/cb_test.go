@@ -0,0 +1,83 @@
+package cb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/weibaohui/k8m/pkg/models"
+)
+
+type fakeLabels struct {
+	labels map[string]string
+	err    error
+	calls  int
+}
+
+func (f *fakeLabels) Labels() (map[string]string, error) {
+	f.calls++
+	return f.labels, f.err
+}
+
+func TestScopedLabelSetsReadActions(t *testing.T) {
+	grant := &models.ClusterUserRole{LabelSelector: "team=a"}
+	noChange := func() (map[string]string, error) {
+		t.Fatalf("changed labels should not be resolved for read actions")
+		return nil, nil
+	}
+
+	for _, action := range []string{"exec", "logs", "describe"} {
+		t.Run(action, func(t *testing.T) {
+			for _, tt := range []struct {
+				team string
+				want bool
+			}{{"a", true}, {"b", false}} {
+				live := &fakeLabels{labels: map[string]string{"team": tt.team}}
+				name, sets, unscoped, err := scopedLabelSets(action, "web-0", nil, live, noChange)
+				if err != nil || unscoped || name != "web-0" {
+					t.Fatalf("scopedLabelSets(%s) = %q, %v, %v, %v", action, name, sets, unscoped, err)
+				}
+				if live.calls != 1 || len(sets) != 1 {
+					t.Fatalf("%s should check the live object's labels once, got %d calls, %d sets", action, live.calls, len(sets))
+				}
+				if got := grant.MatchLabels(sets[0]); got != tt.want {
+					t.Errorf("%s on team=%s: grant matched = %v, want %v", action, tt.team, got, tt.want)
+				}
+			}
+
+			// 标签无法读取时拒绝
+			live := &fakeLabels{err: errors.New("not found")}
+			if _, _, _, err := scopedLabelSets(action, "web-0", nil, live, noChange); err == nil {
+				t.Errorf("%s should fail when labels can't be resolved", action)
+			}
+
+			// 未指定名称时仅未限定范围的授权生效
+			if _, _, unscoped, _ := scopedLabelSets(action, "", nil, &fakeLabels{}, noChange); !unscoped {
+				t.Errorf("%s without a name should be unscoped", action)
+			}
+		})
+	}
+}
+
+func TestScopedLabelSetsChanges(t *testing.T) {
+	dest := map[string]any{"metadata": map[string]any{"name": "web", "labels": map[string]any{"team": "b"}}}
+
+	name, sets, unscoped, err := scopedLabelSets("create", "", dest, &fakeLabels{}, nil)
+	if err != nil || unscoped || name != "web" || len(sets) != 1 || sets[0]["team"] != "b" {
+		t.Fatalf("create should use the submitted name and labels, got %q %v %v %v", name, sets, unscoped, err)
+	}
+
+	live := &fakeLabels{labels: map[string]string{"team": "a"}}
+	changed := func() (map[string]string, error) { return map[string]string{"team": "b"}, nil }
+	_, sets, _, err = scopedLabelSets("patch", "web", nil, live, changed)
+	if err != nil || len(sets) != 2 || sets[0]["team"] != "a" || sets[1]["team"] != "b" {
+		t.Fatalf("patch should check labels before and after the change, got %v %v", sets, err)
+	}
+
+	if _, _, unscoped, _ = scopedLabelSets("delete", "", nil, &fakeLabels{}, nil); !unscoped {
+		t.Errorf("unnamed delete should be unscoped")
+	}
+
+	if _, sets, unscoped, _ = scopedLabelSets("list", "", nil, &fakeLabels{}, nil); unscoped || sets != nil {
+		t.Errorf("list should be left to the result filter")
+	}
+}
@@ -0,0 +1,117 @@
+package comm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+)
+
+// SubmitApprovalIfRequired 判断操作是否命中审批策略，命中则以当前用户身份提交待审批请求
+// req.Namespace 可以是逗号分割的多个命名空间，任意一个命中策略即需要审批
+// verb 为该操作对应的权限动作（如 delete、update），提交前先校验申请人本身具备该权限
+// 返回true表示已提交审批，调用方不应再立即执行操作
+func SubmitApprovalIfRequired(ctx context.Context, req *models.ApprovalRequest, verb string) (bool, error) {
+	return SubmitApprovalWithCheck(ctx, req, func(nsList []string) error {
+		return CheckPermissionLogic(ctx, req.Cluster, nsList, req.Namespace, req.Name, verb, req.Group, req.Kind)
+	})
+}
+
+// SubmitApprovalWithCheck 同 SubmitApprovalIfRequired，由 check 校验申请人本身的权限，
+// 用于一次操作涉及多个资源（如YAML删除）需逐个校验的场景
+func SubmitApprovalWithCheck(ctx context.Context, req *models.ApprovalRequest, check func(nsList []string) error) (bool, error) {
+	var nsList []string
+	if req.Namespace != "" {
+		nsList = strings.Split(req.Namespace, ",")
+	}
+	policy := service.ApprovalService().MatchPolicy(req.Cluster, nsList, req.Action)
+	if policy == nil {
+		return false, nil
+	}
+	if err := check(nsList); err != nil {
+		return false, err
+	}
+	req.RequestedBy = fmt.Sprintf("%s", ctx.Value(constants.JwtUserName))
+	if err := service.ApprovalService().Submit(policy, req); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// BatchResult 批量操作的结果汇总，记录提交审批的数量及执行失败的项
+type BatchResult struct {
+	Unit      string   // 计量单位，如 项、个节点
+	Submitted int      // 已提交审批的数量
+	Failed    []string // 执行失败的项及原因
+}
+
+// Add 记录单项的结果，item 为资源标识，如 ns/name
+func (r *BatchResult) Add(item string, submitted bool, err error) {
+	if err != nil {
+		r.Failed = append(r.Failed, fmt.Sprintf("%s: %v", item, err))
+		return
+	}
+	if submitted {
+		r.Submitted++
+	}
+}
+
+// Err 存在失败项时返回汇总错误，同时包含已提交审批的数量
+func (r *BatchResult) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("%d%s执行失败：%s", len(r.Failed), r.Unit, strings.Join(r.Failed, "；"))
+	if r.Submitted > 0 {
+		msg = fmt.Sprintf("其中%d%s需要审批，已提交审批申请；%s", r.Submitted, r.Unit, msg)
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+// Message 全部成功时的提示信息，没有提交审批时返回空
+func (r *BatchResult) Message() string {
+	if r.Submitted == 0 {
+		return ""
+	}
+	return fmt.Sprintf("其中%d%s需要审批，已提交审批申请", r.Submitted, r.Unit)
+}
+
+// CheckApprovePermission 校验当前用户是否可以审批该请求
+// 申请人不能审批自己的申请；策略指定了审批角色时，需在该集群具备此角色，否则需具备 approve 权限
+func CheckApprovePermission(ctx context.Context, req *models.ApprovalRequest) error {
+	username := fmt.Sprintf("%s", ctx.Value(constants.JwtUserName))
+	if username == req.RequestedBy {
+		return fmt.Errorf("用户[%s]不能审批自己提交的申请", username)
+	}
+	if service.UserService().IsUserPlatformAdmin(username) {
+		return nil
+	}
+
+	policy := &models.ApprovalPolicy{}
+	policy, err := policy.GetOne(nil, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", req.PolicyID)
+	})
+	if err == nil && policy.ApproverRole != "" {
+		clusterUserRoles, err := service.UserService().GetClusters(username)
+		if err != nil {
+			return fmt.Errorf("用户[%s]获取集群授权错误，默认阻止", username)
+		}
+		if _, ok := slice.FindBy(clusterUserRoles, func(index int, item *models.ClusterUserRole) bool {
+			return item.Cluster == req.Cluster && item.Role == policy.ApproverRole
+		}); !ok {
+			return fmt.Errorf("用户[%s]没有集群[%s] [%s]角色，不能审批", username, req.Cluster, policy.ApproverRole)
+		}
+		return nil
+	}
+
+	var nsList []string
+	if req.Namespace != "" {
+		nsList = strings.Split(req.Namespace, ",")
+	}
+	return CheckPermissionLogic(ctx, req.Cluster, nsList, req.Namespace, req.Name, constants.ActionApprove, req.Group, req.Kind)
+}
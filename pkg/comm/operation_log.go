@@ -0,0 +1,162 @@
+package comm
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// operationLogMaxSize 操作日志中请求内容、变更差异的最大字节数（含截断提示），需小于 MySQL TEXT 类型的 65535 字节上限
+const operationLogMaxSize = 65000
+
+// operationLogTruncatedSuffix 内容超长截断后追加的提示
+const operationLogTruncatedSuffix = "\n...(内容过长，已截断)"
+
+// OperationDetail 生成操作日志中记录的请求内容及变更前后差异
+// live 为变更前的资源对象，submitted 为提交的资源对象（create/update），patchData 为提交的补丁（patch）
+// 记录前统一脱敏，并去除 managedFields、status 等与本次变更无关的字段
+func OperationDetail(gvk schema.GroupVersionKind, live any, submitted any, patchType types.PatchType, patchData string) (string, string) {
+	keywords := sensitiveKeywords()
+	var params string
+	var after map[string]any
+
+	switch {
+	case submitted != nil:
+		after = toObjectMap(submitted)
+		if after != nil {
+			pruneObject(after)
+			utils.RedactObject(after, gvk.Kind, keywords)
+			params = utils.ToJSON(after)
+		}
+	case patchData != "":
+		params = redactPatch(gvk.Kind, patchData, keywords)
+		if live != nil {
+			after = applyPatch(gvk, toObjectMap(live), patchType, patchData)
+			if after != nil {
+				pruneObject(after)
+				utils.RedactObject(after, gvk.Kind, keywords)
+			}
+		}
+	}
+
+	var diff string
+	if before := toObjectMap(live); before != nil && after != nil {
+		pruneObject(before)
+		utils.RedactObject(before, gvk.Kind, keywords)
+		diff = utils.UnifiedDiff(toYaml(before), toYaml(after), "before", "after")
+	}
+	return truncate(params), truncate(diff)
+}
+
+func toObjectMap(obj any) map[string]any {
+	if obj == nil {
+		return nil
+	}
+	bs, err := json.Marshal(obj)
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if err = json.Unmarshal(bs, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// pruneObject 去除由服务端维护、与本次变更无关的字段，减少差异噪音
+func pruneObject(obj map[string]any) {
+	delete(obj, "status")
+	if metadata, ok := obj["metadata"].(map[string]any); ok {
+		for _, key := range []string{"managedFields", "resourceVersion", "generation", "uid", "creationTimestamp"} {
+			delete(metadata, key)
+		}
+	}
+}
+
+// applyPatch 在变更前的对象上应用补丁，得到变更后的对象
+// 内置资源的 strategic merge patch 按类型定义合并，CRD 等无类型定义的资源按 merge patch 处理
+func applyPatch(gvk schema.GroupVersionKind, live map[string]any, patchType types.PatchType, patchData string) map[string]any {
+	if live == nil {
+		return nil
+	}
+	original, err := json.Marshal(live)
+	if err != nil {
+		return nil
+	}
+	var patched []byte
+	switch patchType {
+	case types.JSONPatchType:
+		p, decodeErr := jsonpatch.DecodePatch([]byte(patchData))
+		if decodeErr != nil {
+			return nil
+		}
+		patched, err = p.Apply(original)
+	case types.StrategicMergePatchType:
+		if dataStruct, newErr := scheme.Scheme.New(gvk); newErr == nil {
+			patched, err = strategicpatch.StrategicMergePatch(original, []byte(patchData), dataStruct)
+		} else {
+			patched, err = jsonpatch.MergePatch(original, []byte(patchData))
+		}
+	default:
+		patched, err = jsonpatch.MergePatch(original, []byte(patchData))
+	}
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if err = json.Unmarshal(patched, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// redactPatch 对补丁内容脱敏
+// merge patch 按资源对象脱敏；json patch 中 Secret 的所有值均脱敏
+func redactPatch(kind string, patchData string, keywords []string) string {
+	var v any
+	if err := json.Unmarshal([]byte(patchData), &v); err != nil {
+		return patchData
+	}
+	switch node := v.(type) {
+	case map[string]any:
+		utils.RedactObject(node, kind, keywords)
+	case []any:
+		for _, item := range node {
+			op, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			if _, hasValue := op["value"]; hasValue && kind == "Secret" {
+				op["value"] = utils.RedactedValue
+			}
+		}
+	}
+	return utils.ToJSON(v)
+}
+
+func toYaml(obj map[string]any) string {
+	bs, err := yaml.Marshal(obj)
+	if err != nil {
+		return ""
+	}
+	return string(bs)
+}
+
+// truncate 按字节截断，截断位置回退到完整字符边界，结果连同提示不超过 operationLogMaxSize 字节
+func truncate(s string) string {
+	if len(s) <= operationLogMaxSize {
+		return s
+	}
+	end := operationLogMaxSize - len(operationLogTruncatedSuffix)
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end] + operationLogTruncatedSuffix
+}
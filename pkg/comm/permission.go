@@ -13,9 +13,32 @@ import (
 	"k8s.io/klog/v2"
 )
 
-// CheckPermissionLogic
+// CheckPermissionLogic 校验用户对指定集群资源执行action的权限
+// 先按内置集群角色校验，内置角色不满足时，再按用户引用的自定义角色校验
+// group、kind 为操作资源的GVK信息，用于自定义角色规则匹配
 // return err
-func CheckPermissionLogic(ctx context.Context, cluster string, nsList []string, ns, name, action string) error {
+func CheckPermissionLogic(ctx context.Context, cluster string, nsList []string, ns, name, action, group, kind string) error {
+	return CheckPermissionWithLabels(ctx, cluster, nsList, ns, name, action, group, kind, nil)
+}
+
+// CheckPermissionWithLabels 同 CheckPermissionLogic，额外按授权上的资源名称通配符、标签选择器过滤授权条目
+// lbs 为目标资源的标签，为nil表示标签未知（如列表查询），此时不按标签选择器过滤，由查询结果过滤兜底
+func CheckPermissionWithLabels(ctx context.Context, cluster string, nsList []string, ns, name, action, group, kind string, lbs map[string]string) error {
+	return checkPermissionInScope(ctx, cluster, nsList, ns, name, action, group, kind, func(item *models.ClusterUserRole) bool {
+		return item.MatchName(name) && (lbs == nil || item.MatchLabels(lbs))
+	})
+}
+
+// CheckPermissionUnscoped 同 CheckPermissionLogic，但仅未限定资源名称、标签的授权条目生效
+// 用于无法逐个校验目标资源的操作，如未指定名称的批量删除
+func CheckPermissionUnscoped(ctx context.Context, cluster string, nsList []string, ns, action, group, kind string) error {
+	return checkPermissionInScope(ctx, cluster, nsList, ns, "", action, group, kind, func(item *models.ClusterUserRole) bool {
+		return !item.HasScope()
+	})
+}
+
+// checkPermissionInScope 权限校验，inScope 判断该集群的授权条目是否覆盖目标资源
+func checkPermissionInScope(ctx context.Context, cluster string, nsList []string, ns, name, action, group, kind string, inScope func(item *models.ClusterUserRole) bool) error {
 
 	// 内部监听增加一个认证机制，不用做权限校验
 	// 比如node watch
@@ -51,6 +74,28 @@ func CheckPermissionLogic(ctx context.Context, cluster string, nsList []string,
 		return fmt.Errorf("用户[%s]没有集群[%s]访问权限", username, cluster)
 	}
 
+	// 授权限定了资源名称或标签时，仅保留与目标资源匹配的授权条目
+	clusterUserRoles = slice.Filter(clusterUserRoles, func(index int, item *models.ClusterUserRole) bool {
+		return item.Cluster != cluster || inScope(item)
+	})
+
+	err = checkBuiltinRoles(username, cluster, clusterUserRoles, nsList, action)
+	if err != nil {
+		// 内置角色不满足，再看自定义角色是否允许
+		if checkCustomRoles(cluster, clusterUserRoles, nsList, action, group, kind) {
+			klog.V(6).Infof("cb: cluster= %s,user= %s,  operation=%s,  resource=[%s/%s] 自定义角色授权通过",
+				cluster, username, action, ns, name)
+			return nil
+		}
+		return err
+	}
+	klog.V(6).Infof("cb: cluster= %s,user= %s,  operation=%s,  resource=[%s/%s] ",
+		cluster, username, action, ns, name)
+	return nil
+}
+
+// checkBuiltinRoles 按内置集群角色（集群管理员、集群只读、Exec）校验权限
+func checkBuiltinRoles(username, cluster string, clusterUserRoles []*models.ClusterUserRole, nsList []string, action string) error {
 	// 下面都是有集群的访问权限的情况，需要进一步区分是什么类型的操作。
 	// 以及是否有namespace的权限
 
@@ -120,7 +165,7 @@ func CheckPermissionLogic(ctx context.Context, cluster string, nsList []string,
 			}
 		}
 
-	case "delete", "update", "patch", "create":
+	case "delete", "update", "patch", "create", constants.ActionViewSecret, constants.ActionApprove:
 		changeClusters := slice.Filter(clusterUserRoles, func(index int, item *models.ClusterUserRole) bool {
 			return item.Cluster == cluster && item.Role == constants.RoleClusterAdmin
 		})
@@ -176,7 +221,119 @@ func CheckPermissionLogic(ctx context.Context, cluster string, nsList []string,
 			}
 		}
 	}
-	klog.V(6).Infof("cb: cluster= %s,user= %s,  operation=%s,  resource=[%s/%s] ",
-		cluster, username, action, ns, name)
-	return err
+	return nil
+}
+
+// checkCustomRoles 按自定义角色校验权限
+// 授权条目上的命名空间白名单、黑名单同样生效，任意一个自定义角色允许即通过
+func checkCustomRoles(cluster string, clusterUserRoles []*models.ClusterUserRole, nsList []string, action, group, kind string) bool {
+	for _, item := range clusterUserRoles {
+		if item.Cluster != cluster || constants.IsBuiltinClusterRole(item.Role) {
+			continue
+		}
+		if len(nsList) > 0 {
+			if item.BlacklistNamespaces != "" && utils.AnyIn(nsList, strings.Split(item.BlacklistNamespaces, ",")) {
+				continue
+			}
+			if item.Namespaces != "" && !utils.AllIn(nsList, strings.Split(item.Namespaces, ",")) {
+				continue
+			}
+		}
+		role, err := service.CustomRoleService().GetByName(item.Role)
+		if err != nil {
+			klog.V(6).Infof("获取自定义角色[%s]失败: %v", item.Role, err)
+			continue
+		}
+		if role.Allow(action, group, kind, nsList) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResourceScopes 获取用户在集群、命名空间范围内允许对该类资源执行 action 的授权条目
+// restricted 为true表示这些授权全部限定了资源名称或标签，查询结果需要按授权范围过滤
+// 未限定范围的授权仅在其本身允许该操作时才解除过滤，避免只授权了其他资源类型的自定义角色放开全部资源
+func ResourceScopes(ctx context.Context, cluster string, nsList []string, action, group, kind string) ([]*models.ClusterUserRole, bool) {
+	if constants.RolePlatformAdmin == ctx.Value(constants.RolePlatformAdmin) {
+		return nil, false
+	}
+	username := fmt.Sprintf("%s", ctx.Value(constants.JwtUserName))
+	if username == "" || service.UserService().IsUserPlatformAdmin(username) {
+		return nil, false
+	}
+	clusterUserRoles, err := service.UserService().GetClusters(username)
+	if err != nil {
+		return nil, false
+	}
+	var grants []*models.ClusterUserRole
+	for _, item := range clusterUserRoles {
+		if item.Cluster != cluster {
+			continue
+		}
+		if len(nsList) > 0 {
+			if item.BlacklistNamespaces != "" && utils.AnyIn(nsList, strings.Split(item.BlacklistNamespaces, ",")) {
+				continue
+			}
+			if item.Namespaces != "" && !utils.AllIn(nsList, strings.Split(item.Namespaces, ",")) {
+				continue
+			}
+		}
+		if !grantAllows(item, nsList, action, group, kind) {
+			continue
+		}
+		if !item.HasScope() {
+			return nil, false
+		}
+		grants = append(grants, item)
+	}
+	return grants, len(grants) > 0
+}
+
+// grantAllows 判断单个授权条目的角色是否允许对该类资源执行 action，不含命名空间黑白名单的判断
+func grantAllows(item *models.ClusterUserRole, nsList []string, action, group, kind string) bool {
+	switch item.Role {
+	case constants.RoleClusterAdmin:
+		return true
+	case constants.RoleClusterReadonly:
+		switch action {
+		case "exec", "delete", "update", "patch", "create", constants.ActionViewSecret, constants.ActionApprove:
+			return false
+		}
+		return true
+	case constants.RoleClusterPodExec:
+		return action == "exec"
+	}
+	role, err := service.CustomRoleService().GetByName(item.Role)
+	if err != nil {
+		return false
+	}
+	return role.Allow(action, group, kind, nsList)
+}
+
+// MatchAnyScope 判断资源是否在任意一个授权条目的范围内
+func MatchAnyScope(grants []*models.ClusterUserRole, name string, lbs map[string]string) bool {
+	for _, item := range grants {
+		if item.MatchName(name) && item.MatchLabels(lbs) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScopedGrant 判断用户在集群上是否存在限定了资源名称或标签的授权条目
+// 存在时，变更类操作需要获取目标资源的标签进行校验
+func HasScopedGrant(ctx context.Context, cluster string) bool {
+	if constants.RolePlatformAdmin == ctx.Value(constants.RolePlatformAdmin) {
+		return false
+	}
+	username := fmt.Sprintf("%s", ctx.Value(constants.JwtUserName))
+	clusterUserRoles, err := service.UserService().GetClusters(username)
+	if err != nil {
+		return false
+	}
+	_, ok := slice.FindBy(clusterUserRoles, func(index int, item *models.ClusterUserRole) bool {
+		return item.Cluster == cluster && item.HasScope()
+	})
+	return ok
 }
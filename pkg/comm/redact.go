@@ -0,0 +1,152 @@
+package comm
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/flag"
+)
+
+// redactKinds 需要进行脱敏处理的资源类型
+var redactKinds = map[string]bool{
+	"Secret":                true,
+	"ConfigMap":             true,
+	"Pod":                   true,
+	"Deployment":            true,
+	"StatefulSet":           true,
+	"DaemonSet":             true,
+	"ReplicaSet":            true,
+	"ReplicationController": true,
+	"Job":                   true,
+	"CronJob":               true,
+}
+
+// NeedRedact 判断该资源类型是否可能包含敏感数据
+func NeedRedact(kind string) bool {
+	return redactKinds[kind]
+}
+
+// CanViewSensitiveData 判断当前用户是否有查看敏感数据明文的权限
+// 平台管理员、集群管理员具备该权限，自定义角色需在规则中授予 view_secret 动作
+func CanViewSensitiveData(ctx context.Context, cluster string, nsList []string, kind string) bool {
+	if constants.RolePlatformAdmin == ctx.Value(constants.RolePlatformAdmin) {
+		return true
+	}
+	ns := ""
+	if len(nsList) == 1 {
+		ns = nsList[0]
+	}
+	return CheckPermissionLogic(ctx, cluster, nsList, ns, "", constants.ActionViewSecret, "", kind) == nil
+}
+
+// WithoutRedact 返回读取结果不脱敏的上下文
+// 仅用于读取资源、修改后再回写集群的内部读取，读取结果不得直接返回给用户
+func WithoutRedact(ctx context.Context) context.Context {
+	return context.WithValue(ctx, constants.SkipRedact, true)
+}
+
+// RedactSkipped 判断上下文是否标记了读取结果不脱敏
+func RedactSkipped(ctx context.Context) bool {
+	skip, _ := ctx.Value(constants.SkipRedact).(bool)
+	return skip
+}
+
+// RestoreRedactedDest 将待提交对象中仍为脱敏占位值的字段还原为集群中的原值
+// dest 为指向资源对象的指针，live 为资源在集群中的当前状态
+func RestoreRedactedDest(dest any, live any) error {
+	var obj, liveObj map[string]any
+	bs, err := json.Marshal(dest)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(bs, &obj); err != nil {
+		return err
+	}
+	if bs, err = json.Marshal(live); err != nil {
+		return err
+	}
+	if err = json.Unmarshal(bs, &liveObj); err != nil {
+		return err
+	}
+	if !utils.RestoreRedacted(obj, liveObj) {
+		return nil
+	}
+	return writeBack(obj, dest)
+}
+
+// RedactDest 对查询结果进行脱敏，dest 为指向资源对象或资源对象列表的指针
+// 通过JSON序列化转换为通用结构处理，处理后再写回dest
+func RedactDest(dest any, kind string) error {
+	var v any
+	bs, err := json.Marshal(dest)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(bs, &v); err != nil {
+		return err
+	}
+
+	keywords := sensitiveKeywords()
+	changed := false
+	switch node := v.(type) {
+	case map[string]any:
+		changed = utils.RedactObject(node, kind, keywords)
+	case []any:
+		for _, item := range node {
+			if m, ok := item.(map[string]any); ok {
+				if utils.RedactObject(m, kind, keywords) {
+					changed = true
+				}
+			}
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return writeBack(v, dest)
+}
+
+// RedactEnvDest 对环境变量列表进行脱敏，dest 为指向环境变量列表的指针
+func RedactEnvDest(dest any) error {
+	var items []any
+	bs, err := json.Marshal(dest)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(bs, &items); err != nil {
+		return err
+	}
+	if !utils.RedactEnvItems(items, sensitiveKeywords()) {
+		return nil
+	}
+	return writeBack(items, dest)
+}
+
+// RedactDescribeDest 对describe文本结果进行脱敏，dest 为 *[]byte 或 *string
+func RedactDescribeDest(dest any) {
+	keywords := sensitiveKeywords()
+	switch d := dest.(type) {
+	case *[]byte:
+		if d != nil {
+			*d = []byte(utils.RedactDescribe(string(*d), keywords))
+		}
+	case *string:
+		if d != nil {
+			*d = utils.RedactDescribe(*d, keywords)
+		}
+	}
+}
+
+func writeBack(v any, dest any) error {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bs, dest)
+}
+
+func sensitiveKeywords() []string {
+	return utils.SplitSensitiveKeys(flag.Init().SensitiveKeys)
+}
@@ -0,0 +1,103 @@
+package comm
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/weibaohui/k8m/pkg/models"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// FilterDestByScope 按授权范围过滤列表查询结果，dest 为指向资源对象列表的指针
+// 不在任何授权条目范围（资源名称、标签）内的资源将被移除
+func FilterDestByScope(dest any, grants []*models.ClusterUserRole) error {
+	var items []any
+	bs, err := json.Marshal(dest)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(bs, &items); err != nil {
+		return err
+	}
+
+	kept := make([]any, 0, len(items))
+	for _, item := range items {
+		name, lbs := objectNameAndLabels(item)
+		if MatchAnyScope(grants, name, lbs) {
+			kept = append(kept, item)
+		}
+	}
+	if len(kept) == len(items) {
+		return nil
+	}
+	return writeBack(kept, dest)
+}
+
+// DestInScope 判断单个资源查询结果是否在授权范围内，不在范围内时清空dest
+func DestInScope(dest any, grants []*models.ClusterUserRole) bool {
+	var obj any
+	bs, err := json.Marshal(dest)
+	if err != nil {
+		return false
+	}
+	if err = json.Unmarshal(bs, &obj); err != nil {
+		return false
+	}
+	name, lbs := objectNameAndLabels(obj)
+	if MatchAnyScope(grants, name, lbs) {
+		return true
+	}
+	if v := reflect.ValueOf(dest); v.Kind() == reflect.Ptr && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+	return false
+}
+
+// DestNameAndLabels 读取提交的资源对象（如 create/update 的 dest）的名称及标签，没有标签时返回空标签
+func DestNameAndLabels(dest any) (string, map[string]string) {
+	var obj any
+	if bs, err := json.Marshal(dest); err == nil {
+		_ = json.Unmarshal(bs, &obj)
+	}
+	name, lbs := objectNameAndLabels(obj)
+	if lbs == nil {
+		lbs = map[string]string{}
+	}
+	return name, lbs
+}
+
+// PatchedLabels 在变更前的对象上应用补丁，返回变更后资源的标签
+func PatchedLabels(gvk schema.GroupVersionKind, live map[string]any, patchType types.PatchType, patchData string) (map[string]string, error) {
+	after := applyPatch(gvk, live, patchType, patchData)
+	if after == nil {
+		return nil, fmt.Errorf("无法解析补丁内容")
+	}
+	_, lbs := objectNameAndLabels(after)
+	if lbs == nil {
+		lbs = map[string]string{}
+	}
+	return lbs, nil
+}
+
+func objectNameAndLabels(obj any) (string, map[string]string) {
+	m, ok := obj.(map[string]any)
+	if !ok {
+		return "", nil
+	}
+	metadata, ok := m["metadata"].(map[string]any)
+	if !ok {
+		return "", nil
+	}
+	name, _ := metadata["name"].(string)
+	lbs := map[string]string{}
+	if raw, ok := metadata["labels"].(map[string]any); ok {
+		for k, v := range raw {
+			if s, ok := v.(string); ok {
+				lbs[k] = s
+			}
+		}
+	}
+	return name, lbs
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// UnifiedDiff 生成两段文本的 unified diff，内容相同时返回空字符串
+func UnifiedDiff(before, after, fromName, toName string) string {
+	if before == after {
+		return ""
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(before),
+		B:        difflib.SplitLines(after),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+	if err != nil {
+		return ""
+	}
+	return diff
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "replicas: 1\nimage: nginx:1.25\n"
+	after := "replicas: 3\nimage: nginx:1.25\n"
+	diff := UnifiedDiff(before, after, "before", "after")
+	if !strings.Contains(diff, "-replicas: 1") || !strings.Contains(diff, "+replicas: 3") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+	if UnifiedDiff(before, before, "before", "after") != "" {
+		t.Errorf("identical text should produce empty diff")
+	}
+}
@@ -0,0 +1,303 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/base64"
+	"strings"
+)
+
+// RedactedValue 脱敏后展示的值
+const RedactedValue = "******"
+
+// lastAppliedAnnotation kubectl apply 记录的原始配置，其中包含明文数据，需要一并脱敏
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// SplitSensitiveKeys 将逗号分割的敏感关键字转换为小写数组
+func SplitSensitiveKeys(keys string) []string {
+	var result []string
+	for _, k := range strings.Split(keys, ",") {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k != "" {
+			result = append(result, k)
+		}
+	}
+	return result
+}
+
+// IsSensitiveKey 判断key是否包含任意敏感关键字，不区分大小写
+func IsSensitiveKey(key string, keywords []string) bool {
+	key = strings.ToLower(key)
+	for _, kw := range keywords {
+		if kw != "" && strings.Contains(key, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactObject 对单个资源对象（JSON反序列化后的map）进行脱敏，返回是否有改动
+// Secret 的 data、stringData 全部脱敏；ConfigMap 中命中敏感关键字的key脱敏；
+// 任意层级的容器 env 中，名称命中敏感关键字的 value 脱敏。
+// 如果对象是 List（含 items），则逐个处理。
+func RedactObject(obj map[string]any, kind string, keywords []string) bool {
+	if items, ok := obj["items"].([]any); ok {
+		changed := false
+		for _, item := range items {
+			if m, ok := item.(map[string]any); ok {
+				if RedactObject(m, kind, keywords) {
+					changed = true
+				}
+			}
+		}
+		return changed
+	}
+
+	if k, ok := obj["kind"].(string); ok && k != "" {
+		kind = k
+	}
+
+	changed := false
+	switch kind {
+	case "Secret":
+		if data, ok := obj["data"].(map[string]any); ok {
+			masked := base64.StdEncoding.EncodeToString([]byte(RedactedValue))
+			for key := range data {
+				data[key] = masked
+				changed = true
+			}
+		}
+		if data, ok := obj["stringData"].(map[string]any); ok {
+			for key := range data {
+				data[key] = RedactedValue
+				changed = true
+			}
+		}
+	case "ConfigMap":
+		for _, field := range []string{"data", "binaryData"} {
+			if data, ok := obj[field].(map[string]any); ok {
+				for key := range data {
+					if IsSensitiveKey(key, keywords) {
+						data[key] = RedactedValue
+						if field == "binaryData" {
+							data[key] = base64.StdEncoding.EncodeToString([]byte(RedactedValue))
+						}
+						changed = true
+					}
+				}
+			}
+		}
+	default:
+		changed = redactEnv(obj, keywords)
+	}
+
+	if changed {
+		if metadata, ok := obj["metadata"].(map[string]any); ok {
+			if annotations, ok := metadata["annotations"].(map[string]any); ok {
+				if _, exists := annotations[lastAppliedAnnotation]; exists {
+					annotations[lastAppliedAnnotation] = RedactedValue
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// redactEnv 递归查找 env 数组，对名称命中敏感关键字的 value 脱敏
+func redactEnv(v any, keywords []string) bool {
+	changed := false
+	switch node := v.(type) {
+	case map[string]any:
+		for key, child := range node {
+			if key == "env" {
+				if envs, ok := child.([]any); ok {
+					for _, e := range envs {
+						if env, ok := e.(map[string]any); ok {
+							name, _ := env["name"].(string)
+							if _, hasValue := env["value"]; hasValue && IsSensitiveKey(name, keywords) {
+								env["value"] = RedactedValue
+								changed = true
+							}
+						}
+					}
+					continue
+				}
+			}
+			if redactEnv(child, keywords) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, child := range node {
+			if redactEnv(child, keywords) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// RedactEnvItems 对环境变量列表进行脱敏
+// 列表元素为任意结构体反序列化后的map，包含name的字段视为变量名，包含value的字段视为变量值
+func RedactEnvItems(items []any, keywords []string) bool {
+	changed := false
+	for _, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		var name string
+		for k, v := range m {
+			if s, ok := v.(string); ok && strings.Contains(strings.ToLower(k), "name") && !strings.Contains(strings.ToLower(k), "container") {
+				name = s
+				break
+			}
+		}
+		if !IsSensitiveKey(name, keywords) {
+			continue
+		}
+		for k := range m {
+			if strings.Contains(strings.ToLower(k), "value") {
+				m[k] = RedactedValue
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// RedactDescribe 对 kubectl describe 输出的文本进行脱敏
+// ConfigMap 的 Data 段中命中敏感关键字的值、容器 Environment 段中命中敏感关键字的变量值会被替换
+func RedactDescribe(text string, keywords []string) string {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	var out []string
+	envIndent := -1
+	maskingData := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		// ConfigMap Data 段格式：
+		// key:
+		// ----
+		// value
+		if i+1 < len(lines) && strings.HasSuffix(trimmed, ":") && strings.HasPrefix(strings.TrimSpace(lines[i+1]), "----") {
+			maskingData = IsSensitiveKey(strings.TrimSuffix(trimmed, ":"), keywords)
+			out = append(out, line, lines[i+1])
+			i++
+			if maskingData {
+				out = append(out, RedactedValue)
+			}
+			continue
+		}
+		if maskingData {
+			if trimmed != "BinaryData" && trimmed != "Events:" && !strings.HasPrefix(trimmed, "====") {
+				if trimmed == "" {
+					out = append(out, line)
+				}
+				continue
+			}
+			maskingData = false
+		}
+
+		// 容器 Environment 段格式：
+		//     Environment:
+		//       NAME:  value
+		if trimmed == "Environment:" {
+			envIndent = indent
+		} else if envIndent >= 0 {
+			if trimmed == "" || indent <= envIndent {
+				envIndent = -1
+			} else if idx := strings.Index(trimmed, ":"); idx > 0 {
+				name := trimmed[:idx]
+				value := strings.TrimSpace(trimmed[idx+1:])
+				if value != "" && !strings.HasPrefix(value, "<set to the key") && IsSensitiveKey(name, keywords) {
+					line = line[:indent] + name + ":  " + RedactedValue
+				}
+			}
+		}
+		out = append(out, line)
+	}
+	result := strings.Join(out, "\n")
+	if strings.HasSuffix(text, "\n") {
+		result += "\n"
+	}
+	return result
+}
+
+// RestoreRedacted 将提交对象中仍为脱敏占位值的字段还原为集群中的原值，返回是否有改动
+// 用于无查看敏感数据权限的用户编辑脱敏后的资源再提交时，避免把占位值写回集群。
+// 数组元素按 name 字段与原对象对应，没有 name 时按下标对应。
+func RestoreRedacted(obj, live map[string]any) bool {
+	if live == nil {
+		return false
+	}
+	changed := false
+	masked := base64.StdEncoding.EncodeToString([]byte(RedactedValue))
+	if kind, _ := obj["kind"].(string); kind == "Secret" {
+		// stringData 不会持久化，占位值直接去掉，保留 data 中的原值
+		if data, ok := obj["stringData"].(map[string]any); ok {
+			for key, v := range data {
+				if v == RedactedValue {
+					delete(data, key)
+					changed = true
+				}
+			}
+		}
+	}
+	for key, v := range obj {
+		liveValue, exists := live[key]
+		if !exists {
+			continue
+		}
+		switch node := v.(type) {
+		case string:
+			if node == RedactedValue || node == masked {
+				if _, ok := liveValue.(string); ok && liveValue != node {
+					obj[key] = liveValue
+					changed = true
+				}
+			}
+		case map[string]any:
+			if liveMap, ok := liveValue.(map[string]any); ok && RestoreRedacted(node, liveMap) {
+				changed = true
+			}
+		case []any:
+			if liveItems, ok := liveValue.([]any); ok && restoreRedactedItems(node, liveItems) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func restoreRedactedItems(items, liveItems []any) bool {
+	changed := false
+	for i, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		var liveItem map[string]any
+		if name, ok := m["name"].(string); ok {
+			for _, l := range liveItems {
+				if lm, ok := l.(map[string]any); ok && lm["name"] == name {
+					liveItem = lm
+					break
+				}
+			}
+		} else if i < len(liveItems) {
+			liveItem, _ = liveItems[i].(map[string]any)
+		}
+		if RestoreRedacted(m, liveItem) {
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestRedactObjectSecret(t *testing.T) {
+	obj := map[string]any{
+		"kind": "Secret",
+		"metadata": map[string]any{
+			"annotations": map[string]any{
+				"kubectl.kubernetes.io/last-applied-configuration": `{"data":{"password":"cGFzcw=="}}`,
+			},
+		},
+		"data":       map[string]any{"password": "cGFzcw=="},
+		"stringData": map[string]any{"user": "admin"},
+	}
+	if !RedactObject(obj, "", nil) {
+		t.Fatalf("expected secret to be redacted")
+	}
+	masked := base64.StdEncoding.EncodeToString([]byte(RedactedValue))
+	if obj["data"].(map[string]any)["password"] != masked {
+		t.Errorf("data not redacted: %v", obj["data"])
+	}
+	if obj["stringData"].(map[string]any)["user"] != RedactedValue {
+		t.Errorf("stringData not redacted: %v", obj["stringData"])
+	}
+	annotations := obj["metadata"].(map[string]any)["annotations"].(map[string]any)
+	if annotations[lastAppliedAnnotation] != RedactedValue {
+		t.Errorf("last-applied annotation not redacted")
+	}
+}
+
+func TestRedactObjectConfigMapAndEnv(t *testing.T) {
+	keywords := SplitSensitiveKeys("password, TOKEN")
+
+	list := map[string]any{
+		"items": []any{
+			map[string]any{
+				"kind": "ConfigMap",
+				"data": map[string]any{"db_password": "p", "log_level": "info"},
+			},
+		},
+	}
+	RedactObject(list, "ConfigMap", keywords)
+	data := list["items"].([]any)[0].(map[string]any)["data"].(map[string]any)
+	if data["db_password"] != RedactedValue || data["log_level"] != "info" {
+		t.Errorf("unexpected configmap redaction: %v", data)
+	}
+
+	deploy := map[string]any{
+		"spec": map[string]any{"template": map[string]any{"spec": map[string]any{
+			"containers": []any{map[string]any{
+				"env": []any{
+					map[string]any{"name": "API_TOKEN", "value": "abc"},
+					map[string]any{"name": "MODE", "value": "prod"},
+				},
+			}},
+		}}},
+	}
+	if !RedactObject(deploy, "Deployment", keywords) {
+		t.Fatalf("expected env to be redacted")
+	}
+	if !strings.Contains(ToJSON(deploy), `"prod"`) || strings.Contains(ToJSON(deploy), "abc") {
+		t.Errorf("unexpected env redaction: %s", ToJSON(deploy))
+	}
+}
+
+func TestRedactDescribe(t *testing.T) {
+	keywords := SplitSensitiveKeys("password")
+	text := `Name:         app-config
+Data
+====
+db_password:
+----
+s3cr3t
+
+mode:
+----
+prod
+
+Events:  <none>
+`
+	got := RedactDescribe(text, keywords)
+	if strings.Contains(got, "s3cr3t") {
+		t.Errorf("configmap value not redacted:\n%s", got)
+	}
+	if !strings.Contains(got, "prod") {
+		t.Errorf("non sensitive value should be kept:\n%s", got)
+	}
+
+	pod := `Containers:
+  app:
+    Environment:
+      DB_PASSWORD:  s3cr3t
+      MODE:         prod
+    Mounts:         <none>
+`
+	got = RedactDescribe(pod, keywords)
+	if strings.Contains(got, "s3cr3t") || !strings.Contains(got, "prod") {
+		t.Errorf("unexpected env redaction:\n%s", got)
+	}
+}
+
+func TestRestoreRedactedRoundTrip(t *testing.T) {
+	keywords := SplitSensitiveKeys("password")
+	newObj := func() map[string]any {
+		return map[string]any{
+			"kind": "Deployment",
+			"metadata": map[string]any{
+				"annotations": map[string]any{lastAppliedAnnotation: `{"spec":{}}`},
+			},
+			"spec": map[string]any{"template": map[string]any{"spec": map[string]any{
+				"containers": []any{
+					map[string]any{"name": "app", "image": "app:v1", "env": []any{
+						map[string]any{"name": "DB_PASSWORD", "value": "s3cret"},
+						map[string]any{"name": "MODE", "value": "prod"},
+					}},
+				},
+			}}},
+		}
+	}
+	live := newObj()
+	obj := newObj()
+	if !RedactObject(obj, "", keywords) {
+		t.Fatalf("expected deployment to be redacted")
+	}
+	// 模拟用户基于脱敏结果只修改镜像
+	container := obj["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)["containers"].([]any)[0].(map[string]any)
+	container["image"] = "app:v2"
+	if !RestoreRedacted(obj, live) {
+		t.Fatalf("expected masked values to be restored")
+	}
+	env := container["env"].([]any)
+	if env[0].(map[string]any)["value"] != "s3cret" {
+		t.Errorf("env value not restored: %v", env[0])
+	}
+	if container["image"] != "app:v2" {
+		t.Errorf("user change lost: %v", container["image"])
+	}
+	annotations := obj["metadata"].(map[string]any)["annotations"].(map[string]any)
+	if annotations[lastAppliedAnnotation] != `{"spec":{}}` {
+		t.Errorf("last-applied annotation not restored")
+	}
+
+	secretLive := map[string]any{"kind": "Secret", "data": map[string]any{"token": "dG9r"}}
+	secret := map[string]any{"kind": "Secret", "data": map[string]any{"token": "dG9r"}, "stringData": map[string]any{"user": "admin"}}
+	RedactObject(secret, "", nil)
+	RestoreRedacted(secret, secretLive)
+	if secret["data"].(map[string]any)["token"] != "dG9r" {
+		t.Errorf("secret data not restored: %v", secret["data"])
+	}
+	if _, exists := secret["stringData"].(map[string]any)["user"]; exists {
+		t.Errorf("masked stringData should be dropped: %v", secret["stringData"])
+	}
+}
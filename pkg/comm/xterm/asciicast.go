@@ -0,0 +1,139 @@
+package xterm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// asciicast v2 事件类型
+const (
+	EventOutput = "o" // 终端输出
+	EventInput  = "i" // 用户输入
+	EventResize = "r" // 窗口大小调整，数据格式为 COLSxROWS
+)
+
+// AsciicastHeader asciicast v2 文件头
+type AsciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     uint16            `json:"width"`
+	Height    uint16            `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recorder 以 asciicast v2 格式录制终端会话
+// 首行为文件头，其后每行一个事件 [相对开始时间(秒), 事件类型, 数据]
+// 文件头延迟到第一个事件时写入：若第一个事件为窗口大小调整，则以该尺寸作为初始尺寸
+type Recorder struct {
+	mu            sync.Mutex
+	w             io.Writer
+	header        AsciicastHeader
+	start         time.Time
+	headerWritten bool
+	pending       []byte // 上次输出末尾不完整的UTF-8字节，与下次输出拼接后再记录
+	err           error
+}
+
+func NewRecorder(w io.Writer, title string) *Recorder {
+	now := time.Now()
+	return &Recorder{
+		w:     w,
+		start: now,
+		header: AsciicastHeader{
+			Version:   2,
+			Width:     80,
+			Height:    24,
+			Timestamp: now.Unix(),
+			Title:     title,
+			Env:       map[string]string{"TERM": "xterm-256color", "SHELL": "/bin/sh"},
+		},
+	}
+}
+
+// Output 记录终端输出
+// 输出按块到达，多字节字符可能被截断在两块之间，末尾不完整的字节留到下次一并记录
+func (r *Recorder) Output(data []byte) {
+	r.mu.Lock()
+	data = append(r.pending, data...)
+	n := completeUTF8Len(data)
+	r.pending = append([]byte(nil), data[n:]...)
+	r.mu.Unlock()
+	r.write(EventOutput, string(data[:n]))
+}
+
+// Input 记录用户输入
+func (r *Recorder) Input(data []byte) {
+	r.write(EventInput, string(data))
+}
+
+// Resize 记录窗口大小调整
+func (r *Recorder) Resize(cols, rows uint16) {
+	r.mu.Lock()
+	if !r.headerWritten && cols > 0 && rows > 0 {
+		r.header.Width = cols
+		r.header.Height = rows
+		r.writeHeader()
+		r.mu.Unlock()
+		return
+	}
+	r.mu.Unlock()
+	r.write(EventResize, fmt.Sprintf("%dx%d", cols, rows))
+}
+
+// Duration 会话已录制时长
+func (r *Recorder) Duration() time.Duration {
+	return time.Since(r.start)
+}
+
+// Err 返回录制过程中的第一个写入错误，出错后不再继续写入
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) write(eventType string, data string) {
+	if data == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writeHeader()
+	elapsed := time.Since(r.start).Seconds()
+	line, _ := json.Marshal([]any{json.Number(fmt.Sprintf("%.6f", elapsed)), eventType, data})
+	r.writeLine(line)
+}
+
+func (r *Recorder) writeHeader() {
+	if r.headerWritten {
+		return
+	}
+	r.headerWritten = true
+	line, _ := json.Marshal(r.header)
+	r.writeLine(line)
+}
+
+func (r *Recorder) writeLine(line []byte) {
+	if r.err != nil {
+		return
+	}
+	_, r.err = r.w.Write(append(line, '\n'))
+}
+
+// completeUTF8Len 返回 data 中以完整UTF-8字符结尾的前缀长度
+func completeUTF8Len(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if utf8.FullRune(data[i:]) {
+				return len(data)
+			}
+			return i
+		}
+	}
+	return len(data)
+}
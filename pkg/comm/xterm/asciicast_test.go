@@ -0,0 +1,85 @@
+package xterm
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRecorder(&buf, "default/nginx")
+	r.Resize(120, 40)
+	r.Input([]byte("ls\r"))
+	r.Output([]byte("a.txt\r\n"))
+	r.Resize(100, 30)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %d: %s", len(lines), buf.String())
+	}
+
+	var header AsciicastHeader
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != 2 || header.Width != 120 || header.Height != 40 {
+		t.Errorf("unexpected header: %+v", header)
+	}
+
+	want := []struct {
+		eventType string
+		data      string
+	}{
+		{EventInput, "ls\r"},
+		{EventOutput, "a.txt\r\n"},
+		{EventResize, "100x30"},
+	}
+	for i, w := range want {
+		var event []any
+		if err := json.Unmarshal([]byte(lines[i+1]), &event); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := event[0].(float64); !ok {
+			t.Errorf("event %d: time should be a number: %v", i, event[0])
+		}
+		if event[1] != w.eventType || event[2] != w.data {
+			t.Errorf("event %d: got %v, want %s %q", i, event, w.eventType, w.data)
+		}
+	}
+}
+
+func TestRecorderDefaultSize(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRecorder(&buf, "")
+	r.Output([]byte("hello"))
+
+	var header AsciicastHeader
+	line, _, _ := strings.Cut(buf.String(), "\n")
+	if err := json.Unmarshal([]byte(line), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Width != 80 || header.Height != 24 {
+		t.Errorf("unexpected default size: %+v", header)
+	}
+}
+
+func TestRecorderSplitRune(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRecorder(&buf, "")
+	data := []byte("中文")
+	r.Output(data[:4])
+	r.Output(data[4:])
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %s", len(lines), buf.String())
+	}
+	var first, second []any
+	_ = json.Unmarshal([]byte(lines[1]), &first)
+	_ = json.Unmarshal([]byte(lines[2]), &second)
+	if first[2] != "中" || second[2] != "文" {
+		t.Errorf("unexpected events: %v %v", first, second)
+	}
+}
@@ -0,0 +1,121 @@
+package xterm
+
+import (
+	"sync"
+	"time"
+)
+
+// liveRecentSize 为新加入的观察者保留的最近输出大小，便于观察者看到当前屏幕上下文
+const liveRecentSize = 32 * 1024
+
+// LiveSessionInfo 进行中的终端会话信息
+type LiveSessionInfo struct {
+	ID            string    `json:"id"`
+	UserName      string    `json:"username"`
+	Cluster       string    `json:"cluster"`
+	Namespace     string    `json:"namespace"`
+	PodName       string    `json:"pod_name"`
+	ContainerName string    `json:"container_name,omitempty"`
+	NodeName      string    `json:"node_name,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	Observers     int       `json:"observers"` // 当前观察者数量
+}
+
+// LiveSession 进行中的终端会话
+// 终端输出通过 Broadcast 分发给所有只读观察者，Kill 用于强制结束会话
+type LiveSession struct {
+	LiveSessionInfo
+
+	mu        sync.Mutex
+	recent    []byte
+	observers map[chan []byte]struct{}
+	kill      func(reason string)
+	closed    bool
+}
+
+// NewLiveSession 创建进行中的终端会话，kill 为强制结束会话的方法
+func NewLiveSession(info LiveSessionInfo, kill func(reason string)) *LiveSession {
+	info.StartedAt = time.Now()
+	return &LiveSession{
+		LiveSessionInfo: info,
+		observers:       make(map[chan []byte]struct{}),
+		kill:            kill,
+	}
+}
+
+// Broadcast 分发终端输出，观察者接收过慢时丢弃该观察者的本段输出，不阻塞终端
+func (s *LiveSession) Broadcast(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.recent = append(s.recent, data...)
+	if len(s.recent) > liveRecentSize {
+		s.recent = append([]byte(nil), s.recent[len(s.recent)-liveRecentSize:]...)
+	}
+	for ch := range s.observers {
+		select {
+		case ch <- append([]byte(nil), data...):
+		default:
+		}
+	}
+}
+
+// Subscribe 以只读方式观察会话，返回的通道首先收到最近的输出，会话结束时通道关闭
+// 调用返回的取消函数停止观察
+func (s *LiveSession) Subscribe() (<-chan []byte, func()) {
+	ch := make(chan []byte, 256)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if len(s.recent) > 0 {
+		ch <- append([]byte(nil), s.recent...)
+	}
+	s.observers[ch] = struct{}{}
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.observers[ch]; ok {
+			delete(s.observers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Snapshot 返回会话信息及当前观察者数量
+func (s *LiveSession) Snapshot() LiveSessionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info := s.LiveSessionInfo
+	info.Observers = len(s.observers)
+	return info
+}
+
+// Kill 强制结束会话
+func (s *LiveSession) Kill(reason string) {
+	if s.kill != nil {
+		s.kill(reason)
+	}
+}
+
+// Close 会话结束，关闭所有观察者通道
+func (s *LiveSession) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for ch := range s.observers {
+		close(ch)
+	}
+	s.observers = nil
+	s.recent = nil
+}
@@ -0,0 +1,36 @@
+package xterm
+
+import "testing"
+
+func TestLiveSession(t *testing.T) {
+	var killed string
+	s := NewLiveSession(LiveSessionInfo{ID: "1"}, func(reason string) { killed = reason })
+	s.Broadcast([]byte("before"))
+
+	ch, cancel := s.Subscribe()
+	if got := string(<-ch); got != "before" {
+		t.Errorf("expected recent output, got %q", got)
+	}
+	s.Broadcast([]byte("after"))
+	if got := string(<-ch); got != "after" {
+		t.Errorf("expected live output, got %q", got)
+	}
+	if s.Snapshot().Observers != 1 {
+		t.Errorf("expected 1 observer")
+	}
+	cancel()
+	if s.Snapshot().Observers != 0 {
+		t.Errorf("expected 0 observer after cancel")
+	}
+
+	ch, _ = s.Subscribe()
+	<-ch
+	s.Kill("admin")
+	if killed != "admin" {
+		t.Errorf("kill func not called")
+	}
+	s.Close()
+	if _, ok := <-ch; ok {
+		t.Errorf("expected channel closed after session closed")
+	}
+}
@@ -0,0 +1,28 @@
+package constants
+
+const (
+	// ChatSessionID context中携带的AI对话会话ID，存在时对话历史持久化到该会话
+	ChatSessionID = "chat_session_id"
+)
+
+const (
+	// ToolConfirmer context中携带的MCP工具调用确认器，存在时变更类工具需用户确认后才执行
+	ToolConfirmer = "tool_confirmer"
+)
+
+const (
+	// AIFeature context中携带的AI功能，用于按功能选择模型
+	AIFeature = "ai_feature"
+	// AICluster context中携带的AI请求相关集群，用于用量统计
+	AICluster = "ai_cluster"
+)
+
+const (
+	// ChatRedactor context中携带的脱敏器，同一对话共用一个脱敏器，使占位符在多轮对话中保持一致
+	ChatRedactor = "chat_redactor"
+)
+
+const (
+	// ChatReferences context中携带的参考资料（如运维手册片段），作为系统消息发送给大模型，不写入对话历史
+	ChatReferences = "chat_references"
+)
@@ -33,4 +33,13 @@ const (
 	
 	// AIPromptTypeLog 日志分析类型
 	AIPromptTypeLog AIPromptType = "Log"
-)
\ No newline at end of file
+)
+
+// 模型路由中使用的其他AI功能
+const (
+	// AIFeatureGPTShell GPTShell 对话
+	AIFeatureGPTShell AIPromptType = "GPTShell"
+
+	// AIFeatureInspectionSummary 巡检结果AI汇总
+	AIFeatureInspectionSummary AIPromptType = "InspectionSummary"
+)
@@ -0,0 +1,13 @@
+package constants
+
+// 需要审批的高危操作类型
+const (
+	ApprovalActionNodeDrain     = "node_drain"     // 节点驱逐
+	ApprovalActionForceRemove   = "force_remove"   // 批量强制删除
+	ApprovalActionHelmUninstall = "helm_uninstall" // Helm卸载
+	ApprovalActionYamlDelete    = "yaml_delete"    // 通过YAML删除资源
+)
+
+// ActionApprove 审批高危操作的动作
+// 内置角色中仅集群管理员具备，自定义角色可在规则的 verbs 中授予
+const ActionApprove = "approve"
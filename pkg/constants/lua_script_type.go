@@ -14,3 +14,12 @@ const (
 	LuaEventStatusNormal LuaEventStatus = "正常" // 正常
 	LuaEventStatusFailed LuaEventStatus = "失败" // 失败
 )
+
+// LuaEventSeverity 检查事件严重级别
+type LuaEventSeverity string
+
+const (
+	LuaEventSeverityInfo     LuaEventSeverity = "info"     // 提示
+	LuaEventSeverityWarning  LuaEventSeverity = "warning"  // 警告
+	LuaEventSeverityCritical LuaEventSeverity = "critical" // 严重
+)
@@ -0,0 +1,6 @@
+package constants
+
+const (
+	// McpKeyPolicy context中携带的MCP密钥，存在时按密钥的工具名单、只读、集群及有效期限制MCP调用
+	McpKeyPolicy = "mcp_key_policy"
+)
@@ -4,7 +4,8 @@ package constants
 //
 // 平台管理员拥有所有权限
 // 普通用户需要赋予集群角色，
-// 集群角色三种，集群管理员、集群只读、集群Pod内执行命令
+// 内置集群角色三种，集群管理员、集群只读、集群Pod内执行命令
+// 除内置角色外，还可以引用平台管理员定义的自定义角色（CustomRole）
 const (
 	RolePlatformAdmin = "platform_admin" // 平台管理员
 	RoleGuest         = "guest"          // 普通用户，只能登录，约等于游客,无任何集群权限，也看不到集群列表
@@ -14,6 +15,22 @@ const (
 	RoleClusterPodExec  = "cluster_pod_exec" // 集群Pod内执行命令权限
 )
 
+// ActionViewSecret 查看Secret、敏感ConfigMap key、敏感环境变量明文的动作
+// 内置角色中仅集群管理员具备，自定义角色可在规则的 verbs 中授予
+const ActionViewSecret = "view_secret"
+
+// SkipRedact 上下文标记，读取结果不脱敏，仅用于读取后回写集群的内部读取
+const SkipRedact = "k8m_skip_redact"
+
+// IsBuiltinClusterRole 判断是否为内置集群角色
+func IsBuiltinClusterRole(role string) bool {
+	switch role {
+	case RoleClusterAdmin, RoleClusterReadonly, RoleClusterPodExec:
+		return true
+	}
+	return false
+}
+
 // ClusterAuthorizationType 集群授权类型
 type ClusterAuthorizationType string
 
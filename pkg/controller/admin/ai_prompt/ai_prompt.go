@@ -1,12 +1,17 @@
 package ai_prompt
 
 import (
+	"fmt"
+
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/gin-gonic/gin"
 	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
 	"github.com/weibaohui/k8m/pkg/constants"
 	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"github.com/weibaohui/kom/kom"
 	"gorm.io/gorm"
 	"k8s.io/klog/v2"
 )
@@ -28,6 +33,10 @@ func RegisterAdminAIPromptRoutes(admin *gin.RouterGroup) {
 
 	admin.POST("/ai_prompt/toggle/:id", ctrl.AIPromptToggle)                 // 添加启用/禁用路由
 	admin.POST("/ai_prompt/id/:id/enabled/:enabled", ctrl.AIPromptQuickSave) // 快捷保存启用状态
+	admin.GET("/ai_prompt/id/:id/versions", ctrl.AIPromptVersions)
+	admin.POST("/ai_prompt/id/:id/rollback/:version", ctrl.AIPromptRollback)
+	admin.GET("/ai_prompt/variables", ctrl.AIPromptVariables)
+	admin.POST("/ai_prompt/test", ctrl.AIPromptTest)
 }
 
 // @Summary 获取AI提示词列表
@@ -79,7 +88,7 @@ func (s *AdminAIPromptController) AIPromptSave(c *gin.Context) {
 	if m.ID == 0 {
 		m.IsEnabled = false
 	}
-	err = m.Save(params)
+	err = service.PromptService().SavePrompt(params, &m, c.Query("comment"))
 	if err != nil {
 		amis.WriteJsonError(c, err)
 		return
@@ -120,9 +129,10 @@ func (s *AdminAIPromptController) AIPromptQuickSave(c *gin.Context) {
 			return
 		}
 
-		// 禁用同类型的其他提示词
+		// 禁用同类型、同适用范围的其他提示词，不同集群或用户组的变体可同时启用
 		err = dao.DB().Model(&models.AIPrompt{}).
 			Where("prompt_type = ? AND id != ? AND is_enabled = ?", currentPrompt.PromptType, id, true).
+			Where("clusters = ? AND user_groups = ?", currentPrompt.Clusters, currentPrompt.UserGroups).
 			Update("is_enabled", false).Error
 		if err != nil {
 			klog.Errorf("禁用同类型提示词失败: %v", err)
@@ -270,11 +280,12 @@ func (s *AdminAIPromptController) AIPromptToggle(c *gin.Context) {
 		return
 	}
 
-	// 如果要启用此提示词，需要先禁用同类型的其他提示词
+	// 如果要启用此提示词，需要先禁用同类型、同适用范围的其他提示词
 	if !currentPrompt.IsEnabled {
-		// 禁用同类型的其他提示词
+		// 禁用同类型、同适用范围的其他提示词，不同集群或用户组的变体可同时启用
 		err = dao.DB().Model(&models.AIPrompt{}).
 			Where("prompt_type = ? AND id != ? AND is_enabled = ?", currentPrompt.PromptType, id, true).
+			Where("clusters = ? AND user_groups = ?", currentPrompt.Clusters, currentPrompt.UserGroups).
 			Update("is_enabled", false).Error
 		if err != nil {
 			klog.Errorf("禁用同类型提示词失败: %v", err)
@@ -294,3 +305,166 @@ func (s *AdminAIPromptController) AIPromptToggle(c *gin.Context) {
 
 	amis.WriteJsonOK(c)
 }
+
+// @Summary 获取AI提示词历史版本
+// @Security BearerAuth
+// @Param id path string true "提示词ID"
+// @Success 200 {object} []models.AIPromptVersion
+// @Router /admin/ai_prompt/id/{id}/versions [get]
+func (s *AdminAIPromptController) AIPromptVersions(c *gin.Context) {
+	id := utils.ToUInt(c.Param("id"))
+	list, err := service.PromptService().Versions(id)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonList(c, list)
+}
+
+// @Summary 回滚AI提示词到指定版本
+// @Description 回滚后的内容作为新版本保存，启用状态不变
+// @Security BearerAuth
+// @Param id path string true "提示词ID"
+// @Param version path int true "版本号"
+// @Success 200 {object} string
+// @Router /admin/ai_prompt/id/{id}/rollback/{version} [post]
+func (s *AdminAIPromptController) AIPromptRollback(c *gin.Context) {
+	params := dao.BuildParams(c)
+	id := utils.ToUInt(c.Param("id"))
+	version := utils.ToInt(c.Param("version"))
+	if err := service.PromptService().Rollback(params, id, version); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 获取AI提示词的标准变量
+// @Description 所有提示词均可使用的变量，由请求自动填充
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/ai_prompt/variables [get]
+func (s *AdminAIPromptController) AIPromptVariables(c *gin.Context) {
+	vars := []models.AIPromptVariable{
+		{Name: models.AIPromptVarCluster, Type: models.AIPromptVariableTypeString, Description: "当前集群"},
+		{Name: models.AIPromptVarKind, Type: models.AIPromptVariableTypeString, Description: "资源类型"},
+		{Name: models.AIPromptVarNamespace, Type: models.AIPromptVariableTypeString, Description: "命名空间"},
+		{Name: models.AIPromptVarLanguage, Type: models.AIPromptVariableTypeString, Description: "回答使用的语言，默认根据用户语言区域确定"},
+		{Name: models.AIPromptVarLocale, Type: models.AIPromptVariableTypeString, Description: "用户语言区域，取自浏览器 Accept-Language"},
+	}
+	amis.WriteJsonList(c, vars)
+}
+
+// promptTestRequest 提示词测试请求
+type promptTestRequest struct {
+	ID         uint                   `json:"id"`          // 已保存的提示词ID，为0时使用 Content 及 Variables
+	Version    int                    `json:"version"`     // 测试指定的历史版本，为0时使用当前内容
+	PromptType constants.AIPromptType `json:"prompt_type"` // 提示词类型，用于选择模型
+	Content    string                 `json:"content"`     // 提示词内容，不为空时覆盖已保存的内容，用于测试未保存的修改
+	Variables  string                 `json:"variables"`
+	Cluster    string                 `json:"cluster"` // 示例资源所在集群
+	Group      string                 `json:"group"`
+	ApiVersion string                 `json:"api_version"`
+	Kind       string                 `json:"kind"`
+	Namespace  string                 `json:"namespace"`
+	Name       string                 `json:"name"`
+	Values     map[string]any         `json:"values"`  // 其他变量值
+	DryRun     bool                   `json:"dry_run"` // 仅渲染，不请求大模型
+}
+
+// @Summary 测试AI提示词
+// @Description 使用示例资源渲染提示词并请求大模型，返回渲染后的提示词及模型回答。指定了集群、资源类型及名称时，资源的 describe 信息作为 DescribeInfo 变量
+// @Security BearerAuth
+// @Param body body promptTestRequest true "测试参数"
+// @Success 200 {object} string
+// @Router /admin/ai_prompt/test [post]
+func (s *AdminAIPromptController) AIPromptTest(c *gin.Context) {
+	params := dao.BuildParams(c)
+	var req promptTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	prompt := &models.AIPrompt{PromptType: req.PromptType}
+	if req.ID > 0 {
+		m := &models.AIPrompt{}
+		saved, err := m.GetOne(params, func(db *gorm.DB) *gorm.DB {
+			return db.Where("id = ?", req.ID)
+		})
+		if err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+		prompt = saved
+		if req.Version > 0 {
+			v := &models.AIPromptVersion{}
+			snapshot, err := v.GetOne(nil, func(db *gorm.DB) *gorm.DB {
+				return db.Where("prompt_id = ? AND version = ?", req.ID, req.Version)
+			})
+			if err != nil {
+				amis.WriteJsonError(c, err)
+				return
+			}
+			snapshot.ApplyTo(prompt)
+		}
+	}
+	if req.Content != "" {
+		prompt.Content = req.Content
+		prompt.Variables = req.Variables
+	}
+	if prompt.Content == "" {
+		amis.WriteJsonError(c, fmt.Errorf("提示词内容不能为空"))
+		return
+	}
+
+	ctx := service.AIService().WithFeature(amis.GetContextWithUser(c), prompt.PromptType)
+	ctx = service.AIUsageService().WithCluster(ctx, req.Cluster)
+
+	values := map[string]any{
+		"Group":                     req.Group,
+		"Version":                   req.ApiVersion,
+		"Name":                      req.Name,
+		models.AIPromptVarCluster:   req.Cluster,
+		models.AIPromptVarKind:      req.Kind,
+		models.AIPromptVarNamespace: req.Namespace,
+		models.AIPromptVarLocale:    c.GetHeader("Accept-Language"),
+		models.AIPromptVarLanguage:  models.PromptLanguage(c.GetHeader("Accept-Language")),
+	}
+	if req.Cluster != "" && req.Kind != "" && req.Name != "" {
+		var describe []byte
+		err := kom.Cluster(req.Cluster).WithContext(ctx).GVK(req.Group, req.ApiVersion, req.Kind).
+			Name(req.Name).Namespace(req.Namespace).Describe(&describe).Error
+		if err != nil {
+			amis.WriteJsonError(c, fmt.Errorf("获取示例资源失败: %w", err))
+			return
+		}
+		values["DescribeInfo"] = string(describe)
+	}
+	for k, v := range req.Values {
+		values[k] = v
+	}
+
+	rendered, err := prompt.Render(values)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if req.DryRun {
+		amis.WriteJsonData(c, gin.H{"prompt": rendered})
+		return
+	}
+	if !service.AIService().IsEnabled() {
+		amis.WriteJsonError(c, fmt.Errorf("请先配置开启ChatGPT功能"))
+		return
+	}
+	answer, err := service.ChatService().ChatWithCtxNoHistory(ctx, rendered)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonData(c, gin.H{
+		"prompt": rendered,
+		"answer": answer,
+	})
+}
@@ -0,0 +1,148 @@
+package approval
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
+)
+
+type AdminApprovalPolicyController struct {
+}
+
+// RegisterAdminApprovalRoutes 注册审批策略管理及审批记录查询路由
+func RegisterAdminApprovalRoutes(admin *gin.RouterGroup) {
+	ctrl := &AdminApprovalPolicyController{}
+	admin.GET("/approval_policy/list", ctrl.List)
+	admin.POST("/approval_policy/save", ctrl.Save)
+	admin.POST("/approval_policy/delete/:ids", ctrl.Delete)
+	admin.GET("/approval_policy/option_list", ctrl.ActionOptions)
+	admin.GET("/approval/list", ctrl.RequestList)
+}
+
+var approvalActions = []map[string]string{
+	{"label": "节点驱逐", "value": constants.ApprovalActionNodeDrain},
+	{"label": "批量强制删除", "value": constants.ApprovalActionForceRemove},
+	{"label": "Helm卸载", "value": constants.ApprovalActionHelmUninstall},
+	{"label": "YAML删除", "value": constants.ApprovalActionYamlDelete},
+}
+
+// @Summary 获取审批策略列表
+// @Security BearerAuth
+// @Success 200 {object} []models.ApprovalPolicy
+// @Router /admin/approval_policy/list [get]
+func (a *AdminApprovalPolicyController) List(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := &models.ApprovalPolicy{}
+
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 保存审批策略
+// @Description 新增或更新审批策略，cluster为空或*表示全部集群，namespaces为空表示全部命名空间
+// @Security BearerAuth
+// @Accept json
+// @Param data body models.ApprovalPolicy true "审批策略"
+// @Success 200 {object} map[string]any
+// @Router /admin/approval_policy/save [post]
+func (a *AdminApprovalPolicyController) Save(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := models.ApprovalPolicy{}
+	err := c.ShouldBindJSON(&m)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if strings.TrimSpace(m.Actions) == "" {
+		amis.WriteJsonError(c, fmt.Errorf("请选择需要审批的操作"))
+		return
+	}
+	for _, action := range strings.Split(m.Actions, ",") {
+		if !isApprovalAction(strings.TrimSpace(action)) {
+			amis.WriteJsonError(c, fmt.Errorf("不支持的审批操作类型[%s]", action))
+			return
+		}
+	}
+	if m.ApproverRole != "" && !constants.IsBuiltinClusterRole(m.ApproverRole) {
+		if _, err = service.CustomRoleService().GetByName(m.ApproverRole); err != nil {
+			amis.WriteJsonError(c, fmt.Errorf("审批角色[%s]不存在", m.ApproverRole))
+			return
+		}
+	}
+
+	err = m.Save(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.ApprovalService().ClearCache()
+	amis.WriteJsonData(c, gin.H{
+		"id": m.ID,
+	})
+}
+
+// @Summary 删除审批策略
+// @Description 删除策略后，已提交的审批申请不受影响
+// @Security BearerAuth
+// @Param ids path string true "策略ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/approval_policy/delete/{ids} [post]
+func (a *AdminApprovalPolicyController) Delete(c *gin.Context) {
+	ids := c.Param("ids")
+	params := dao.BuildParams(c)
+	params.UserName = ""
+
+	m := &models.ApprovalPolicy{}
+	err := m.Delete(params, ids)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.ApprovalService().ClearCache()
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 审批操作类型选项列表
+// @Security BearerAuth
+// @Success 200 {object} []map[string]string
+// @Router /admin/approval_policy/option_list [get]
+func (a *AdminApprovalPolicyController) ActionOptions(c *gin.Context) {
+	amis.WriteJsonData(c, gin.H{
+		"options": approvalActions,
+	})
+}
+
+// @Summary 获取全部审批申请记录
+// @Security BearerAuth
+// @Success 200 {object} []models.ApprovalRequest
+// @Router /admin/approval/list [get]
+func (a *AdminApprovalPolicyController) RequestList(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := &models.ApprovalRequest{}
+
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+func isApprovalAction(action string) bool {
+	for _, item := range approvalActions {
+		if item["value"] == action {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package approval
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+)
+
+type Controller struct{}
+
+// RegisterApprovalRoutes 注册审批申请相关路由
+func RegisterApprovalRoutes(mgm *gin.RouterGroup) {
+	ctrl := &Controller{}
+	mgm.GET("/approval/my/list", ctrl.MyList)
+	mgm.GET("/approval/pending/list", ctrl.PendingList)
+	mgm.POST("/approval/approve/:id", ctrl.Approve)
+	mgm.POST("/approval/reject/:id", ctrl.Reject)
+}
+
+type decisionRequest struct {
+	Comment string `json:"comment"`
+}
+
+// @Summary 获取我提交的审批申请
+// @Security BearerAuth
+// @Success 200 {object} []models.ApprovalRequest
+// @Router /mgm/approval/my/list [get]
+func (ac *Controller) MyList(c *gin.Context) {
+	params := dao.BuildParams(c)
+	username := amis.GetLoginUser(c)
+	m := &models.ApprovalRequest{}
+	items, total, err := m.List(params, func(db *gorm.DB) *gorm.DB {
+		return db.Where("requested_by = ?", username)
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 获取待审批的申请
+// @Description 平台管理员可见全部，其他用户仅可见有权限集群中的申请
+// @Security BearerAuth
+// @Success 200 {object} []models.ApprovalRequest
+// @Router /mgm/approval/pending/list [get]
+func (ac *Controller) PendingList(c *gin.Context) {
+	params := dao.BuildParams(c)
+	username := amis.GetLoginUser(c)
+	queryFunc := func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ?", models.ApprovalStatusPending)
+	}
+	if !service.UserService().IsUserPlatformAdmin(username) {
+		clusters, err := service.UserService().GetClusterNames(username)
+		if err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+		queryFunc = func(db *gorm.DB) *gorm.DB {
+			return db.Where("status = ? and cluster in ? and requested_by <> ?", models.ApprovalStatusPending, clusters, username)
+		}
+	}
+	m := &models.ApprovalRequest{}
+	items, total, err := m.List(params, queryFunc)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 审批通过
+// @Description 审批通过后在后台以申请人身份执行操作，执行结果写回申请记录
+// @Security BearerAuth
+// @Param id path int true "审批申请ID"
+// @Param comment body string false "审批意见"
+// @Success 200 {object} string
+// @Router /mgm/approval/approve/{id} [post]
+func (ac *Controller) Approve(c *gin.Context) {
+	req, comment, err := ac.prepare(c)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if err = service.ApprovalService().Approve(req, amis.GetLoginUser(c), comment); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOKMsg(c, "审批通过，正在后台执行，执行结果可在申请记录中查看")
+}
+
+// @Summary 驳回审批申请
+// @Security BearerAuth
+// @Param id path int true "审批申请ID"
+// @Param comment body string false "驳回意见"
+// @Success 200 {object} string
+// @Router /mgm/approval/reject/{id} [post]
+func (ac *Controller) Reject(c *gin.Context) {
+	req, comment, err := ac.prepare(c)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if err = service.ApprovalService().Reject(req, amis.GetLoginUser(c), comment); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
+
+// prepare 读取待审批申请并校验当前用户的审批权限
+func (ac *Controller) prepare(c *gin.Context) (*models.ApprovalRequest, string, error) {
+	var body decisionRequest
+	_ = c.ShouldBindJSON(&body)
+
+	id := utils.ToUInt(c.Param("id"))
+	req, err := service.ApprovalService().GetPending(id)
+	if err != nil {
+		return nil, "", err
+	}
+	if err = comm.CheckApprovePermission(amis.GetContextWithUser(c), req); err != nil {
+		return nil, "", err
+	}
+	return req, body.Comment, nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/ai"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
 	"github.com/weibaohui/k8m/pkg/models"
@@ -69,7 +70,7 @@ func (m *AIController) TestConnection(c *gin.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
-	client, err := service.AIService().TestClient(entity.ApiURL, entity.ApiKey, entity.ApiModel)
+	client, err := service.AIService().TestClient(&entity)
 	if err != nil {
 		amis.WriteJsonError(c, err)
 		return
@@ -100,12 +101,12 @@ func (m *AIController) Save(c *gin.Context) {
 		return
 	}
 
-	// 添加业务逻辑验证
-	if config.ApiURL == "" {
+	// 添加业务逻辑验证，Anthropic、Ollama 未填写地址时使用官方默认地址
+	if config.ApiURL == "" && config.Provider != "anthropic" && config.Provider != "ollama" {
 		amis.WriteJsonError(c, fmt.Errorf("API URL不能为空"))
 		return
 	}
-	if config.ApiKey == "" {
+	if config.ApiKey == "" && ai.NeedPassword(config.Provider) {
 		amis.WriteJsonError(c, fmt.Errorf("API Key不能为空"))
 		return
 	}
@@ -119,6 +120,7 @@ func (m *AIController) Save(c *gin.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	service.AIService().ResetRouteClients()
 
 	amis.WriteJsonOK(c)
 }
@@ -154,5 +156,6 @@ func (m *AIController) Delete(c *gin.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	service.AIService().ResetRouteClients()
 	amis.WriteJsonOK(c)
 }
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
+)
+
+type AIRouteController struct {
+}
+
+// RegisterAIModelRouteRoutes 注册路由
+func RegisterAIModelRouteRoutes(admin *gin.RouterGroup) {
+	ctrl := &AIRouteController{}
+	admin.GET("/ai/route/list", ctrl.List)
+	admin.GET("/ai/route/features", ctrl.Features)
+	admin.POST("/ai/route/save", ctrl.Save)
+	admin.POST("/ai/route/delete/:ids", ctrl.Delete)
+}
+
+// routeFeatures 可配置模型路由的AI功能
+var routeFeatures = []constants.AIPromptType{
+	constants.AIFeatureGPTShell,
+	constants.AIFeatureInspectionSummary,
+	constants.AIPromptTypeLog,
+	constants.AIPromptTypeEvent,
+	constants.AIPromptTypeDescribe,
+	constants.AIPromptTypeCron,
+	constants.AIPromptTypeExample,
+	constants.AIPromptTypeFieldExample,
+	constants.AIPromptTypeResource,
+	constants.AIPromptTypeK8sGPTResource,
+	constants.AIPromptTypeAnySelection,
+	constants.AIPromptTypeAnyQuestion,
+}
+
+// @Summary 获取可配置模型路由的AI功能
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/ai/route/features [get]
+func (r *AIRouteController) Features(c *gin.Context) {
+	var options []map[string]string
+	for _, f := range routeFeatures {
+		options = append(options, map[string]string{
+			"label": string(f),
+			"value": string(f),
+		})
+	}
+	amis.WriteJsonData(c, gin.H{
+		"options": options,
+	})
+}
+
+// @Summary 获取AI模型路由列表
+// @Security BearerAuth
+// @Success 200 {object} []models.AIModelRoute
+// @Router /admin/ai/route/list [get]
+func (r *AIRouteController) List(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := &models.AIModelRoute{}
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 创建或更新AI模型路由
+// @Description 为AI功能指定主模型及按顺序尝试的备用模型，主模型请求失败或超时后使用备用模型
+// @Security BearerAuth
+// @Param body body models.AIModelRoute true "模型路由"
+// @Success 200 {object} string
+// @Router /admin/ai/route/save [post]
+func (r *AIRouteController) Save(c *gin.Context) {
+	params := dao.BuildParams(c)
+	var m models.AIModelRoute
+	if err := c.ShouldBindJSON(&m); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if m.Feature == "" {
+		amis.WriteJsonError(c, fmt.Errorf("AI功能不能为空"))
+		return
+	}
+	if m.Timeout < 0 {
+		amis.WriteJsonError(c, fmt.Errorf("超时时间不能小于0"))
+		return
+	}
+	if m.ModelID == 0 {
+		amis.WriteJsonError(c, fmt.Errorf("主模型不能为空"))
+		return
+	}
+
+	// 校验主模型及备用模型均存在，备用模型中去掉主模型及重复项
+	ids := []uint{m.ModelID}
+	for _, id := range strings.Split(m.FallbackModelIDs, ",") {
+		if v := utils.ToUInt(strings.TrimSpace(id)); v > 0 && !slices.Contains(ids, v) {
+			ids = append(ids, v)
+		}
+	}
+	for _, id := range ids {
+		mc := &models.AIModelConfig{ID: id}
+		if _, err := mc.GetOne(nil); err != nil {
+			amis.WriteJsonError(c, fmt.Errorf("模型[%d]不存在", id))
+			return
+		}
+	}
+	var fallbacks []string
+	for _, id := range ids[1:] {
+		fallbacks = append(fallbacks, fmt.Sprintf("%d", id))
+	}
+	m.FallbackModelIDs = strings.Join(fallbacks, ",")
+
+	if err := m.Save(params); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.AIService().ResetRouteClients()
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 删除AI模型路由
+// @Security BearerAuth
+// @Param ids path string true "路由ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/ai/route/delete/{ids} [post]
+func (r *AIRouteController) Delete(c *gin.Context) {
+	ids := c.Param("ids")
+	params := dao.BuildParams(c)
+	params.UserName = ""
+	m := &models.AIModelRoute{}
+	if err := m.Delete(params, ids); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.AIService().ResetRouteClients()
+	amis.WriteJsonOK(c)
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+)
+
+type AIUsageController struct {
+}
+
+// RegisterAIUsageRoutes 注册路由
+func RegisterAIUsageRoutes(admin *gin.RouterGroup) {
+	ctrl := &AIUsageController{}
+	admin.GET("/ai/usage/list", ctrl.List)
+	admin.GET("/ai/usage/report", ctrl.Report)
+	admin.GET("/ai/quota/list", ctrl.QuotaList)
+	admin.POST("/ai/quota/save", ctrl.QuotaSave)
+	admin.POST("/ai/quota/delete/:ids", ctrl.QuotaDelete)
+}
+
+// @Summary 获取AI用量明细
+// @Security BearerAuth
+// @Param created_at_range query string false "时间范围，格式：开始时间,结束时间"
+// @Success 200 {object} []models.AITokenUsage
+// @Router /admin/ai/usage/list [get]
+func (u *AIUsageController) List(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := &models.AITokenUsage{}
+	var queryFuncs []func(*gorm.DB) *gorm.DB
+	if queryFunc, ok := dao.BuildCreatedAtQuery(params); ok {
+		queryFuncs = append(queryFuncs, queryFunc)
+	}
+	items, total, err := m.List(params, queryFuncs...)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 获取AI用量汇总
+// @Description 按用户、模型、AI功能、集群或厂商汇总请求数、token 数及费用
+// @Security BearerAuth
+// @Param group_by query string false "分组字段：user_name（默认）、model、feature、cluster、provider"
+// @Param created_at_range query string false "时间范围，格式：开始时间,结束时间"
+// @Success 200 {object} []service.AIUsageReportItem
+// @Router /admin/ai/usage/report [get]
+func (u *AIUsageController) Report(c *gin.Context) {
+	params := dao.BuildParams(c)
+	var queryFuncs []func(*gorm.DB) *gorm.DB
+	if queryFunc, ok := dao.BuildCreatedAtQuery(params); ok {
+		queryFuncs = append(queryFuncs, queryFunc)
+	}
+	items, err := service.AIUsageService().Report(c.DefaultQuery("group_by", "user_name"), queryFuncs...)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonList(c, items)
+}
+
+// @Summary 获取AI token 配额列表
+// @Security BearerAuth
+// @Success 200 {object} []models.AITokenQuota
+// @Router /admin/ai/quota/list [get]
+func (u *AIUsageController) QuotaList(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := &models.AITokenQuota{}
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 创建或更新AI token 配额
+// @Description 按用户或用户组设置每日或每月 token 上限，用户组配额对组内每个用户单独计算，同一周期内用户配额优先
+// @Security BearerAuth
+// @Param body body models.AITokenQuota true "配额"
+// @Success 200 {object} string
+// @Router /admin/ai/quota/save [post]
+func (u *AIUsageController) QuotaSave(c *gin.Context) {
+	params := dao.BuildParams(c)
+	var m models.AITokenQuota
+	if err := c.ShouldBindJSON(&m); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if m.Scope != models.AITokenQuotaScopeUser && m.Scope != models.AITokenQuotaScopeGroup {
+		amis.WriteJsonError(c, fmt.Errorf("配额范围应为 user 或 group"))
+		return
+	}
+	if m.Target == "" {
+		amis.WriteJsonError(c, fmt.Errorf("用户或用户组不能为空"))
+		return
+	}
+	if m.Period != models.AITokenQuotaPeriodDaily && m.Period != models.AITokenQuotaPeriodMonthly {
+		amis.WriteJsonError(c, fmt.Errorf("配额周期应为 daily 或 monthly"))
+		return
+	}
+	if m.TokenLimit <= 0 {
+		amis.WriteJsonError(c, fmt.Errorf("token 上限应大于0"))
+		return
+	}
+	if err := m.Save(params); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 删除AI token 配额
+// @Security BearerAuth
+// @Param ids path string true "配额ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/ai/quota/delete/{ids} [post]
+func (u *AIUsageController) QuotaDelete(c *gin.Context) {
+	ids := c.Param("ids")
+	params := dao.BuildParams(c)
+	params.UserName = ""
+	m := &models.AITokenQuota{}
+	if err := m.Delete(params, ids); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
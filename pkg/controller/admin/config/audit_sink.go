@@ -0,0 +1,128 @@
+package config
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
+)
+
+type AuditSinkController struct {
+}
+
+// RegisterAuditSinkRoutes 注册审计日志外发配置路由
+func RegisterAuditSinkRoutes(admin *gin.RouterGroup) {
+	ctrl := &AuditSinkController{}
+	admin.GET("/config/audit_sink/list", ctrl.List)
+	admin.POST("/config/audit_sink/save", ctrl.Save)
+	admin.POST("/config/audit_sink/delete/:ids", ctrl.Delete)
+	admin.POST("/config/audit_sink/save/id/:id/status/:enabled", ctrl.QuickSave)
+	admin.POST("/config/audit_sink/test", ctrl.Test)
+}
+
+// @Summary 获取审计日志外发配置列表
+// @Security BearerAuth
+// @Success 200 {object} []models.AuditSink
+// @Router /admin/config/audit_sink/list [get]
+func (a *AuditSinkController) List(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := &models.AuditSink{}
+
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 保存审计日志外发配置
+// @Description 支持 file（JSON Lines文件）、syslog（RFC5424，tcp/udp）、http（批量推送）三种类型，保存后立即生效
+// @Security BearerAuth
+// @Accept json
+// @Param data body models.AuditSink true "外发配置"
+// @Success 200 {object} map[string]any
+// @Router /admin/config/audit_sink/save [post]
+func (a *AuditSinkController) Save(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := models.AuditSink{}
+	err := c.ShouldBindJSON(&m)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if _, err = service.AuditService().BuildSink(&m); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	err = m.Save(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonErrorOrOK(c, service.AuditService().Reload())
+}
+
+// @Summary 删除审计日志外发配置
+// @Security BearerAuth
+// @Param ids path string true "配置ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/config/audit_sink/delete/{ids} [post]
+func (a *AuditSinkController) Delete(c *gin.Context) {
+	ids := c.Param("ids")
+	params := dao.BuildParams(c)
+	params.UserName = ""
+	m := &models.AuditSink{}
+
+	err := m.Delete(params, ids)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonErrorOrOK(c, service.AuditService().Reload())
+}
+
+// @Summary 启用或停用审计日志外发配置
+// @Security BearerAuth
+// @Param id path int true "配置ID"
+// @Param enabled path bool true "是否启用"
+// @Success 200 {object} string
+// @Router /admin/config/audit_sink/save/id/{id}/status/{enabled} [post]
+func (a *AuditSinkController) QuickSave(c *gin.Context) {
+	id := c.Param("id")
+	enabled := c.Param("enabled")
+
+	var entity models.AuditSink
+	entity.ID = utils.ToUInt(id)
+	entity.Enabled = enabled == "true"
+	err := dao.DB().Model(&entity).Select("enabled").Updates(entity).Error
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonErrorOrOK(c, service.AuditService().Reload())
+}
+
+// @Summary 测试审计日志外发配置
+// @Description 按提交的配置同步发送一条测试日志，不保存配置
+// @Security BearerAuth
+// @Accept json
+// @Param data body models.AuditSink true "外发配置"
+// @Success 200 {object} string
+// @Router /admin/config/audit_sink/test [post]
+func (a *AuditSinkController) Test(c *gin.Context) {
+	m := models.AuditSink{}
+	err := c.ShouldBindJSON(&m)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	err = service.AuditService().Test(&m, amis.GetLoginUser(c))
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOKMsg(c, "测试日志已发送")
+}
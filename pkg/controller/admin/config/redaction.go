@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+)
+
+type RedactionController struct {
+}
+
+// RegisterRedactionRoutes 注册路由
+func RegisterRedactionRoutes(admin *gin.RouterGroup) {
+	ctrl := &RedactionController{}
+	admin.GET("/redaction/rule/list", ctrl.List)
+	admin.POST("/redaction/rule/save", ctrl.Save)
+	admin.POST("/redaction/rule/delete/:ids", ctrl.Delete)
+	admin.POST("/redaction/preview", ctrl.Preview)
+	admin.GET("/redaction/log/list", ctrl.LogList)
+}
+
+// @Summary 获取脱敏规则列表
+// @Security BearerAuth
+// @Success 200 {object} []models.RedactionRule
+// @Router /admin/redaction/rule/list [get]
+func (r *RedactionController) List(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := &models.RedactionRule{}
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 创建或更新脱敏规则
+// @Description 规则类型为 regex、keyword 或 builtin，builtin 的匹配内容为内置规则名称（secret、token、ip、email）
+// @Security BearerAuth
+// @Param body body models.RedactionRule true "脱敏规则"
+// @Success 200 {object} string
+// @Router /admin/redaction/rule/save [post]
+func (r *RedactionController) Save(c *gin.Context) {
+	params := dao.BuildParams(c)
+	var m models.RedactionRule
+	if err := c.ShouldBindJSON(&m); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if m.Name == "" {
+		amis.WriteJsonError(c, fmt.Errorf("规则名称不能为空"))
+		return
+	}
+	switch m.RuleType {
+	case models.RedactionRuleTypeRegex, models.RedactionRuleTypeKeyword, models.RedactionRuleTypeBuiltin:
+	default:
+		amis.WriteJsonError(c, fmt.Errorf("规则类型应为 regex、keyword 或 builtin"))
+		return
+	}
+	if _, err := service.RedactionService().Compile(&m); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if err := m.Save(params); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.RedactionService().Reset()
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 删除脱敏规则
+// @Security BearerAuth
+// @Param ids path string true "规则ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/redaction/rule/delete/{ids} [post]
+func (r *RedactionController) Delete(c *gin.Context) {
+	ids := c.Param("ids")
+	params := dao.BuildParams(c)
+	params.UserName = ""
+	m := &models.RedactionRule{}
+	if err := m.Delete(params, ids); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.RedactionService().Reset()
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 预览脱敏效果
+// @Description 使用当前启用的规则脱敏文本，返回脱敏后的内容及各类别的脱敏次数
+// @Security BearerAuth
+// @Param body body object true "待脱敏文本，格式：{\"text\":\"...\"}"
+// @Success 200 {object} object
+// @Router /admin/redaction/preview [post]
+func (r *RedactionController) Preview(c *gin.Context) {
+	var req struct {
+		Text string `json:"text"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	text, stats := service.RedactionService().Preview(req.Text)
+	amis.WriteJsonData(c, gin.H{
+		"text":  text,
+		"stats": stats,
+	})
+}
+
+// @Summary 获取脱敏审计记录
+// @Security BearerAuth
+// @Param created_at_range query string false "时间范围，格式：开始时间,结束时间"
+// @Success 200 {object} []models.RedactionLog
+// @Router /admin/redaction/log/list [get]
+func (r *RedactionController) LogList(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := &models.RedactionLog{}
+	var queryFuncs []func(*gorm.DB) *gorm.DB
+	if queryFunc, ok := dao.BuildCreatedAtQuery(params); ok {
+		queryFuncs = append(queryFuncs, queryFunc)
+	}
+	items, total, err := m.List(params, queryFuncs...)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/retention"
+	"gorm.io/gorm"
+)
+
+type RetentionController struct {
+}
+
+// RegisterRetentionRoutes 注册日志保留策略路由
+func RegisterRetentionRoutes(admin *gin.RouterGroup) {
+	ctrl := &RetentionController{}
+	admin.GET("/config/retention/list", ctrl.List)
+	admin.POST("/config/retention/save", ctrl.Save)
+	admin.POST("/config/retention/delete/:ids", ctrl.Delete)
+	admin.POST("/config/retention/run/:id", ctrl.Run)
+	admin.GET("/config/retention/option_list", ctrl.TableOptions)
+}
+
+// @Summary 获取日志保留策略列表
+// @Security BearerAuth
+// @Success 200 {object} []models.RetentionPolicy
+// @Router /admin/config/retention/list [get]
+func (r *RetentionController) List(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := &models.RetentionPolicy{}
+
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 保存日志保留策略
+// @Description 每个日志表一条策略，days、max_rows 至少设置一项，由Leader节点每小时执行一次清理
+// @Security BearerAuth
+// @Accept json
+// @Param data body models.RetentionPolicy true "保留策略"
+// @Success 200 {object} string
+// @Router /admin/config/retention/save [post]
+func (r *RetentionController) Save(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := models.RetentionPolicy{}
+	err := c.ShouldBindJSON(&m)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if !retention.IsSupported(m.LogTable) {
+		amis.WriteJsonError(c, fmt.Errorf("不支持的日志表[%s]", m.LogTable))
+		return
+	}
+	if m.Days < 0 || m.MaxRows < 0 {
+		amis.WriteJsonError(c, fmt.Errorf("保留天数、保留条数不能为负数"))
+		return
+	}
+	if m.Days == 0 && m.MaxRows == 0 {
+		amis.WriteJsonError(c, fmt.Errorf("请设置保留天数或保留条数"))
+		return
+	}
+	err = m.Save(params, func(db *gorm.DB) *gorm.DB {
+		return db.Select([]string{"log_table", "days", "max_rows", "archive", "archive_dir", "enabled", "created_by", "created_at", "updated_at"})
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 删除日志保留策略
+// @Security BearerAuth
+// @Param ids path string true "策略ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/config/retention/delete/{ids} [post]
+func (r *RetentionController) Delete(c *gin.Context) {
+	ids := c.Param("ids")
+	params := dao.BuildParams(c)
+	params.UserName = ""
+	m := &models.RetentionPolicy{}
+
+	err := m.Delete(params, ids)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 立即执行日志保留策略
+// @Description 与定时清理一样由Leader节点在后台执行一次清理，执行结果记录在策略的 last_result 中
+// @Security BearerAuth
+// @Param id path int true "策略ID"
+// @Success 200 {object} string
+// @Router /admin/config/retention/run/{id} [post]
+func (r *RetentionController) Run(c *gin.Context) {
+	id := c.Param("id")
+	params := dao.BuildParams(c)
+	m := &models.RetentionPolicy{}
+	p, err := m.GetOne(params, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", id)
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if err = retention.RequestRun(p.ID); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOKMsg(c, "已提交清理请求，由Leader节点执行，请稍后刷新查看执行结果")
+}
+
+// @Summary 获取可配置保留策略的日志表
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/config/retention/option_list [get]
+func (r *RetentionController) TableOptions(c *gin.Context) {
+	amis.WriteJsonData(c, gin.H{
+		"options": retention.Options(),
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/constants"
 	"github.com/weibaohui/k8m/pkg/lua"
 	"github.com/weibaohui/k8m/pkg/models"
 	"gorm.io/gorm"
@@ -25,6 +26,10 @@ func RegisterAdminScheduleRoutes(admin *gin.RouterGroup) {
 	admin.GET("/inspection/schedule/record/id/:id/event/list", ctrl.EventList)
 	admin.POST("/inspection/schedule/record/id/:id/summary", ctrl.SummaryByRecordID)
 	admin.GET("/inspection/schedule/record/id/:id/output/list", ctrl.OutputList)
+	admin.GET("/inspection/schedule/record/id/:id/diff", ctrl.RecordDiff)
+	admin.GET("/inspection/schedule/record/id/:id/diff/base/:base_id", ctrl.RecordDiff)
+	admin.GET("/inspection/schedule/id/:id/cluster/:cluster/trend", ctrl.RuleTrend)
+	admin.GET("/inspection/schedule/id/:id/cluster/:cluster/trend/runs/:runs", ctrl.RuleTrend)
 	admin.POST("/inspection/schedule/save", ctrl.Save)
 	admin.POST("/inspection/schedule/delete/:ids", ctrl.Delete)
 	admin.POST("/inspection/schedule/save/id/:id/status/:enabled", ctrl.QuickSave)
@@ -33,6 +38,7 @@ func RegisterAdminScheduleRoutes(admin *gin.RouterGroup) {
 	admin.POST("/inspection/schedule/id/:id/summary", ctrl.SummaryBySchedule)
 	admin.POST("/inspection/schedule/id/:id/summary/cluster/:cluster/start_time/:start_time/end_time/:end_time", ctrl.SummaryBySchedule)
 	admin.GET("/inspection/event/status/option_list", ctrl.EventStatusOptionList)
+	admin.GET("/inspection/event/severity/option_list", ctrl.EventSeverityOptionList)
 }
 
 // @Summary 获取巡检计划列表
@@ -127,6 +133,20 @@ func (s *AdminScheduleController) EventStatusOptionList(c *gin.Context) {
 	})
 }
 
+// @Summary 获取巡检事件严重级别选项列表
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/inspection/event/severity/option_list [get]
+func (s *AdminScheduleController) EventSeverityOptionList(c *gin.Context) {
+	amis.WriteJsonData(c, gin.H{
+		"options": []map[string]string{
+			{"label": "严重", "value": string(constants.LuaEventSeverityCritical)},
+			{"label": "警告", "value": string(constants.LuaEventSeverityWarning)},
+			{"label": "提示", "value": string(constants.LuaEventSeverityInfo)},
+		},
+	})
+}
+
 // @Summary 保存巡检计划
 // @Security BearerAuth
 // @Success 200 {object} string
@@ -148,6 +168,10 @@ func (s *AdminScheduleController) Save(c *gin.Context) {
 		amis.WriteJsonError(c, fmt.Errorf("cron表达式错误: %w", err))
 		return
 	}
+	if err = m.ValidateSeverity(); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
 
 	// 验证AI总结配置
 	if m.AIEnabled {
@@ -300,10 +324,7 @@ func (s *AdminScheduleController) Start(c *gin.Context) {
 	go func() {
 		// 立马执行一次
 		sb := lua.NewScheduleBackground()
-		clusters := strings.Split(one.Clusters, ",")
-		for _, cluster := range clusters {
-			_, _ = sb.RunByCluster(context.Background(), &one.ID, cluster, lua.TriggerTypeManual)
-		}
+		sb.RunByClusters(context.Background(), &one.ID, one.Clusters, lua.TriggerTypeManual)
 	}()
 
 	amis.WriteJsonOKMsg(c, "巡检开始，请稍后刷新查看结果")
@@ -182,6 +182,7 @@ func (s *AdminScheduleController) SummaryBySchedule(c *gin.Context) {
 	if len(records) > 0 {
 		latestRecord := records[0]
 		kindStatus := map[string]map[string]int{} // kind -> status -> count
+		severityCounts := map[string]int{}        // severity -> fail count
 		for _, e := range events {
 			if e.RecordID == latestRecord.ID {
 				if _, ok := kindStatus[e.Kind]; !ok {
@@ -191,6 +192,7 @@ func (s *AdminScheduleController) SummaryBySchedule(c *gin.Context) {
 					kindStatus[e.Kind]["pass"]++
 				} else {
 					kindStatus[e.Kind]["fail"]++
+					severityCounts[string(models.NormalizeSeverity(e.Severity, constants.LuaEventSeverityWarning))]++
 				}
 			}
 		}
@@ -207,6 +209,8 @@ func (s *AdminScheduleController) SummaryBySchedule(c *gin.Context) {
 			"schedule_id": latestRecord.ScheduleID,
 			"run_time":    latestRecord.CreatedAt,
 			"kinds":       kindArr,
+			"score":       latestRecord.Score,
+			"severities":  severityCounts,
 		}
 		result["latest_run"] = latestRun
 	}
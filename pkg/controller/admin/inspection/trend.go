@@ -0,0 +1,54 @@
+package inspection
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/lua"
+)
+
+// @Summary 比对巡检记录的失败事件
+// @Description 与指定的基准记录比对，未指定时与同一计划、同一集群的上一次巡检比对，返回新增、已恢复及持续存在的失败事件
+// @Security BearerAuth
+// @Param id path int true "巡检记录ID"
+// @Param base_id path int false "基准巡检记录ID"
+// @Success 200 {object} string
+// @Router /admin/inspection/schedule/record/id/{id}/diff [get]
+// @Router /admin/inspection/schedule/record/id/{id}/diff/base/{base_id} [get]
+func (s *AdminScheduleController) RecordDiff(c *gin.Context) {
+	recordID := utils.ToUInt(c.Param("id"))
+	baseID := utils.ToUInt(c.Param("base_id"))
+	sb := lua.ScheduleBackground{}
+	diff, err := sb.DiffRecords(baseID, recordID)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonData(c, diff)
+}
+
+// @Summary 巡检规则趋势
+// @Description 统计巡检计划在指定集群最近多次巡检中每条规则的失败数
+// @Security BearerAuth
+// @Param id path int true "巡检计划ID"
+// @Param cluster path string true "集群名称（URL安全的Base64编码）"
+// @Param runs path int false "统计最近的巡检次数，默认10"
+// @Success 200 {object} string
+// @Router /admin/inspection/schedule/id/{id}/cluster/{cluster}/trend [get]
+// @Router /admin/inspection/schedule/id/{id}/cluster/{cluster}/trend/runs/{runs} [get]
+func (s *AdminScheduleController) RuleTrend(c *gin.Context) {
+	scheduleID := utils.ToUInt(c.Param("id"))
+	cluster, err := utils.UrlSafeBase64Decode(c.Param("cluster"))
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	runs := utils.ToInt(c.Param("runs"))
+	sb := lua.ScheduleBackground{}
+	trends, err := sb.RuleTrends(scheduleID, string(cluster), runs)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonData(c, trends)
+}
@@ -22,6 +22,7 @@ func RegisterMCPServerRoutes(admin *gin.RouterGroup) {
 	admin.POST("/mcp/save", ctrl.Save)
 	admin.POST("/mcp/save/id/:id/status/:status", ctrl.QuickSave)
 	admin.GET("/mcp/log/list", ctrl.MCPLogList)
+	admin.GET("/mcp/status", ctrl.Status)
 }
 
 // @Summary 获取MCP服务器列表
@@ -32,6 +33,9 @@ func (m *ServerController) List(c *gin.Context) {
 	params := dao.BuildParams(c)
 	var mcpServer models.MCPServerConfig
 	list, count, err := mcpServer.List(params)
+	for _, item := range list {
+		item.MaskSecrets()
+	}
 	amis.WriteJsonListTotalWithError(c, count, list, err)
 }
 
@@ -83,6 +87,19 @@ func (m *ServerController) Save(c *gin.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	if entity.ID != 0 {
+		// 列表返回的是脱敏值，未修改的敏感字段沿用已保存的原值
+		var stored models.MCPServerConfig
+		if err := dao.DB().Where("id = ?", entity.ID).First(&stored).Error; err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+		entity.RestoreSecrets(&stored)
+	}
+	if err := entity.Validate(); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
 
 	err := entity.Save(params)
 	if err != nil {
@@ -135,6 +152,15 @@ func (m *ServerController) QuickSave(c *gin.Context) {
 	amis.WriteJsonErrorOrOK(c, err)
 }
 
+// @Summary 获取MCP服务器健康状态
+// @Description 返回各MCP服务器的连接状态、最近错误、连续失败及重连次数
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/mcp/status [get]
+func (m *ServerController) Status(c *gin.Context) {
+	amis.WriteJsonData(c, service.McpService().Host().GetStatuses())
+}
+
 // @Summary 获取MCP服务器日志列表
 // @Security BearerAuth
 // @Success 200 {object} string
@@ -0,0 +1,167 @@
+package runbook
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
+)
+
+// maxRunbookSize 上传的运维手册最大字节数
+const maxRunbookSize = 5 << 20
+
+// AdminRunbookController 运维手册管理控制器
+type AdminRunbookController struct {
+}
+
+// RegisterAdminRunbookRoutes 注册运维手册管理路由
+func RegisterAdminRunbookRoutes(admin *gin.RouterGroup) {
+	ctrl := &AdminRunbookController{}
+	admin.GET("/runbook/list", ctrl.List)
+	admin.POST("/runbook/save", ctrl.Save)
+	admin.POST("/runbook/upload", ctrl.Upload)
+	admin.POST("/runbook/delete/:ids", ctrl.Delete)
+	admin.GET("/runbook/search", ctrl.Search)
+}
+
+// @Summary 获取运维手册列表
+// @Security BearerAuth
+// @Success 200 {object} []models.Runbook
+// @Router /admin/runbook/list [get]
+func (r *AdminRunbookController) List(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := &models.Runbook{}
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 创建或更新运维手册
+// @Description 保存后重新分块并重建索引，启用的手册在 AI 对话及事件、日志分析时作为参考资料
+// @Security BearerAuth
+// @Param body body models.Runbook true "运维手册"
+// @Success 200 {object} string
+// @Router /admin/runbook/save [post]
+func (r *AdminRunbookController) Save(c *gin.Context) {
+	params := dao.BuildParams(c)
+	var m models.Runbook
+	if err := c.ShouldBindJSON(&m); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if err := service.RunbookService().Save(params, &m); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 上传运维手册
+// @Description 支持 Markdown（.md）及纯文本（.txt）文件，标题默认为文件名，上传后默认启用
+// @Security BearerAuth
+// @Param file formData file true "运维手册文件"
+// @Param title formData string false "标题"
+// @Param tags formData string false "标签，逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/runbook/upload [post]
+func (r *AdminRunbookController) Upload(c *gin.Context) {
+	params := dao.BuildParams(c)
+	file, err := c.FormFile("file")
+	if err != nil {
+		amis.WriteJsonError(c, fmt.Errorf("获取上传的文件错误。\n %v", err))
+		return
+	}
+	if file.Size > maxRunbookSize {
+		amis.WriteJsonError(c, fmt.Errorf("文件大小超过限制 %dMB", maxRunbookSize>>20))
+		return
+	}
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	format := models.RunbookFormatText
+	switch ext {
+	case ".md", ".markdown":
+		format = models.RunbookFormatMarkdown
+	case ".txt", ".log", "":
+	default:
+		amis.WriteJsonError(c, fmt.Errorf("不支持的文件类型 %s，仅支持 Markdown 及纯文本", ext))
+		return
+	}
+	src, err := file.Open()
+	if err != nil {
+		amis.WriteJsonError(c, fmt.Errorf("打开上传的文件错误。\n %v", err))
+		return
+	}
+	defer src.Close()
+	content, err := io.ReadAll(src)
+	if err != nil {
+		amis.WriteJsonError(c, fmt.Errorf("读取上传的文件内容错误。\n %v", err))
+		return
+	}
+
+	title := c.PostForm("title")
+	if title == "" {
+		title = strings.TrimSuffix(file.Filename, filepath.Ext(file.Filename))
+	}
+	m := &models.Runbook{
+		Title:   title,
+		Format:  format,
+		Content: string(content),
+		Tags:    c.PostForm("tags"),
+		Enabled: true,
+	}
+	if err := service.RunbookService().Save(params, m); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOKMsg(c, fmt.Sprintf("已上传，共 %d 个分块", m.ChunkCount))
+}
+
+// @Summary 删除运维手册
+// @Security BearerAuth
+// @Param ids path string true "运维手册ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/runbook/delete/{ids} [post]
+func (r *AdminRunbookController) Delete(c *gin.Context) {
+	ids := c.Param("ids")
+	params := dao.BuildParams(c)
+	params.UserName = ""
+	if err := service.RunbookService().Delete(params, ids); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 检索运维手册
+// @Description 用于验证检索效果，返回与问题最相关的片段及得分
+// @Security BearerAuth
+// @Param q query string true "问题"
+// @Param limit query int false "返回数量，默认5"
+// @Success 200 {object} []service.RunbookHit
+// @Router /admin/runbook/search [get]
+func (r *AdminRunbookController) Search(c *gin.Context) {
+	q := c.Query("q")
+	if strings.TrimSpace(q) == "" {
+		amis.WriteJsonError(c, fmt.Errorf("问题不能为空"))
+		return
+	}
+	limit := utils.ToInt(c.DefaultQuery("limit", "5"))
+	if limit <= 0 {
+		limit = 5
+	}
+	hits, err := service.RunbookService().Search(q, limit)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonList(c, hits)
+}
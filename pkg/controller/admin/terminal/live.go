@@ -0,0 +1,110 @@
+package terminal
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/service"
+	"k8s.io/klog/v2"
+)
+
+type AdminLiveSessionController struct {
+}
+
+// RegisterAdminLiveSessionRoutes 注册进行中终端会话的观察及强制终止路由
+// 会话登记在各实例内存中，多实例部署时仅能看到当前实例上的会话
+func RegisterAdminLiveSessionRoutes(admin *gin.RouterGroup) {
+	ctrl := &AdminLiveSessionController{}
+	admin.GET("/terminal/live/list", ctrl.List)
+	admin.GET("/terminal/live/id/:id/watch", ctrl.Watch)
+	admin.POST("/terminal/live/id/:id/kill", ctrl.Kill)
+}
+
+// @Summary 获取进行中的终端会话列表
+// @Security BearerAuth
+// @Success 200 {object} []xterm.LiveSessionInfo
+// @Router /admin/terminal/live/list [get]
+func (a *AdminLiveSessionController) List(c *gin.Context) {
+	list := service.TerminalSessionService().ListLive()
+	amis.WriteJsonListWithTotal(c, int64(len(list)), list)
+}
+
+// @Summary 只读观察终端会话
+// @Description 通过 WebSocket 实时接收终端输出，首先推送最近的输出内容；观察者发送的消息将被忽略
+// @Security BearerAuth
+// @Param id path string true "会话ID"
+// @Success 101 {string} string "WebSocket连接成功"
+// @Router /admin/terminal/live/id/{id}/watch [get]
+func (a *AdminLiveSessionController) Watch(c *gin.Context) {
+	ls, err := service.TerminalSessionService().GetLive(c.Param("id"))
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		},
+	}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		klog.Errorf("WebSocket Upgrade Error:%v", err)
+		return
+	}
+	defer conn.Close()
+
+	service.TerminalSessionService().RecordObserve(ls, amis.GetLoginUser(c))
+	output, cancel := ls.Subscribe()
+	defer cancel()
+
+	// 读取并丢弃观察者的输入，连接断开时结束观察
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case data, ok := <-output:
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "session ended"))
+				return
+			}
+			if err = conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err = conn.WriteMessage(websocket.PingMessage, []byte("keepalive")); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// @Summary 强制终止终端会话
+// @Description 结束会话并在操作日志中记录操作人
+// @Security BearerAuth
+// @Param id path string true "会话ID"
+// @Success 200 {object} string
+// @Router /admin/terminal/live/id/{id}/kill [post]
+func (a *AdminLiveSessionController) Kill(c *gin.Context) {
+	err := service.TerminalSessionService().KillLive(c.Param("id"), amis.GetLoginUser(c))
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOKMsg(c, "会话已终止")
+}
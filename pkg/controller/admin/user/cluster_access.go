@@ -0,0 +1,134 @@
+package user
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+)
+
+// @Summary 获取临时授权申请列表
+// @Security BearerAuth
+// @Success 200 {object} []models.ClusterAccessRequest
+// @Router /admin/cluster_permissions/access_request/list [get]
+func (a *AdminClusterPermission) ListAccessRequests(c *gin.Context) {
+	params := dao.BuildParams(c)
+	params.UserName = ""
+	m := &models.ClusterAccessRequest{}
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 批准临时授权申请
+// @Description 批准后生成带有效期的集群授权，到期后由Leader节点的后台任务自动回收
+// @Security BearerAuth
+// @Param id path int true "申请ID"
+// @Param comment body string false "审批意见"
+// @Success 200 {object} string
+// @Router /admin/cluster_permissions/access_request/approve/{id} [post]
+func (a *AdminClusterPermission) ApproveAccessRequest(c *gin.Context) {
+	req, comment, err := getAccessRequest(c)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if err = service.ClusterAccessService().Approve(req, amis.GetLoginUser(c), comment); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 驳回临时授权申请
+// @Security BearerAuth
+// @Param id path int true "申请ID"
+// @Param comment body string false "驳回意见"
+// @Success 200 {object} string
+// @Router /admin/cluster_permissions/access_request/reject/{id} [post]
+func (a *AdminClusterPermission) RejectAccessRequest(c *gin.Context) {
+	req, comment, err := getAccessRequest(c)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if err = service.ClusterAccessService().Reject(req, amis.GetLoginUser(c), comment); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 更新集群授权的有效期
+// @Description valid_from、valid_until 为空表示不限制
+// @Security BearerAuth
+// @Param id path int true "权限ID"
+// @Success 200 {object} string
+// @Router /admin/cluster_permissions/update_validity/{id} [post]
+func (a *AdminClusterPermission) UpdateValidity(c *gin.Context) {
+	id := c.Param("id")
+	var validity grantValidity
+	if err := c.ShouldBindJSON(&validity); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if err := validity.validate(); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	params := dao.BuildParams(c)
+	params.UserName = ""
+	m := &models.ClusterUserRole{}
+	m.ID = utils.ToUInt(id)
+	m.ValidFrom = validity.ValidFrom
+	m.ValidUntil = validity.ValidUntil
+	err := m.Save(params, func(db *gorm.DB) *gorm.DB {
+		return db.Select("valid_from", "valid_until")
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.UserService().ClearCacheByKey("cluster")
+
+	amis.WriteJsonOK(c)
+}
+
+// grantValidity 集群授权有效期
+type grantValidity struct {
+	ValidFrom  *time.Time `json:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until"`
+}
+
+func (v *grantValidity) validate() error {
+	if v.ValidFrom != nil && v.ValidUntil != nil && !v.ValidUntil.After(*v.ValidFrom) {
+		return fmt.Errorf("失效时间必须晚于生效时间")
+	}
+	return nil
+}
+
+func getAccessRequest(c *gin.Context) (*models.ClusterAccessRequest, string, error) {
+	var body struct {
+		Comment string `json:"comment"`
+	}
+	_ = c.ShouldBindJSON(&body)
+
+	m := &models.ClusterAccessRequest{}
+	req, err := m.GetOne(nil, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", utils.ToUInt(c.Param("id")))
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return req, body.Comment, nil
+}
@@ -2,6 +2,7 @@ package user
 
 import (
 	"fmt"
+	"path"
 	"strings"
 
 	"github.com/duke-git/lancet/v2/slice"
@@ -15,6 +16,7 @@ import (
 	"github.com/weibaohui/kom/kom"
 	"gorm.io/gorm"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/klog/v2"
 )
 
@@ -33,6 +35,12 @@ func RegisterClusterPermissionRoutes(admin *gin.RouterGroup) {
 	admin.POST("/cluster_permissions/delete/:ids", ctrl.DeleteClusterPermission)
 	admin.POST("/cluster_permissions/update_namespaces/:id", ctrl.UpdateNamespaces)
 	admin.POST("/cluster_permissions/update_blacklist_namespaces/:id", ctrl.UpdateBlacklistNamespaces)
+	admin.POST("/cluster_permissions/update_validity/:id", ctrl.UpdateValidity)
+	admin.POST("/cluster_permissions/update_scope/:id", ctrl.UpdateScope)
+	// 临时授权申请审批
+	admin.GET("/cluster_permissions/access_request/list", ctrl.ListAccessRequests)
+	admin.POST("/cluster_permissions/access_request/approve/:id", ctrl.ApproveAccessRequest)
+	admin.POST("/cluster_permissions/access_request/reject/:id", ctrl.RejectAccessRequest)
 
 }
 
@@ -161,9 +169,11 @@ func (a *AdminClusterPermission) SaveClusterPermission(c *gin.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
-	// {"users":"lisi,no2fa,test"}
+	// {"users":"lisi,no2fa,test","valid_from":"...","valid_until":"..."}
+	// valid_from、valid_until 可选，为空表示永久有效
 	type requestBody struct {
 		Users string `json:"users"`
+		grantValidity
 	}
 	var userList requestBody
 
@@ -172,12 +182,24 @@ func (a *AdminClusterPermission) SaveClusterPermission(c *gin.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	if err = userList.validate(); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
 
 	if userList.Users == "" {
 		amis.WriteJsonError(c, fmt.Errorf("用户列表不能为空"))
 		return
 	}
 
+	// 非内置角色，必须是已定义的自定义角色
+	if !constants.IsBuiltinClusterRole(role) {
+		if _, err = service.CustomRoleService().GetByName(role); err != nil {
+			amis.WriteJsonError(c, fmt.Errorf("角色[%s]不存在", role))
+			return
+		}
+	}
+
 	params := dao.BuildParams(c)
 
 	// 默认授权类型为用户
@@ -194,6 +216,8 @@ func (a *AdminClusterPermission) SaveClusterPermission(c *gin.Context) {
 			return db.Where(m)
 		})
 
+		m.ValidFrom = userList.ValidFrom
+		m.ValidUntil = userList.ValidUntil
 		if err != nil || one == nil {
 			// 不在用户权限条目，则添加
 			err := m.Save(params)
@@ -201,9 +225,18 @@ func (a *AdminClusterPermission) SaveClusterPermission(c *gin.Context) {
 				klog.V(6).Infof("新增用户权限失败: %s", err.Error())
 				continue
 			}
+			continue
 		}
 
-		// 如果存在该集群下的用户条目，则跳过，不做处理
+		// 如果存在该集群下的用户条目，仅在指定了有效期时更新有效期
+		if m.ValidFrom != nil || m.ValidUntil != nil {
+			m.ID = one.ID
+			if err := m.Save(params, func(db *gorm.DB) *gorm.DB {
+				return db.Select("valid_from", "valid_until")
+			}); err != nil {
+				klog.V(6).Infof("更新用户权限有效期失败: %s", err.Error())
+			}
+		}
 	}
 	service.UserService().ClearCacheByKey("cluster")
 	amis.WriteJsonOK(c)
@@ -298,3 +331,52 @@ func (a *AdminClusterPermission) UpdateBlacklistNamespaces(c *gin.Context) {
 
 	amis.WriteJsonOK(c)
 }
+
+// @Summary 更新指定集群用户角色的资源范围
+// @Description label_selector 为标签选择器（如 team=payments），name_patterns 为逗号分割的资源名称通配符（如 payments-*），为空表示不限制
+// @Security BearerAuth
+// @Param id path int true "权限ID"
+// @Success 200 {object} string
+// @Router /admin/cluster_permissions/update_scope/{id} [post]
+func (a *AdminClusterPermission) UpdateScope(c *gin.Context) {
+	id := c.Param("id")
+	type requestBody struct {
+		LabelSelector string `json:"label_selector"`
+		NamePatterns  string `json:"name_patterns"`
+	}
+	var scope requestBody
+
+	err := c.ShouldBindJSON(&scope)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if scope.LabelSelector != "" {
+		if _, err = labels.Parse(scope.LabelSelector); err != nil {
+			amis.WriteJsonError(c, fmt.Errorf("标签选择器格式错误: %v", err))
+			return
+		}
+	}
+	for _, pattern := range strings.Split(scope.NamePatterns, ",") {
+		if _, err = path.Match(strings.TrimSpace(pattern), ""); err != nil {
+			amis.WriteJsonError(c, fmt.Errorf("名称通配符[%s]格式错误: %v", pattern, err))
+			return
+		}
+	}
+
+	params := dao.BuildParams(c)
+	m := &models.ClusterUserRole{}
+	m.ID = utils.ToUInt(id)
+	m.LabelSelector = strings.TrimSpace(scope.LabelSelector)
+	m.NamePatterns = strings.TrimSpace(scope.NamePatterns)
+	err = m.Save(params, func(db *gorm.DB) *gorm.DB {
+		return db.Select("label_selector", "name_patterns")
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.UserService().ClearCacheByKey("cluster")
+
+	amis.WriteJsonOK(c)
+}
@@ -0,0 +1,178 @@
+package user
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+)
+
+type AdminCustomRoleController struct {
+}
+
+// AdminCustomRoleController 用于自定义集群角色相关接口
+// 路由注册函数
+func RegisterAdminCustomRoleRoutes(admin *gin.RouterGroup) {
+	ctrl := &AdminCustomRoleController{}
+	admin.GET("/custom_role/list", ctrl.List)
+	admin.POST("/custom_role/save", ctrl.Save)
+	admin.POST("/custom_role/delete/:ids", ctrl.Delete)
+	admin.GET("/custom_role/option_list", ctrl.OptionList)
+}
+
+// @Summary 获取自定义角色列表
+// @Security BearerAuth
+// @Success 200 {object} []models.CustomRole
+// @Router /admin/custom_role/list [get]
+func (a *AdminCustomRoleController) List(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := &models.CustomRole{}
+
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 保存自定义角色
+// @Description 新增或更新自定义角色，rules 为 JSON 格式的规则列表；重命名时引用旧名称的集群授权及审批策略同步更新
+// @Security BearerAuth
+// @Accept json
+// @Param data body models.CustomRole true "自定义角色信息"
+// @Success 200 {object} map[string]any
+// @Router /admin/custom_role/save [post]
+func (a *AdminCustomRoleController) Save(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := models.CustomRole{}
+	err := c.ShouldBindJSON(&m)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	m.Name = strings.TrimSpace(m.Name)
+	if m.Name == "" {
+		amis.WriteJsonError(c, fmt.Errorf("角色名称不能为空"))
+		return
+	}
+	if constants.IsBuiltinClusterRole(m.Name) || m.Name == constants.RolePlatformAdmin || m.Name == constants.RoleGuest {
+		amis.WriteJsonError(c, fmt.Errorf("角色名称[%s]与内置角色冲突", m.Name))
+		return
+	}
+	if _, err = m.GetRules(); err != nil {
+		amis.WriteJsonError(c, fmt.Errorf("规则格式错误: %v", err))
+		return
+	}
+
+	// 名称唯一
+	exists, err := (&models.CustomRole{}).GetOne(nil, func(db *gorm.DB) *gorm.DB {
+		return db.Where("name = ? and id != ?", m.Name, m.ID)
+	})
+	if err == nil && exists.ID != 0 {
+		amis.WriteJsonError(c, fmt.Errorf("角色名称[%s]已存在", m.Name))
+		return
+	}
+
+	// 重命名时，同步更新引用旧名称的集群授权及审批策略，避免授权失效
+	var oldName string
+	if m.ID != 0 {
+		if old, getErr := (&models.CustomRole{}).GetOne(nil, func(db *gorm.DB) *gorm.DB {
+			return db.Where("id = ?", m.ID)
+		}); getErr == nil {
+			oldName = old.Name
+		}
+	}
+
+	if m.CreatedBy == "" {
+		m.CreatedBy = params.UserName
+	}
+	err = dao.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&m).Error; err != nil {
+			return err
+		}
+		if oldName == "" || oldName == m.Name {
+			return nil
+		}
+		if err := tx.Model(&models.ClusterUserRole{}).Where("role = ?", oldName).Update("role", m.Name).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ApprovalPolicy{}).Where("approver_role = ?", oldName).Update("approver_role", m.Name).Error
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.CustomRoleService().ClearCache(m.Name)
+	if oldName != "" && oldName != m.Name {
+		service.CustomRoleService().ClearCache(oldName)
+		service.UserService().ClearCacheByKey("cluster")
+		service.ApprovalService().ClearCache()
+	}
+	amis.WriteJsonData(c, gin.H{
+		"id": m.ID,
+	})
+}
+
+// @Summary 删除自定义角色
+// @Description 根据ID批量删除自定义角色，已引用该角色的集群授权将失效
+// @Security BearerAuth
+// @Param ids path string true "角色ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/custom_role/delete/{ids} [post]
+func (a *AdminCustomRoleController) Delete(c *gin.Context) {
+	ids := c.Param("ids")
+	params := dao.BuildParams(c)
+	params.UserName = ""
+
+	m := &models.CustomRole{}
+	items, _, err := m.List(dao.BuildDefaultParams(), func(db *gorm.DB) *gorm.DB {
+		return db.Where("id in ?", utils.ToInt64Slice(ids))
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	err = m.Delete(params, ids)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	for _, item := range items {
+		service.CustomRoleService().ClearCache(item.Name)
+	}
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 集群角色选项列表
+// @Description 获取内置集群角色及全部自定义角色，用于集群授权时选择
+// @Security BearerAuth
+// @Success 200 {object} []map[string]string
+// @Router /admin/custom_role/option_list [get]
+func (a *AdminCustomRoleController) OptionList(c *gin.Context) {
+	names := []map[string]string{
+		{"label": "集群管理员", "value": constants.RoleClusterAdmin},
+		{"label": "集群只读", "value": constants.RoleClusterReadonly},
+		{"label": "集群Exec", "value": constants.RoleClusterPodExec},
+	}
+	items, err := service.CustomRoleService().List()
+	if err == nil {
+		for _, n := range items {
+			names = append(names, map[string]string{
+				"label": n.Name,
+				"value": n.Name,
+			})
+		}
+	}
+	amis.WriteJsonData(c, gin.H{
+		"options": names,
+	})
+}
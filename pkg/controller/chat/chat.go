@@ -1,10 +1,10 @@
 package chat
 
 import (
-	"context"
+	"fmt"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/weibaohui/htpl"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
 	"github.com/weibaohui/k8m/pkg/constants"
@@ -32,6 +32,11 @@ func RegisterChatRoutes(ai *gin.RouterGroup) {
 	ai.GET("/chat/ws_chatgpt", ctrl.GPTShell)
 	ai.GET("/chat/ws_chatgpt/history", ctrl.History)
 	ai.GET("/chat/ws_chatgpt/history/reset", ctrl.Reset)
+	ai.GET("/chat/ws_chatgpt/sessions", ctrl.ListSession)
+	ai.POST("/chat/ws_chatgpt/session/create", ctrl.CreateSession)
+	ai.POST("/chat/ws_chatgpt/session/:id/rename", ctrl.RenameSession)
+	ai.POST("/chat/ws_chatgpt/session/delete/:id", ctrl.DeleteSession)
+	ai.GET("/chat/ws_chatgpt/session/:id/export", ctrl.ExportSession)
 	ai.GET("/chat/k8s_gpt/resource", ctrl.K8sGPTResource)
 }
 
@@ -60,9 +65,13 @@ type ResourceData struct {
 	RegardingKind       string `form:"regardingKind"`
 	// AnyQuestion 任意提问
 	Question string `form:"question"`
+	// 回答使用的语言，为空时根据用户语言区域确定
+	Language string `form:"language"`
 }
 
-func handleRequest(c *gin.Context, promptFunc func(data any) string) {
+// handleRequest 处理AI分析请求，promptType 同时用于按功能选择模型
+// vars 构建提示词变量，标准变量（集群、资源类型、命名空间、语言）未设置时自动填充
+func handleRequest(c *gin.Context, promptType constants.AIPromptType, vars func(d ResourceData) map[string]any) {
 	if !service.AIService().IsEnabled() {
 		amis.WriteJsonData(c, gin.H{
 			"result": "请先配置开启ChatGPT功能",
@@ -77,9 +86,42 @@ func handleRequest(c *gin.Context, promptFunc func(data any) string) {
 		return
 	}
 
-	ctxInst := amis.GetContextWithUser(c)
+	cluster := c.Query("cluster")
+	ctxInst := service.AIService().WithFeature(amis.GetContextWithUser(c), promptType)
+	ctxInst = service.AIUsageService().WithCluster(ctxInst, cluster)
+
+	values := vars(data)
+	// 事件及日志分析附带相关的运维手册片段
+	if promptType == constants.AIPromptTypeEvent || promptType == constants.AIPromptTypeLog {
+		var query []string
+		for _, v := range values {
+			query = append(query, fmt.Sprint(v))
+		}
+		ctxInst = service.RunbookService().WithReferences(ctxInst, strings.Join(query, "\n"))
+	}
+	locale := c.GetHeader("Accept-Language")
+	language := data.Language
+	if language == "" {
+		language = models.PromptLanguage(locale)
+	}
+	for k, v := range map[string]any{
+		models.AIPromptVarCluster:   cluster,
+		models.AIPromptVarKind:      data.Kind,
+		models.AIPromptVarNamespace: data.Namespace,
+		models.AIPromptVarLocale:    locale,
+		models.AIPromptVarLanguage:  language,
+	} {
+		if _, ok := values[k]; !ok {
+			values[k] = v
+		}
+	}
 
-	prompt := promptFunc(data)
+	prompt, err := service.PromptService().RenderPrompt(ctxInst, promptType, values)
+	if err != nil {
+		klog.V(2).Infof("渲染%s提示词失败: %v", promptType, err)
+		amis.WriteJsonError(c, err)
+		return
+	}
 
 	stream, err := service.ChatService().GetChatStreamWithoutHistory(ctxInst, prompt)
 	if err != nil {
@@ -89,34 +131,6 @@ func handleRequest(c *gin.Context, promptFunc func(data any) string) {
 	sse.WriteWebSocketChatCompletionStream(c, stream)
 }
 
-// renderTemplate 通用的模板处理函数
-// templateStr: 模板字符串
-// contextBuilder: 根据ResourceData构建上下文的函数
-func renderTemplate(templateStr string, data any, contextBuilder func(ResourceData) map[string]any) string {
-	d, ok := data.(ResourceData)
-	if !ok {
-		klog.V(6).Infof("Error: data is not ResourceData type")
-		return ""
-	}
-	eng := htpl.NewEngine()
-	// 解析模板
-	tpl, err := eng.ParseString(templateStr)
-	if err != nil {
-		klog.V(6).Infof("Error Parse template:%v\n\n", err)
-		return ""
-	}
-
-	ctx := contextBuilder(d)
-
-	// 渲染模板
-	result, err := tpl.Render(ctx)
-	if err != nil {
-		klog.V(6).Infof("Error Render template:%v\n\n", err)
-		return ""
-	}
-	return result
-}
-
 // @Summary 分析K8s事件
 // @Security BearerAuth
 // @Param note query string false "事件备注"
@@ -128,19 +142,14 @@ func renderTemplate(templateStr string, data any, contextBuilder func(ResourceDa
 // @Router /ai/chat/event [get]
 func (cc *Controller) Event(c *gin.Context) {
 
-	handleRequest(c, func(data any) string {
-		// 从数据库获取prompt模板
-		templateStr := getPromptWithFallback(c.Request.Context(), constants.AIPromptTypeEvent)
-
-		return renderTemplate(templateStr, data, func(d ResourceData) map[string]any {
-			return map[string]any{
-				"Note":          d.Note,
-				"Source":        d.Source,
-				"Reason":        d.Reason,
-				"Type":          d.Type,
-				"RegardingKind": d.RegardingKind,
-			}
-		})
+	handleRequest(c, constants.AIPromptTypeEvent, func(d ResourceData) map[string]any {
+		return map[string]any{
+			"Note":          d.Note,
+			"Source":        d.Source,
+			"Reason":        d.Reason,
+			"Type":          d.Type,
+			"RegardingKind": d.RegardingKind,
+		}
 	})
 }
 
@@ -172,17 +181,12 @@ func (cc *Controller) Describe(c *gin.Context) {
 		Namespace(data.Namespace).
 		Describe(&describe)
 
-	handleRequest(c, func(data any) string {
-		// 从数据库获取prompt模板
-		templateStr := getPromptWithFallback(c.Request.Context(), constants.AIPromptTypeDescribe)
-
-		return renderTemplate(templateStr, data, func(d ResourceData) map[string]any {
-			return map[string]any{
-				"Group":        d.Group,
-				"Kind":         d.Kind,
-				"DescribeInfo": string(describe),
-			}
-		})
+	handleRequest(c, constants.AIPromptTypeDescribe, func(d ResourceData) map[string]any {
+		return map[string]any{
+			"Group":        d.Group,
+			"Kind":         d.Kind,
+			"DescribeInfo": string(describe),
+		}
 	})
 }
 
@@ -194,17 +198,12 @@ func (cc *Controller) Describe(c *gin.Context) {
 // @Success 200 {object} string
 // @Router /ai/chat/example [get]
 func (cc *Controller) Example(c *gin.Context) {
-	handleRequest(c, func(data any) string {
-		// 从数据库获取prompt模板
-		templateStr := getPromptWithFallback(c.Request.Context(), constants.AIPromptTypeExample)
-
-		return renderTemplate(templateStr, data, func(d ResourceData) map[string]any {
-			return map[string]any{
-				"Kind":    d.Kind,
-				"Group":   d.Group,
-				"Version": d.Version,
-			}
-		})
+	handleRequest(c, constants.AIPromptTypeExample, func(d ResourceData) map[string]any {
+		return map[string]any{
+			"Kind":    d.Kind,
+			"Group":   d.Group,
+			"Version": d.Version,
+		}
 	})
 }
 
@@ -217,18 +216,13 @@ func (cc *Controller) Example(c *gin.Context) {
 // @Success 200 {object} string
 // @Router /ai/chat/example/field [get]
 func (cc *Controller) FieldExample(c *gin.Context) {
-	handleRequest(c, func(data any) string {
-		// 从数据库获取prompt模板
-		templateStr := getPromptWithFallback(c.Request.Context(), constants.AIPromptTypeFieldExample)
-
-		return renderTemplate(templateStr, data, func(d ResourceData) map[string]any {
-			return map[string]any{
-				"Kind":    d.Kind,
-				"Group":   d.Group,
-				"Version": d.Version,
-				"Field":   d.Field,
-			}
-		})
+	handleRequest(c, constants.AIPromptTypeFieldExample, func(d ResourceData) map[string]any {
+		return map[string]any{
+			"Kind":    d.Kind,
+			"Group":   d.Group,
+			"Version": d.Version,
+			"Field":   d.Field,
+		}
 	})
 }
 
@@ -240,17 +234,12 @@ func (cc *Controller) FieldExample(c *gin.Context) {
 // @Success 200 {object} string
 // @Router /ai/chat/resource [get]
 func (cc *Controller) Resource(c *gin.Context) {
-	handleRequest(c, func(data any) string {
-		// 从数据库获取prompt模板
-		templateStr := getPromptWithFallback(c.Request.Context(), constants.AIPromptTypeResource)
-
-		return renderTemplate(templateStr, data, func(d ResourceData) map[string]any {
-			return map[string]any{
-				"Kind":    d.Kind,
-				"Group":   d.Group,
-				"Version": d.Version,
-			}
-		})
+	handleRequest(c, constants.AIPromptTypeResource, func(d ResourceData) map[string]any {
+		return map[string]any{
+			"Kind":    d.Kind,
+			"Group":   d.Group,
+			"Version": d.Version,
+		}
 	})
 }
 
@@ -263,18 +252,13 @@ func (cc *Controller) Resource(c *gin.Context) {
 // @Success 200 {object} string
 // @Router /ai/chat/k8s_gpt/resource [get]
 func (cc *Controller) K8sGPTResource(c *gin.Context) {
-	handleRequest(c, func(data any) string {
-		// 从数据库获取prompt模板
-		templateStr := getPromptWithFallback(c.Request.Context(), constants.AIPromptTypeK8sGPTResource)
-
-		return renderTemplate(templateStr, data, func(d ResourceData) map[string]any {
-			return map[string]any{
-				"Data":  d.Data,
-				"Name":  d.Name,
-				"Kind":  d.Kind,
-				"Field": d.Field,
-			}
-		})
+	handleRequest(c, constants.AIPromptTypeK8sGPTResource, func(d ResourceData) map[string]any {
+		return map[string]any{
+			"Data":  d.Data,
+			"Name":  d.Name,
+			"Kind":  d.Kind,
+			"Field": d.Field,
+		}
 	})
 }
 
@@ -284,15 +268,10 @@ func (cc *Controller) K8sGPTResource(c *gin.Context) {
 // @Success 200 {object} string
 // @Router /ai/chat/any_selection [get]
 func (cc *Controller) AnySelection(c *gin.Context) {
-	handleRequest(c, func(data any) string {
-		// 从数据库获取prompt模板
-		templateStr := getPromptWithFallback(c.Request.Context(), constants.AIPromptTypeAnySelection)
-
-		return renderTemplate(templateStr, data, func(d ResourceData) map[string]any {
-			return map[string]any{
-				"Question": d.Question,
-			}
-		})
+	handleRequest(c, constants.AIPromptTypeAnySelection, func(d ResourceData) map[string]any {
+		return map[string]any{
+			"Question": d.Question,
+		}
 	})
 }
 
@@ -305,18 +284,13 @@ func (cc *Controller) AnySelection(c *gin.Context) {
 // @Success 200 {object} string
 // @Router /ai/chat/any_question [get]
 func (cc *Controller) AnyQuestion(c *gin.Context) {
-	handleRequest(c, func(data any) string {
-		// 从数据库获取prompt模板
-		templateStr := getPromptWithFallback(c.Request.Context(), constants.AIPromptTypeAnyQuestion)
-
-		return renderTemplate(templateStr, data, func(d ResourceData) map[string]any {
-			return map[string]any{
-				"Kind":     d.Kind,
-				"Group":    d.Group,
-				"Version":  d.Version,
-				"Question": d.Question,
-			}
-		})
+	handleRequest(c, constants.AIPromptTypeAnyQuestion, func(d ResourceData) map[string]any {
+		return map[string]any{
+			"Kind":     d.Kind,
+			"Group":    d.Group,
+			"Version":  d.Version,
+			"Question": d.Question,
+		}
 	})
 }
 
@@ -326,15 +300,10 @@ func (cc *Controller) AnyQuestion(c *gin.Context) {
 // @Success 200 {object} string
 // @Router /ai/chat/cron [get]
 func (cc *Controller) Cron(c *gin.Context) {
-	handleRequest(c, func(data any) string {
-		// 从数据库获取prompt模板
-		templateStr := getPromptWithFallback(c.Request.Context(), constants.AIPromptTypeCron)
-
-		return renderTemplate(templateStr, data, func(d ResourceData) map[string]any {
-			return map[string]any{
-				"Cron": d.Cron,
-			}
-		})
+	handleRequest(c, constants.AIPromptTypeCron, func(d ResourceData) map[string]any {
+		return map[string]any{
+			"Cron": d.Cron,
+		}
 	})
 }
 
@@ -344,30 +313,9 @@ func (cc *Controller) Cron(c *gin.Context) {
 // @Success 200 {object} string
 // @Router /ai/chat/log [get]
 func (cc *Controller) Log(c *gin.Context) {
-	handleRequest(c, func(data any) string {
-		// 从数据库获取prompt模板
-		templateStr := getPromptWithFallback(c.Request.Context(), constants.AIPromptTypeLog)
-
-		return renderTemplate(templateStr, data, func(d ResourceData) map[string]any {
-			return map[string]any{
-				"Data": utils.ToJSON(d.Data),
-			}
-		})
+	handleRequest(c, constants.AIPromptTypeLog, func(d ResourceData) map[string]any {
+		return map[string]any{
+			"Data": utils.ToJSON(d.Data),
+		}
 	})
 }
-
-// getPromptWithFallback 根据提示类型从数据库获取模板，若失败则回退到内置模板。
-// 参数说明：
-// - ctx: 请求上下文，用于数据库或服务调用的上下文传递。
-// - promptType: 提示词类型常量（如 constants.AIPromptTypeEvent 等）。
-// 返回值：
-// - 模板字符串，如果数据库查询失败则返回内置模板内容。
-func getPromptWithFallback(ctx context.Context, promptType constants.AIPromptType) string {
-	templateStr, err := service.PromptService().GetPrompt(ctx, promptType)
-	if err != nil {
-		klog.Errorf("获取%s prompt模板失败: %v", promptType, err)
-		// 如果获取失败，使用内置模板
-		templateStr = models.GetBuiltinPromptContent(promptType)
-	}
-	return templateStr
-}
@@ -3,21 +3,27 @@ package chat
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/constants"
 	"github.com/weibaohui/k8m/pkg/service"
 )
 
 // @Summary 获取聊天历史记录
 // @Security BearerAuth
+// @Param session_id query int false "会话ID，不指定时使用最近的会话"
 // @Success 200 {object} string
 // @Router /ai/chat/history [get]
 func (cc *Controller) History(c *gin.Context) {
-	client, err := service.AIService().DefaultClient()
+	client, err := service.AIService().ClientFor(constants.AIFeatureGPTShell)
 	if err != nil {
 		amis.WriteJsonError(c, err)
 		return
 	}
 
-	ctx := amis.GetContextWithUser(c)
+	ctx, _, err := sessionContext(c)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
 	history := client.GetHistory(ctx)
 	amis.WriteJsonData(c, history)
 
@@ -25,15 +31,20 @@ func (cc *Controller) History(c *gin.Context) {
 
 // @Summary 重置聊天历史记录
 // @Security BearerAuth
+// @Param session_id query int false "会话ID，不指定时使用最近的会话"
 // @Success 200 {object} string
 // @Router /ai/chat/reset [post]
 func (cc *Controller) Reset(c *gin.Context) {
-	client, err := service.AIService().DefaultClient()
+	client, err := service.AIService().ClientFor(constants.AIFeatureGPTShell)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	ctx, _, err := sessionContext(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
 		return
 	}
-	ctx := amis.GetContextWithUser(c)
 	err = client.ClearHistory(ctx)
 	if err != nil {
 		amis.WriteJsonError(c, err)
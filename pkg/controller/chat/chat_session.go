@@ -0,0 +1,119 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/ai"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+)
+
+// sessionContext 根据 session_id 参数确定当前对话会话，并返回携带用户名及会话ID的 ctx
+// 未指定 session_id 时使用用户最近的会话，用户没有会话时按 cluster/namespace 参数创建
+func sessionContext(c *gin.Context) (context.Context, *models.ChatSession, error) {
+	username := amis.GetLoginUser(c)
+	session, err := service.ChatSessionService().Resolve(utils.ToUInt(c.Query("session_id")), username, c.Query("cluster"), c.Query("namespace"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return ai.WithSessionID(amis.GetContextWithUser(c), session.ID), session, nil
+}
+
+// @Summary 获取AI对话会话列表
+// @Security BearerAuth
+// @Success 200 {object} []models.ChatSession
+// @Router /ai/chat/ws_chatgpt/sessions [get]
+func (cc *Controller) ListSession(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := &models.ChatSession{}
+	username := amis.GetLoginUser(c)
+	items, total, err := m.List(params, func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_name = ?", username).Order("updated_at desc")
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 创建AI对话会话
+// @Security BearerAuth
+// @Param body body models.ChatSession true "会话标题及绑定的集群/命名空间"
+// @Success 200 {object} models.ChatSession
+// @Router /ai/chat/ws_chatgpt/session/create [post]
+func (cc *Controller) CreateSession(c *gin.Context) {
+	var req models.ChatSession
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	session, err := service.ChatSessionService().Create(amis.GetLoginUser(c), req.Title, req.Cluster, req.Namespace)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonData(c, session)
+}
+
+// @Summary 重命名AI对话会话
+// @Security BearerAuth
+// @Param id path int true "会话ID"
+// @Param body body object true "新标题，格式：{\"title\":\"xxx\"}"
+// @Success 200 {object} string
+// @Router /ai/chat/ws_chatgpt/session/{id}/rename [post]
+func (cc *Controller) RenameSession(c *gin.Context) {
+	var req struct {
+		Title string `json:"title"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if req.Title == "" {
+		amis.WriteJsonError(c, fmt.Errorf("标题不能为空"))
+		return
+	}
+	err := service.ChatSessionService().Rename(utils.ToUInt(c.Param("id")), amis.GetLoginUser(c), req.Title)
+	amis.WriteJsonErrorOrOK(c, err)
+}
+
+// @Summary 删除AI对话会话
+// @Security BearerAuth
+// @Param id path int true "会话ID"
+// @Success 200 {object} string
+// @Router /ai/chat/ws_chatgpt/session/delete/{id} [post]
+func (cc *Controller) DeleteSession(c *gin.Context) {
+	err := service.ChatSessionService().Delete(utils.ToUInt(c.Param("id")), amis.GetLoginUser(c))
+	amis.WriteJsonErrorOrOK(c, err)
+}
+
+// @Summary 导出AI对话会话
+// @Security BearerAuth
+// @Param id path int true "会话ID"
+// @Param format query string false "导出格式：markdown（默认）、json"
+// @Produce text/markdown
+// @Success 200 {string} string "会话内容"
+// @Router /ai/chat/ws_chatgpt/session/{id}/export [get]
+func (cc *Controller) ExportSession(c *gin.Context) {
+	id := utils.ToUInt(c.Param("id"))
+	format := c.DefaultQuery("format", "markdown")
+	content, err := service.ChatSessionService().Export(id, amis.GetLoginUser(c), format)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	contentType, ext := "text/markdown; charset=utf-8", "md"
+	if format == "json" {
+		contentType, ext = "application/json; charset=utf-8", "json"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=chat-session-%d.%s", id, ext))
+	c.Data(http.StatusOK, contentType, []byte(content))
+}
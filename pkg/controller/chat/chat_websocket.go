@@ -2,6 +2,7 @@ package chat
 
 import (
 	"bytes"
+	"context"
 	"net/http"
 	"sync"
 	"time"
@@ -10,10 +11,14 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
 	"github.com/weibaohui/k8m/pkg/comm/xterm"
+	"github.com/weibaohui/k8m/pkg/constants"
 	"github.com/weibaohui/k8m/pkg/service"
 	"k8s.io/klog/v2"
 )
 
+// toolConfirmTimeout 等待用户确认变更类工具调用的最长时间，超时视为拒绝
+const toolConfirmTimeout = 5 * time.Minute
+
 var WebsocketMessageType = map[int]string{
 	websocket.BinaryMessage: "binary",
 	websocket.TextMessage:   "text",
@@ -29,6 +34,7 @@ var WebsocketMessageType = map[int]string{
 // @Param name query string false "资源名称"
 // @Param resource query string false "资源类型"
 // @Param content query string false "对话内容"
+// @Param session_id query int false "会话ID，不指定时使用最近的会话，没有会话时按集群/命名空间新建"
 // @Success 101 {string} string "Switching Protocols"
 // @Router /ai/chat/gptshell [get]
 // GPTShell 通过 WebSocket 提供与 ChatGPT 及工具集成的交互式对话终端。
@@ -36,6 +42,7 @@ var WebsocketMessageType = map[int]string{
 // 该函数升级 HTTP 连接为 WebSocket，维持心跳检测，实现双向消息流转：
 // - 前端发送消息后，调用 ChatGPT 并动态集成可用工具，支持流式响应和工具调用结果返回；
 // - 后端将 AI 回复和工具执行结果实时推送给前端；
+// - 变更类工具（创建、删除、扩缩容、patch、exec 等）执行前推送 pending_tool_call 消息，待用户批准、修改或拒绝后再执行；
 // - 自动处理连接异常、心跳超时和资源释放。
 //
 // 若 AI 服务未启用或参数绑定失败，将返回相应错误信息。
@@ -55,6 +62,16 @@ func (cc *Controller) GPTShell(c *gin.Context) {
 		return
 	}
 
+	// 在升级为 WebSocket 之前确定会话，会话不存在或不属于当前用户时直接返回错误
+	ctxInst, session, err := sessionContext(c)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	ctxInst = service.AIService().WithFeature(ctxInst, constants.AIFeatureGPTShell)
+	ctxInst = service.AIUsageService().WithCluster(ctxInst, session.Cluster)
+	ctxInst = service.RedactionService().WithRedactor(ctxInst)
+
 	connectionErrorLimit := 10
 
 	keepalivePingTimeout := 20 * time.Second
@@ -74,7 +91,7 @@ func (cc *Controller) GPTShell(c *gin.Context) {
 		return
 	}
 	defer conn.Close()
-	klog.V(6).Infof("ws Client connected")
+	klog.V(6).Infof("ws Client connected, chat session %d", session.ID)
 
 	// 创建一个写锁，用于保护WebSocket写操作
 	var writeMutex sync.Mutex
@@ -153,9 +170,29 @@ func (cc *Controller) GPTShell(c *gin.Context) {
 		}
 	}()
 
+	// 变更类工具调用需要前端确认，确认请求作为单独的一条消息发送，前端回复 tool_call_reply 消息
+	ctxInst, cancel := context.WithCancel(ctxInst)
+	confirmer := service.NewToolConfirmer(func(data []byte) error {
+		return safeWriteMessage(websocket.TextMessage, data)
+	}, toolConfirmTimeout)
+	ctxInst = service.WithToolConfirmer(ctxInst, confirmer)
+
+	// 对话在单独的协程中逐轮执行，读取协程可以在对话执行期间接收确认回复
+	prompts := make(chan string, 10)
+	go func() {
+		for prompt := range prompts {
+			if err := service.ChatService().RunOneRound(ctxInst, prompt, &outBuffer); err != nil {
+				klog.V(6).Infof("failed to run chat round: %s", err)
+			}
+		}
+	}()
+
 	// chatgpt << ws
 	go func() {
-		ctxInst := amis.GetContextWithUser(c)
+		defer func() {
+			cancel()
+			close(prompts)
+		}()
 		for {
 			// data processing
 			messageType, data, err := conn.ReadMessage()
@@ -173,15 +210,19 @@ func (cc *Controller) GPTShell(c *gin.Context) {
 			}
 			klog.V(6).Infof("received %s (type: %v) message of size %v byte(s) from web ui with key sequence: %v  [%s]", dataType, messageType, dataLength, dataBuffer, string(dataBuffer))
 
-			klog.V(6).Infof("prompt: %s", string(data))
-
-			err = service.ChatService().RunOneRound(ctxInst, string(data), &outBuffer)
-
-			if err != nil {
-				klog.V(6).Infof("failed to write %v bytes to tty: %s", len(dataBuffer), err)
+			if confirmer.Reply(dataBuffer) {
 				continue
 			}
 
+			klog.V(6).Infof("prompt: %s", string(data))
+
+			// 队列已满时直接拒绝，不能阻塞读取协程，否则无法接收确认回复
+			select {
+			case prompts <- string(data):
+			default:
+				klog.V(6).Infof("chat prompt queue is full, drop prompt: %s", string(data))
+				_ = safeWriteMessage(websocket.TextMessage, []byte("当前还有未处理完的问题，请稍后再发送。\n"))
+			}
 		}
 
 	}()
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/pkg/comm"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
 	"github.com/weibaohui/kom/kom"
@@ -70,7 +71,7 @@ func (cc *Controller) Import(c *gin.Context) {
 	defer os.Remove(tempFilePath) // 请求结束时删除临时文件
 
 	var cm *v1.ConfigMap
-	err = kom.Cluster(selectedCluster).WithContext(ctx).Resource(&v1.ConfigMap{}).Name(name).Namespace(ns).Get(&cm).Error
+	err = kom.Cluster(selectedCluster).WithContext(comm.WithoutRedact(ctx)).Resource(&v1.ConfigMap{}).Name(name).Namespace(ns).Get(&cm).Error
 	if err != nil {
 		amis.WriteJsonError(c, fmt.Errorf("获取configmap错误: %v", err))
 		return
@@ -129,7 +130,7 @@ func (cc *Controller) Update(c *gin.Context) {
 		return
 	}
 	var cm *v1.ConfigMap
-	err = kom.Cluster(selectedCluster).WithContext(ctx).Resource(&v1.ConfigMap{}).Name(name).Namespace(ns).Get(&cm).Error
+	err = kom.Cluster(selectedCluster).WithContext(comm.WithoutRedact(ctx)).Resource(&v1.ConfigMap{}).Name(name).Namespace(ns).Get(&cm).Error
 	if err != nil {
 		amis.WriteJsonError(c, fmt.Errorf("获取configmap错误: %v", err))
 		return
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/pkg/comm"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
 	"github.com/weibaohui/kom/kom"
@@ -602,7 +603,7 @@ func (nc *ActionController) BatchUpdateImages(c *gin.Context) {
 		
 		// 获取现有的Deployment
 		var existingDeployment v1.Deployment
-		err = kom.Cluster(selectedCluster).WithContext(ctx).
+		err = kom.Cluster(selectedCluster).WithContext(comm.WithoutRedact(ctx)).
 			Resource(&v1.Deployment{}).
 			Namespace(deployment.Namespace).
 			Name(deployment.Name).
@@ -8,8 +8,11 @@ import (
 
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/pkg/comm"
 	utils2 "github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/service"
 	"github.com/weibaohui/kom/kom"
 	"github.com/weibaohui/kom/utils"
@@ -37,6 +40,10 @@ func RegisterActionRoutes(api *gin.RouterGroup) {
 	api.POST("/:kind/group/:group/version/:version/list/ns/", ctrl.List)                                  // CRD
 	api.POST("/:kind/group/:group/version/:version/list", ctrl.List)
 
+	// 审批通过后，以申请人身份重放强制删除操作
+	service.ApprovalService().RegisterExecutor(constants.ApprovalActionForceRemove, func(ctx context.Context, req *models.ApprovalRequest) (string, error) {
+		return "", ctrl.removeSingle(ctx, req.Cluster, req.Kind, req.Group, req.Version, req.Namespace, req.Name, true)
+	})
 }
 
 // @Summary 获取资源列表
@@ -440,20 +447,37 @@ func (ac *ActionController) BatchForceRemove(c *gin.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	result := &comm.BatchResult{Unit: "项"}
 	for i := 0; i < len(req.Names); i++ {
 		name := req.Names[i]
 		ns := req.Namespaces[i]
-		x := ac.removeSingle(ctx, selectedCluster, kind, group, version, ns, name, true)
+		// 强制删除命中审批策略时，提交审批申请，审批通过后再执行
+		submitted, x := comm.SubmitApprovalIfRequired(ctx, &models.ApprovalRequest{
+			Cluster:   selectedCluster,
+			Namespace: ns,
+			Group:     group,
+			Version:   version,
+			Kind:      kind,
+			Name:      name,
+			Action:    constants.ApprovalActionForceRemove,
+		}, "delete")
+		if x == nil && !submitted {
+			x = ac.removeSingle(ctx, selectedCluster, kind, group, version, ns, name, true)
+		}
 		if x != nil {
 			klog.V(6).Infof("batch force remove %s error %s/%s %v", kind, ns, name, x)
-			err = x
 		}
+		result.Add(fmt.Sprintf("%s/%s", ns, name), submitted, x)
 	}
 
-	if err != nil {
+	if err = result.Err(); err != nil {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	if msg := result.Message(); msg != "" {
+		amis.WriteJsonOKMsg(c, msg)
+		return
+	}
 
 	amis.WriteJsonOK(c)
 }
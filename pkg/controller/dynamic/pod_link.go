@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/pkg/comm"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
 	"github.com/weibaohui/k8m/pkg/service"
 	"github.com/weibaohui/kom/kom"
@@ -305,6 +306,13 @@ func (pc *PodLinkController) LinksEnv(c *gin.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	// 无查看Secret权限时，敏感数据脱敏
+	if !comm.CanViewSensitiveData(ctx, selectedCluster, []string{pod.Namespace}, "Pod") {
+		if err = comm.RedactEnvDest(&env); err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+	}
 	amis.WriteJsonList(c, env)
 }
 
@@ -342,6 +350,13 @@ func (pc *PodLinkController) LinksEnvFromPod(c *gin.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	// 无查看Secret权限时，敏感数据脱敏
+	if !comm.CanViewSensitiveData(ctx, selectedCluster, []string{pod.Namespace}, "Pod") {
+		if err = comm.RedactEnvDest(&env); err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+	}
 	amis.WriteJsonList(c, env)
 }
 
@@ -379,6 +394,13 @@ func (pc *PodLinkController) LinksConfigMap(c *gin.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	// 无查看Secret权限时，敏感数据脱敏
+	if !comm.CanViewSensitiveData(ctx, selectedCluster, []string{pod.Namespace}, "ConfigMap") {
+		if err = comm.RedactDest(&configMap, "ConfigMap"); err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+	}
 	amis.WriteJsonList(c, configMap)
 }
 
@@ -416,6 +438,13 @@ func (pc *PodLinkController) LinksSecret(c *gin.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	// 无查看Secret权限时，敏感数据脱敏
+	if !comm.CanViewSensitiveData(ctx, selectedCluster, []string{pod.Namespace}, "Secret") {
+		if err = comm.RedactDest(&secret, "Secret"); err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+	}
 	amis.WriteJsonList(c, secret)
 }
 
@@ -1,13 +1,21 @@
 package dynamic
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"regexp"
 	"strings"
 
+	"github.com/duke-git/lancet/v2/slice"
 	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/pkg/comm"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
 	"github.com/weibaohui/kom/kom"
+	"sigs.k8s.io/yaml"
 )
 
 type YamlController struct{}
@@ -17,6 +25,12 @@ func RegisterYamlRoutes(api *gin.RouterGroup) {
 	api.POST("/yaml/apply", ctrl.Apply)
 	api.POST("/yaml/upload", ctrl.UploadFile)
 	api.POST("/yaml/delete", ctrl.Delete)
+
+	// 审批通过后，以申请人身份重放YAML删除操作
+	service.ApprovalService().RegisterExecutor(constants.ApprovalActionYamlDelete, func(ctx context.Context, req *models.ApprovalRequest) (string, error) {
+		result := kom.Cluster(req.Cluster).WithContext(ctx).Applier().Delete(req.Params)
+		return strings.Join(result, "\n"), nil
+	})
 }
 
 // @Summary 上传YAML文件并应用
@@ -102,8 +116,85 @@ func (yc *YamlController) Delete(c *gin.Context) {
 		return
 	}
 	yamlStr := req.Yaml
+
+	// 删除命中审批策略时，逐个校验申请人对YAML中资源的删除权限后提交审批申请，审批通过后再执行
+	// 重放时仍由kom回调逐个校验权限
+	targets := yamlDeleteTargets(yamlStr)
+	var namespaces, names []string
+	for _, t := range targets {
+		if t.Namespace != "" {
+			namespaces = append(namespaces, t.Namespace)
+		}
+		names = append(names, fmt.Sprintf("%s/%s", t.Kind, t.Name))
+	}
+	submitted, err := comm.SubmitApprovalWithCheck(ctx, &models.ApprovalRequest{
+		Cluster:   selectedCluster,
+		Namespace: strings.Join(slice.Unique(namespaces), ","),
+		Kind:      "YAML",
+		Name:      strings.Join(names, ","),
+		Action:    constants.ApprovalActionYamlDelete,
+		Params:    yamlStr,
+	}, func(nsList []string) error {
+		for _, t := range targets {
+			if err := comm.CheckPermissionLogic(ctx, selectedCluster, []string{t.Namespace}, t.Namespace, t.Name, "delete", t.Group, t.Kind); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if submitted {
+		amis.WriteJsonOKMsg(c, "该操作需要审批，已提交审批申请")
+		return
+	}
+
 	result := kom.Cluster(selectedCluster).WithContext(ctx).Applier().Delete(yamlStr)
 	amis.WriteJsonData(c, gin.H{
 		"result": result,
 	})
 }
+
+var yamlDocSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// yamlTarget YAML中声明的资源
+type yamlTarget struct {
+	Group     string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// yamlDeleteTargets 解析YAML中涉及的资源，用于审批策略匹配、权限校验及展示
+func yamlDeleteTargets(yamlStr string) []yamlTarget {
+	var targets []yamlTarget
+	for _, doc := range yamlDocSeparator.Split(yamlStr, -1) {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		var obj struct {
+			APIVersion string `json:"apiVersion"`
+			Kind       string `json:"kind"`
+			Metadata   struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			continue
+		}
+		var group string
+		if i := strings.LastIndex(obj.APIVersion, "/"); i >= 0 {
+			group = obj.APIVersion[:i]
+		}
+		targets = append(targets, yamlTarget{
+			Group:     group,
+			Kind:      obj.Kind,
+			Namespace: obj.Metadata.Namespace,
+			Name:      obj.Metadata.Name,
+		})
+	}
+	return targets
+}
@@ -1,11 +1,13 @@
 package helm
 
 import (
+	"context"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/weibaohui/k8m/pkg/comm"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/constants"
 	"github.com/weibaohui/k8m/pkg/helm"
 	"github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/service"
@@ -64,6 +66,28 @@ func check(c *gin.Context, cluster, ns, name, action string) error {
 	if ns != "" {
 		nsList = append(nsList, ns)
 	}
-	err := comm.CheckPermissionLogic(ctx, cluster, nsList, ns, name, action)
+	err := comm.CheckPermissionLogic(ctx, cluster, nsList, ns, name, action, "", "Helm")
 	return err
 }
+
+// submitUninstallApproval 卸载命中审批策略时，校验申请人的delete权限后提交审批申请，审批通过后再执行
+func submitUninstallApproval(c *gin.Context, ns, releaseName string) (bool, error) {
+	cluster, _ := amis.GetSelectedCluster(c)
+	return comm.SubmitApprovalIfRequired(amis.GetContextWithUser(c), &models.ApprovalRequest{
+		Cluster:   cluster,
+		Namespace: ns,
+		Kind:      "Helm",
+		Name:      releaseName,
+		Action:    constants.ApprovalActionHelmUninstall,
+	}, "delete")
+}
+
+// uninstallApproved 审批通过后，以申请人身份重放卸载操作
+func uninstallApproved(ctx context.Context, req *models.ApprovalRequest) (string, error) {
+	err := comm.CheckPermissionLogic(ctx, req.Cluster, []string{req.Namespace}, req.Namespace, req.Name, "delete", "", "Helm")
+	if err != nil {
+		return "", err
+	}
+	cluster := service.ClusterService().GetClusterByID(req.Cluster)
+	return "", helm.NewHelmCmd("helm", req.Cluster, cluster).UninstallRelease(req.Namespace, req.Name)
+}
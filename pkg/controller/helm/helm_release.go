@@ -6,9 +6,12 @@ import (
 
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/pkg/comm"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/constants"
 	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
 	"k8s.io/klog/v2"
 )
 
@@ -28,6 +31,7 @@ func RegisterHelmReleaseRoutes(api *gin.RouterGroup) {
 	api.POST("/helm/release/batch/uninstall", ctrl.BatchUninstallRelease)
 	api.POST("/helm/release/upgrade", ctrl.UpgradeRelease)
 
+	service.ApprovalService().RegisterExecutor(constants.ApprovalActionHelmUninstall, uninstallApproved)
 }
 
 // @Summary 获取Release的历史版本
@@ -170,6 +174,15 @@ func (hr *ReleaseController) UninstallRelease(c *gin.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	submitted, err := submitUninstallApproval(c, ns, releaseName)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if submitted {
+		amis.WriteJsonOKMsg(c, "该操作需要审批，已提交审批申请")
+		return
+	}
 	h, err := getHelm(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -310,28 +323,39 @@ func (hr *ReleaseController) BatchUninstallRelease(c *gin.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	h, err := getHelm(c)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	result := &comm.BatchResult{Unit: "个Release"}
 	for i := 0; i < len(req.Names); i++ {
 		name := req.Names[i]
 		ns := req.Namespaces[i]
-		h, err := getHelm(c)
-		if err != nil {
-			amis.WriteJsonError(c, err)
-			return
-		}
 
 		// 检查权限
-		err = handleCommonLogic(c, "delete", name, ns, "")
-		if err != nil {
-			amis.WriteJsonError(c, err)
-			return
+		x := handleCommonLogic(c, "delete", name, ns, "")
+		submitted := false
+		if x == nil {
+			submitted, x = submitUninstallApproval(c, ns, name)
+		}
+		if x == nil && !submitted {
+			x = h.UninstallRelease(ns, name)
 		}
-		x := h.UninstallRelease(ns, name)
 		if x != nil {
 			klog.V(6).Infof("batch remove %s/%s error %v", ns, name, x)
-			err = x
 		}
+		result.Add(fmt.Sprintf("%s/%s", ns, name), submitted, x)
 	}
 
+	if err = result.Err(); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if msg := result.Message(); msg != "" {
+		amis.WriteJsonOKMsg(c, msg)
+		return
+	}
 	amis.WriteJsonOK(c)
 }
 
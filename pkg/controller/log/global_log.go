@@ -15,6 +15,7 @@ import (
 	"github.com/weibaohui/kom/kom"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 )
 
 // GlobalLogEntry 全局日志条目
@@ -145,10 +146,12 @@ func (lc *Controller) queryPodLogs(ctx context.Context, cluster, namespace, node
 		q = q.Namespace(namespace)
 	}
 
-	listOpt := metav1.ListOptions{FieldSelector: fmt.Sprintf("metadata.name=%s", podName)}
+	// 查询参数经 fields 转义后拼接为字段选择器，避免通过参数注入其他筛选条件
+	selector := fields.OneTermEqualSelector("metadata.name", podName)
 	if nodeName != "" {
-		listOpt.FieldSelector += fmt.Sprintf(",spec.nodeName=%s", nodeName)
+		selector = fields.AndSelectors(selector, fields.OneTermEqualSelector("spec.nodeName", nodeName))
 	}
+	listOpt := metav1.ListOptions{FieldSelector: selector.String()}
 
 	if err := q.List(&pods, listOpt).Error; err != nil {
 		return nil, fmt.Errorf("failed to list pods: %v", err)
@@ -183,7 +186,7 @@ func (lc *Controller) queryFilteredPodsLogs(ctx context.Context, cluster, namesp
 
 	listOpt := metav1.ListOptions{}
 	if nodeName != "" {
-		listOpt.FieldSelector = fmt.Sprintf("spec.nodeName=%s", nodeName)
+		listOpt.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
 	}
 
 	if err := q.List(&pods, listOpt).Error; err != nil {
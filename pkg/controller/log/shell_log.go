@@ -1,10 +1,13 @@
 package log
 
 import (
+	"fmt"
+
 	"github.com/gin-gonic/gin"
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
 	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
 	"gorm.io/gorm"
 )
 
@@ -14,6 +17,9 @@ func RegisterLogRoutes(mgm *gin.RouterGroup) {
 	ctrl := &Controller{}
 	mgm.GET("/log/shell/list", ctrl.ListShell)
 	mgm.GET("/log/operation/list", ctrl.ListOperation)
+	mgm.GET("/log/operation/id/:id/diff", ctrl.OperationDiff)
+	mgm.GET("/log/terminal/list", ctrl.ListTerminalSession)
+	mgm.GET("/log/terminal/id/:id/play", ctrl.PlayTerminalSession)
 	mgm.GET("/log/global/list", ctrl.ListGlobalLog)
 }
 
@@ -57,6 +63,11 @@ func (lc *Controller) ListOperation(c *gin.Context) {
 		queryFuncs = append(queryFuncs, queryFunc)
 	}
 
+	// 请求内容、变更差异较大，列表中不返回，通过差异查看接口获取
+	queryFuncs = append(queryFuncs, func(db *gorm.DB) *gorm.DB {
+		return db.Omit("params", "diff")
+	})
+
 	items, total, err := m.List(params, queryFuncs...)
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -64,3 +75,36 @@ func (lc *Controller) ListOperation(c *gin.Context) {
 	}
 	amis.WriteJsonListWithTotal(c, total, items)
 }
+
+// @Summary 操作日志变更差异
+// @Description 获取操作日志记录的请求内容，以及update/patch操作变更前后的差异（unified diff）；非平台管理员仅可查看自己的操作
+// @Security BearerAuth
+// @Param id path int true "操作日志ID"
+// @Success 200 {object} string
+// @Router /mgm/log/operation/id/{id}/diff [get]
+func (lc *Controller) OperationDiff(c *gin.Context) {
+	id := c.Param("id")
+	m := &models.OperationLog{}
+	item, err := m.GetOne(nil, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", id)
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	username := amis.GetLoginUser(c)
+	if item.UserName != username && !service.UserService().IsUserPlatformAdmin(username) {
+		amis.WriteJsonError(c, fmt.Errorf("无权查看该操作日志"))
+		return
+	}
+	amis.WriteJsonData(c, gin.H{
+		"id":       item.ID,
+		"action":   item.Action,
+		"cluster":  item.Cluster,
+		"kind":     item.Kind,
+		"name":     item.Name,
+		"username": item.UserName,
+		"params":   item.Params,
+		"diff":     item.Diff,
+	})
+}
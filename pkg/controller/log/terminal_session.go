@@ -0,0 +1,85 @@
+package log
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+)
+
+// @Summary 终端会话录像列表
+// @Description 按用户、集群、Pod等条件查询终端会话录像，非平台管理员仅可查看自己的会话
+// @Security BearerAuth
+// @Param user_name query string false "用户名"
+// @Param cluster query string false "集群"
+// @Param namespace query string false "命名空间"
+// @Param pod_name query string false "Pod名称"
+// @Success 200 {object} []models.TerminalSession
+// @Router /mgm/log/terminal/list [get]
+func (lc *Controller) ListTerminalSession(c *gin.Context) {
+	params := dao.BuildParams(c)
+	m := &models.TerminalSession{}
+
+	var queryFuncs []func(*gorm.DB) *gorm.DB
+	if queryFunc, ok := dao.BuildCreatedAtQuery(params); ok {
+		queryFuncs = append(queryFuncs, queryFunc)
+	}
+	username := amis.GetLoginUser(c)
+	if !service.UserService().IsUserPlatformAdmin(username) {
+		queryFuncs = append(queryFuncs, func(db *gorm.DB) *gorm.DB {
+			return db.Where("user_name = ?", username)
+		})
+	}
+
+	items, total, err := m.List(params, queryFuncs...)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 回放终端会话录像
+// @Description 返回 asciicast v2 格式的录像文件，可直接交给 asciinema-player 播放，支持Range请求；非平台管理员仅可回放自己的会话
+// @Security BearerAuth
+// @Param id path int true "终端会话ID"
+// @Produce application/x-asciicast
+// @Success 200 {string} string "asciicast v2 录像内容"
+// @Router /mgm/log/terminal/id/{id}/play [get]
+func (lc *Controller) PlayTerminalSession(c *gin.Context) {
+	id := c.Param("id")
+	m := &models.TerminalSession{}
+	item, err := m.GetOne(nil, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", id)
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	username := amis.GetLoginUser(c)
+	if item.UserName != username && !service.UserService().IsUserPlatformAdmin(username) {
+		amis.WriteJsonError(c, fmt.Errorf("无权回放该终端会话"))
+		return
+	}
+	file, err := service.TerminalSessionService().Open(item)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	defer file.Close()
+
+	modTime := time.Now()
+	if info, statErr := file.Stat(); statErr == nil {
+		modTime = info.ModTime()
+	}
+	name := fmt.Sprintf("terminal-session-%d.cast", item.ID)
+	c.Header("Content-Type", "application/x-asciicast")
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", name))
+	http.ServeContent(c.Writer, c.Request, name, modTime, file)
+}
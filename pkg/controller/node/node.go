@@ -1,8 +1,15 @@
 package node
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/pkg/comm"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
 	"github.com/weibaohui/kom/kom"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
@@ -18,6 +25,11 @@ func RegisterActionRoutes(api *gin.RouterGroup) {
 	api.POST("/node/batch/drain", ctrl.BatchDrain)
 	api.POST("/node/batch/cordon", ctrl.BatchCordon)
 	api.POST("/node/batch/uncordon", ctrl.BatchUnCordon)
+
+	// 审批通过后，以申请人身份重放驱逐操作
+	service.ApprovalService().RegisterExecutor(constants.ApprovalActionNodeDrain, func(ctx context.Context, req *models.ApprovalRequest) (string, error) {
+		return "", ctrl.drain(ctx, req.Cluster, req.Name)
+	})
 }
 
 // @Summary 驱逐指定节点
@@ -35,11 +47,36 @@ func (nc *ActionController) Drain(c *gin.Context) {
 		return
 	}
 
-	err = kom.Cluster(selectedCluster).WithContext(ctx).Resource(&v1.Node{}).Name(name).
-		Ctl().Node().Drain()
+	submitted, err := nc.submitDrainApproval(ctx, selectedCluster, name)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if submitted {
+		amis.WriteJsonOKMsg(c, "该操作需要审批，已提交审批申请")
+		return
+	}
+
+	err = nc.drain(ctx, selectedCluster, name)
 	amis.WriteJsonErrorOrOK(c, err)
 }
 
+func (nc *ActionController) drain(ctx context.Context, selectedCluster, name string) error {
+	return kom.Cluster(selectedCluster).WithContext(ctx).Resource(&v1.Node{}).Name(name).
+		Ctl().Node().Drain()
+}
+
+// submitDrainApproval 驱逐命中审批策略时，提交审批申请
+func (nc *ActionController) submitDrainApproval(ctx context.Context, selectedCluster, name string) (bool, error) {
+	return comm.SubmitApprovalIfRequired(ctx, &models.ApprovalRequest{
+		Cluster: selectedCluster,
+		Version: "v1",
+		Kind:    "Node",
+		Name:    name,
+		Action:  constants.ApprovalActionNodeDrain,
+	}, "update")
+}
+
 // @Summary 隔离指定节点
 // @Security BearerAuth
 // @Param cluster query string true "集群名称"
@@ -102,20 +139,27 @@ func (nc *ActionController) BatchDrain(c *gin.Context) {
 		return
 	}
 
+	result := &comm.BatchResult{Unit: "个节点"}
 	for i := 0; i < len(req.Names); i++ {
 		name := req.Names[i]
-		x := kom.Cluster(selectedCluster).WithContext(ctx).Resource(&v1.Node{}).Name(name).
-			Ctl().Node().Drain()
+		submitted, x := nc.submitDrainApproval(ctx, selectedCluster, name)
+		if x == nil && !submitted {
+			x = nc.drain(ctx, selectedCluster, name)
+		}
 		if x != nil {
 			klog.V(6).Infof("批量驱逐节点错误 %s %v", name, x)
-			err = x
 		}
+		result.Add(name, submitted, x)
 	}
 
-	if err != nil {
+	if err = result.Err(); err != nil {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	if msg := result.Message(); msg != "" {
+		amis.WriteJsonOKMsg(c, msg)
+		return
+	}
 	amis.WriteJsonOK(c)
 }
 
@@ -54,6 +54,7 @@ func (nc *ShellController) CreateNodeShell(c *gin.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	service.TerminalSessionService().RegisterNodeShell(selectedCluster, ns, podName, name)
 
 	var p *v1.Pod
 	err = kom.Cluster(selectedCluster).WithContext(ctx).Resource(&v1.Pod{}).Name(podName).Namespace(ns).Get(&p).Error
@@ -122,6 +122,7 @@ func cmdLogger(c *gin.Context, cmd string) {
 // Xterm 通过 WebSocket 提供与 Kubernetes Pod 容器的交互式终端会话。
 // 支持 xterm.js 前端，处理终端输入输出、窗口大小调整、命令日志记录和连接保活。
 // 会话结束后可根据参数选择性删除目标 Pod。
+// 配置了录像目录时，以 asciicast v2 格式录制会话的输入、输出及窗口大小调整。
 func (xc *XtermController) Xterm(c *gin.Context) {
 	removeAfterExec := c.Query("remove")
 	ns := c.Param("ns")
@@ -196,6 +197,38 @@ func (xc *XtermController) Xterm(c *gin.Context) {
 		return conn.WriteMessage(messageType, data)
 	}
 
+	username := amis.GetLoginUser(c)
+	// 节点终端的节点名称取自服务端创建节点终端时的登记，不信任客户端参数
+	nodeName := service.TerminalSessionService().NodeShellNode(selectedCluster, ns, podName)
+
+	// 录制终端会话
+	recording := service.TerminalSessionService().Start(&models.TerminalSession{
+		UserName:      username,
+		Cluster:       selectedCluster,
+		Namespace:     ns,
+		PodName:       podName,
+		ContainerName: containerName,
+		NodeName:      nodeName,
+	})
+	defer recording.Finish()
+
+	// 登记进行中的会话，供平台管理员实时观察及强制终止
+	live := service.TerminalSessionService().StartLive(xterm.LiveSessionInfo{
+		UserName:      username,
+		Cluster:       selectedCluster,
+		Namespace:     ns,
+		PodName:       podName,
+		ContainerName: containerName,
+		NodeName:      nodeName,
+	}, func(reason string) {
+		notice := []byte("\r\n" + reason + "\r\n")
+		recording.Output(notice)
+		_ = safeWriteMessage(websocket.BinaryMessage, notice)
+		cancel()
+		_ = conn.Close()
+	})
+	defer service.TerminalSessionService().EndLive(live)
+
 	// 创建 TTY 终端大小管理队列
 	sizeQueue := &TerminalSizeQueue{}
 
@@ -258,6 +291,8 @@ func (xc *XtermController) Xterm(c *gin.Context) {
 				data := outBuffer.Bytes()
 				outBuffer.Reset()
 				klog.V(6).Infof("Received stdout (%d bytes): %q", len(data), string(data))
+				recording.Output(data)
+				live.Broadcast(data)
 
 				if err := safeWriteMessage(websocket.BinaryMessage, data); err != nil {
 					klog.V(6).Infof("Failed to send stderr message   to xterm.js: %v", err)
@@ -273,6 +308,8 @@ func (xc *XtermController) Xterm(c *gin.Context) {
 				data := errBuffer.Bytes()
 				errBuffer.Reset()
 				klog.V(6).Infof("Received stderr (%d bytes): %q", len(data), string(data))
+				recording.Output(data)
+				live.Broadcast(data)
 				if err := safeWriteMessage(websocket.BinaryMessage, data); err != nil {
 					klog.V(6).Infof("Failed to send stderr message   to xterm.js: %v", err)
 					errorCounter++
@@ -326,6 +363,7 @@ func (xc *XtermController) Xterm(c *gin.Context) {
 					klog.V(6).Infof("resizing tty to use %v rows and %v columns...", ttySize.Rows, ttySize.Cols)
 
 					sizeQueue.Push(ttySize.Cols, ttySize.Rows)
+					recording.Resize(ttySize.Cols, ttySize.Rows)
 					continue
 				}
 			}
@@ -337,6 +375,7 @@ func (xc *XtermController) Xterm(c *gin.Context) {
 				klog.V(6).Infof("failed to write %d bytes to tty: %v", len(dataBuffer), err)
 				continue
 			}
+			recording.Input(data)
 
 			// 使用互斥锁保护 cmdBuffer 的读写操作
 			cmdBufferMutex.Lock()
@@ -384,7 +423,16 @@ func (xc *XtermController) Xterm(c *gin.Context) {
 		conn.Close()
 	}()
 
-	waiter.Wait()
+	// 会话被强制终止或超时后上下文取消，不再等待读写协程
+	waitDone := make(chan struct{})
+	go func() {
+		waiter.Wait()
+		close(waitDone)
+	}()
+	select {
+	case <-waitDone:
+	case <-ctx.Done():
+	}
 	klog.V(6).Infof("closing conn...")
 	connectionClosed = true
 	cleanupOnce.Do(cleanup)
@@ -104,6 +104,10 @@ func WriteWebSocketChatCompletionStream(c *gin.Context, stream *openai.ChatCompl
 			// 处理其他错误
 			continue
 		}
+		// 最后一个仅含用量的分片没有 choice
+		if len(response.Choices) == 0 {
+			continue
+		}
 
 		// 发送数据给客户端
 		conn.WriteJSON(gin.H{
@@ -135,6 +139,9 @@ func WriteSSEChatCompletionStream(c *gin.Context, stream *openai.ChatCompletionS
 			// 处理其他错误
 			continue
 		}
+		if len(response.Choices) == 0 {
+			continue
+		}
 		// 发送 SSE 消息
 		c.SSEvent("message", response.Choices[0].Delta.Content)
 		// 刷新输出缓冲区
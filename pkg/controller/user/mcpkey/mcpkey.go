@@ -1,6 +1,7 @@
 package mcpkey
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,10 +16,41 @@ import (
 
 type Controller struct{}
 
+// mcpKeyRequest 创建、修改MCP密钥的请求参数
+type mcpKeyRequest struct {
+	Description string     `json:"description"`
+	ToolMode    string     `json:"tool_mode"`
+	Tools       string     `json:"tools"`
+	ReadOnly    bool       `json:"read_only"`
+	Clusters    string     `json:"clusters"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+func (r *mcpKeyRequest) validate() error {
+	switch r.ToolMode {
+	case "", models.McpKeyToolModeAllow, models.McpKeyToolModeDeny:
+	default:
+		return fmt.Errorf("工具过滤模式无效: %s", r.ToolMode)
+	}
+	if r.ExpiresAt != nil && r.ExpiresAt.IsZero() {
+		r.ExpiresAt = nil
+	}
+	return nil
+}
+
+func (r *mcpKeyRequest) applyTo(k *models.McpKey) {
+	k.ToolMode = r.ToolMode
+	k.Tools = r.Tools
+	k.ReadOnly = r.ReadOnly
+	k.Clusters = r.Clusters
+	k.ExpiresAt = r.ExpiresAt
+}
+
 func RegisterMCPKeysRoutes(mgm *gin.RouterGroup) {
 	ctrl := &Controller{}
 	mgm.GET("/user/profile/mcp_keys/list", ctrl.List)
 	mgm.POST("/user/profile/mcp_keys/create", ctrl.Create)
+	mgm.POST("/user/profile/mcp_keys/update/:id", ctrl.Update)
 	mgm.POST("/user/profile/mcp_keys/delete/:id", ctrl.Delete)
 }
 
@@ -29,23 +61,39 @@ func RegisterMCPKeysRoutes(mgm *gin.RouterGroup) {
 // @Description 为当前用户创建一个新的MCP密钥（10年有效期）
 // @Security BearerAuth
 // @Param description body string false "密钥描述"
+// @Param tool_mode body string false "工具过滤模式：allow/deny，空为不限制"
+// @Param tools body string false "工具名称，逗号分隔"
+// @Param read_only body bool false "是否只读"
+// @Param clusters body string false "允许访问的集群，逗号分隔，空为不限制"
+// @Param expires_at body string false "过期时间，空为永不过期"
 // @Success 200 {object} string "操作成功"
 // @Router /mgm/user/profile/mcp_keys/create [post]
 func (mc *Controller) Create(c *gin.Context) {
 	params := dao.BuildParams(c)
 
-	var req struct {
-		Description string `json:"description"`
-	}
+	var req mcpKeyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	if err := req.validate(); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
 
 	// 从JWT中获取用户信息
 	username := c.GetString(constants.JwtUserName)
 
-	jwt, err := service.UserService().GenerateJWTTokenOnlyUserName(username, time.Hour*24*365*10)
+	// 未设置过期时间的密钥令牌有效期为10年
+	ttl := time.Hour * 24 * 365 * 10
+	if req.ExpiresAt != nil {
+		ttl = time.Until(*req.ExpiresAt)
+		if ttl <= 0 {
+			amis.WriteJsonError(c, fmt.Errorf("过期时间必须晚于当前时间"))
+			return
+		}
+	}
+	jwt, err := service.UserService().GenerateJWTTokenOnlyUserName(username, ttl)
 	if err != nil {
 		amis.WriteJsonError(c, err)
 		return
@@ -58,6 +106,7 @@ func (mc *Controller) Create(c *gin.Context) {
 		Jwt:         jwt,
 		Description: req.Description,
 	}
+	req.applyTo(mcpKey)
 
 	// 保存到数据库
 	if err := mcpKey.Save(params); err != nil {
@@ -68,6 +117,41 @@ func (mc *Controller) Create(c *gin.Context) {
 	amis.WriteJsonOK(c)
 }
 
+// Update 修改MCP密钥的描述及使用限制
+// @Summary 修改MCP密钥
+// @Description 修改当前用户MCP密钥的描述、工具名单、只读、集群范围及过期时间
+// @Security BearerAuth
+// @Param id path string true "MCP密钥ID"
+// @Success 200 {object} string "操作成功"
+// @Router /mgm/user/profile/mcp_keys/update/{id} [post]
+func (mc *Controller) Update(c *gin.Context) {
+	username := c.GetString(constants.JwtUserName)
+
+	var req mcpKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	mcpKey := &models.McpKey{}
+	if err := dao.DB().Where("id = ? AND username = ?", c.Param("id"), username).First(mcpKey).Error; err != nil {
+		amis.WriteJsonError(c, fmt.Errorf("MCP密钥不存在"))
+		return
+	}
+	mcpKey.Description = req.Description
+	req.applyTo(mcpKey)
+	err := dao.DB().Model(mcpKey).Select("description", "tool_mode", "tools", "read_only", "clusters", "expires_at").Updates(mcpKey).Error
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
+
 // List 获取MCP密钥列表
 // @Summary 获取MCP密钥列表
 // @Description 获取当前用户的所有MCP密钥
@@ -0,0 +1,93 @@
+package profile
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+)
+
+// AccessRequest 临时授权申请请求结构体
+type AccessRequest struct {
+	Cluster    string `json:"cluster" binding:"required"` // 集群ID
+	Role       string `json:"role" binding:"required"`    // 申请的集群角色
+	Namespaces string `json:"namespaces"`                 // 命名空间，逗号分割，为空表示不限制
+	Hours      int    `json:"hours" binding:"required"`   // 申请时长（小时）
+	Reason     string `json:"reason" binding:"required"`  // 申请理由
+}
+
+// CreateAccessRequest 申请临时集群授权
+// @Summary 申请临时集群授权
+// @Description 自助申请在指定集群上临时提升权限，由平台管理员审批，到期后自动回收
+// @Security BearerAuth
+// @Param request body AccessRequest true "临时授权申请"
+// @Success 200 {object} string "操作成功"
+// @Router /mgm/user/profile/cluster/access_request/create [post]
+func (uc *Controller) CreateAccessRequest(c *gin.Context) {
+	params := dao.BuildParams(c)
+	var req AccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if req.Hours <= 0 || req.Hours > service.MaxAccessRequestHours {
+		amis.WriteJsonError(c, fmt.Errorf("申请时长须在1到%d小时之间", service.MaxAccessRequestHours))
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		amis.WriteJsonError(c, fmt.Errorf("请填写申请理由"))
+		return
+	}
+	if !constants.IsBuiltinClusterRole(req.Role) {
+		if _, err := service.CustomRoleService().GetByName(req.Role); err != nil {
+			amis.WriteJsonError(c, fmt.Errorf("角色[%s]不存在", req.Role))
+			return
+		}
+	}
+	if service.ClusterService().GetClusterByID(req.Cluster) == nil {
+		amis.WriteJsonError(c, fmt.Errorf("集群[%s]不存在", req.Cluster))
+		return
+	}
+
+	m := &models.ClusterAccessRequest{
+		Username:   params.UserName,
+		Cluster:    req.Cluster,
+		Role:       req.Role,
+		Namespaces: req.Namespaces,
+		Hours:      req.Hours,
+		Reason:     req.Reason,
+		Status:     models.AccessRequestStatusPending,
+	}
+	params.UserName = ""
+	if err := m.Save(params); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
+
+// ListAccessRequests 列出当前用户的临时授权申请
+// @Summary 获取我的临时授权申请
+// @Security BearerAuth
+// @Success 200 {object} []models.ClusterAccessRequest
+// @Router /mgm/user/profile/cluster/access_request/list [get]
+func (uc *Controller) ListAccessRequests(c *gin.Context) {
+	params := dao.BuildParams(c)
+	username := params.UserName
+	params.UserName = ""
+	m := &models.ClusterAccessRequest{}
+	items, total, err := m.List(params, func(db *gorm.DB) *gorm.DB {
+		return db.Where("username = ?", username)
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
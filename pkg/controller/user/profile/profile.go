@@ -19,6 +19,9 @@ func RegisterProfileRoutes(mgm *gin.RouterGroup) {
 	ctrl := &Controller{}
 	mgm.GET("/user/profile", ctrl.Profile)
 	mgm.GET("/user/profile/cluster/permissions/list", ctrl.ListUserPermissions)
+	// 临时集群授权自助申请
+	mgm.POST("/user/profile/cluster/access_request/create", ctrl.CreateAccessRequest)
+	mgm.GET("/user/profile/cluster/access_request/list", ctrl.ListAccessRequests)
 	mgm.POST("/user/profile/update_psw", ctrl.UpdatePsw)
 	// user profile 2FA 用户自助操作
 	mgm.POST("/user/profile/2fa/generate", ctrl.Generate2FASecret)
@@ -25,6 +25,10 @@ type Config struct {
 	ApiKey               string  // OPENAI_API_KEY
 	ApiURL               string  // OPENAI_API_URL
 	ApiModel             string  // OPENAI_MODEL
+	ApiProvider          string  // 大模型厂商：openai、azure、anthropic、ollama，默认openai
+	ApiVersion           string  // Azure OpenAI 的 api-version
+	ApiDeployment        string  // Azure OpenAI 的部署名称
+	ApiModelConfigID     uint    // 默认模型对应的模型配置ID，使用内置模型或命令行参数配置时为 0
 	Debug                bool    // 调试模式，同步修改所有的debug模式
 	LogV                 int     // klog的日志级别klog.V(this)
 	InCluster            bool    // 是否集群内模式
@@ -96,6 +100,10 @@ type Config struct {
 	HeartbeatFailureThreshold   int // 心跳失败阈值
 	ReconnectMaxIntervalSeconds int // 重连最大间隔时间（秒）
 	MaxRetryAttempts            int // 最大重试次数，默认100次
+
+	SensitiveKeys string // 敏感字段关键字，逗号分割。无查看Secret权限时，命中关键字的ConfigMap key、环境变量值将被脱敏
+
+	TerminalRecordPath string // 终端会话录像（asciicast）保存目录，为空时不录制
 }
 
 func Init() *Config {
@@ -152,6 +160,7 @@ func (c *Config) InitFlags() {
 	defaultApiKey := getEnv("OPENAI_API_KEY", "")
 	defaultApiURL := getEnv("OPENAI_API_URL", "")
 	defaultModel := getEnv("OPENAI_MODEL", "Qwen/Qwen2.5-7B-Instruct")
+	defaultApiProvider := getEnv("OPENAI_PROVIDER", "openai")
 
 	// 默认登录方式为password
 	defaultLoginType := getEnv("LOGIN_TYPE", "password")
@@ -269,6 +278,9 @@ func (c *Config) InitFlags() {
 	pflag.StringVarP(&c.ApiKey, "chatgpt-key", "k", defaultApiKey, "大模型的自定义API Key")
 	pflag.StringVarP(&c.ApiURL, "chatgpt-url", "u", defaultApiURL, "大模型的自定义API URL")
 	pflag.StringVarP(&c.ApiModel, "chatgpt-model", "m", defaultModel, "大模型的自定义模型名称")
+	pflag.StringVar(&c.ApiProvider, "chatgpt-provider", defaultApiProvider, "大模型厂商: openai、azure、anthropic、ollama，默认openai")
+	pflag.StringVar(&c.ApiVersion, "chatgpt-api-version", getEnv("OPENAI_API_VERSION", ""), "Azure OpenAI 的 api-version")
+	pflag.StringVar(&c.ApiDeployment, "chatgpt-deployment", getEnv("OPENAI_DEPLOYMENT", ""), "Azure OpenAI 的部署名称")
 
 	// 数据库配置
 	pflag.StringVar(&c.DBDriver, "db-driver", getEnv("DB_DRIVER", "sqlite"), "数据库驱动类型: sqlite、mysql、postgresql等")
@@ -306,6 +318,12 @@ func (c *Config) InitFlags() {
 	pflag.IntVar(&c.ReconnectMaxIntervalSeconds, "reconnect-max-interval", getEnvAsInt("RECONNECT_MAX_INTERVAL", 3600), "重连最大间隔时间（秒），默认3600秒")
 	pflag.IntVar(&c.MaxRetryAttempts, "max-retry-attempts", getEnvAsInt("MAX_RETRY_ATTEMPTS", 100), "最大重试次数，默认100次")
 
+	// 敏感数据脱敏
+	pflag.StringVar(&c.SensitiveKeys, "sensitive-keys", getEnv("SENSITIVE_KEYS", "password,passwd,secret,token,apikey,api_key,access_key,private_key,credential"), "敏感字段关键字，逗号分割。无查看Secret权限时，命中关键字的ConfigMap key、环境变量值将被脱敏")
+
+	// 终端会话录像
+	pflag.StringVar(&c.TerminalRecordPath, "terminal-record-path", getEnv("TERMINAL_RECORD_PATH", "./data/recordings"), "终端会话录像（asciicast v2）保存目录，为空时不录制")
+
 	// 其他配置-打印配置信息
 	pflag.BoolVar(&c.PrintConfig, "print-config", defaultPrintConfig, "是否打印配置信息，默认关闭")
 
@@ -1,7 +1,6 @@
 package lua
 
 import (
-	"encoding/json"
 	"reflect"
 
 	"github.com/weibaohui/kom/kom"
@@ -10,19 +9,6 @@ import (
 	"k8s.io/klog/v2"
 )
 
-// 增加 log 方法，支持 Lua 调用 log(obj)，自动使用 json.Marshal 格式化输出
-func logFunc(L *lua.LState) int {
-	value := L.CheckAny(1)
-	goValue := lValueToGoValue(value)
-	data, err := json.MarshalIndent(goValue, "", "  ")
-	if err != nil {
-		klog.V(6).Infof("[log] json.Marshal error:%v", err)
-	} else {
-		klog.V(6).Infof("[log] %s", string(data))
-	}
-	return 0
-}
-
 // Lua LValue 转 Go any，递归处理 table
 func lValueToGoValue(val lua.LValue) any {
 	switch v := val.(type) {
@@ -5,8 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/weibaohui/k8m/internal/dao"
@@ -20,37 +20,37 @@ import (
 )
 
 type Inspection struct {
-	Cluster  string // 集群名称
-	lua      *lua.LState
-	Schedule *models.InspectionSchedule // 巡检计划ID
+	Cluster   string                     // 集群名称
+	Schedule  *models.InspectionSchedule // 巡检计划ID
+	Semaphore chan struct{}              // 并发令牌，同一次计划执行的多个集群共用，为空时按计划并发数新建
 }
 
 func NewLuaInspection(schedule *models.InspectionSchedule, cluster string) *Inspection {
-	instance := &Inspection{
+	return &Inspection{
 		Cluster:  cluster,
 		Schedule: schedule,
-		lua:      lua.NewState(),
 	}
-	instance.registerKubectlFunc()
-	return instance
 }
 
+// newLState 为单个脚本创建独立的 Lua 状态，print/log 输出写入 out，脚本之间互不干扰，可并发执行
 // 调用方法可参考pkg/models/lua_scripts_builtin.go中的示例
-func (p *Inspection) registerKubectlFunc() {
-	p.lua.SetGlobal("log", p.lua.NewFunction(logFunc))
+func (p *Inspection) newLState(out *outputBuffer) *lua.LState {
+	L := lua.NewState()
+	L.SetGlobal("print", L.NewFunction(printFunc(out)))
+	L.SetGlobal("log", L.NewFunction(captureLogFunc(out)))
 
 	k := kom.Cluster(p.Cluster)
 	if k == nil {
 		klog.Errorf("巡检 集群【%s】，但是该集群未连接，巡检结果为失败", p.Cluster)
 	}
 
-	ud := p.lua.NewUserData()
+	ud := L.NewUserData()
 	ud.Value = &Kubectl{k}
-	p.lua.SetGlobal("kubectl", ud)
+	L.SetGlobal("kubectl", ud)
 
 	// 设置元方法
-	mt := p.lua.NewTypeMetatable("kubectl")
-	p.lua.SetField(mt, "__index", p.lua.SetFuncs(p.lua.NewTable(), map[string]lua.LGFunction{
+	mt := L.NewTypeMetatable("kubectl")
+	L.SetField(mt, "__index", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
 		"GVK":                 gvkFunc, // kubectl.GVK(group, version, kind) Kind首字母大写
 		"WithLabelSelector":   withLabelSelectorFunc,
 		"Name":                withNameFunc,
@@ -63,21 +63,19 @@ func (p *Inspection) registerKubectlFunc() {
 		"GetLogs":             getLogs,
 		"GetPodResourceUsage": getPodResourceUsage,
 	}))
-	p.lua.SetMetatable(ud, mt)
+	L.SetMetatable(ud, mt)
+	return L
 }
-func (p *Inspection) Start() []CheckResult {
-	// 初始化 Lua 状态
-	defer p.lua.Close()
 
+// Start 按计划的并发数并行执行所有检查脚本，结果顺序与脚本顺序一致
+// 并发令牌由 Semaphore 提供，多个集群共用时同时运行的 Lua 状态总数不超过计划并发数
+func (p *Inspection) Start() []CheckResult {
 	params := &dao.Params{
 		PerPage: 10000000,
 	}
 
 	klog.V(6).Infof("p.Schedule.ScriptCodes: %v", utils.ToJSON(p.Schedule.ScriptCodes))
 
-	// 执行所有 Lua 检查脚本并收集结果
-	var results []CheckResult
-
 	// 从数据库读取内置检查脚本
 	script := models.InspectionLuaScript{}
 
@@ -85,33 +83,37 @@ func (p *Inspection) Start() []CheckResult {
 		return db.Where("script_code in ?", strings.Split(p.Schedule.ScriptCodes, ","))
 	})
 	if err != nil {
-		fmt.Println("无法从数据库读取检查脚本:", err)
-		return results
+		klog.Errorf("无法从数据库读取检查脚本: %v", err)
+		return nil
+	}
+
+	// 执行所有 Lua 检查脚本并收集结果
+	results := make([]CheckResult, len(list))
+	semaphore := p.Semaphore
+	if semaphore == nil {
+		semaphore = make(chan struct{}, p.Schedule.GetConcurrency())
 	}
-	for _, item := range list {
-		result := p.runLuaCheck(item)
-		results = append(results, result)
+	var wg sync.WaitGroup
+	for i, item := range list {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, item *models.InspectionLuaScript) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			results[i] = p.runLuaCheck(item)
+		}(i, item)
 	}
+	wg.Wait()
 
-	// 打印所有检测结果
 	for _, res := range results {
-		fmt.Printf("\n--- 检查: %s ---\n", res.Name)
-		fmt.Printf("开始时间: %s\n结束时间: %s\n", res.StartTime.Format(time.RFC3339), res.EndTime.Format(time.RFC3339))
-		if res.LuaRunError != nil {
-			fmt.Printf("错误: %v\n", res.LuaRunError)
-		}
-		fmt.Printf("输出:\n%s\n", res.LuaRunOutput)
-		if len(res.Events) > 0 {
-			fmt.Println("结构化检测失败事件:")
-			for _, evt := range res.Events {
-				if evt.Status != string(constants.LuaEventStatusNormal) {
-					b, _ := json.Marshal(evt)
-					fmt.Println(string(b))
-				}
+		failed := 0
+		for _, evt := range res.Events {
+			if evt.Status != string(constants.LuaEventStatusNormal) {
+				failed++
 			}
-		} else {
-			fmt.Println("无结构化检测事件（未调用 check_event）")
 		}
+		klog.V(6).Infof("巡检 集群【%s】检查[%s] 耗时 %s，事件 %d 个，失败 %d 个，错误: %v",
+			p.Cluster, res.Name, res.EndTime.Sub(res.StartTime), len(res.Events), failed, res.LuaRunError)
 	}
 
 	return results
@@ -119,14 +121,12 @@ func (p *Inspection) Start() []CheckResult {
 
 // runLuaCheck 执行单个Lua脚本检查，支持超时控制和脚本中断
 func (p *Inspection) runLuaCheck(item *models.InspectionLuaScript) CheckResult {
-	var buf bytes.Buffer
-	origStdout := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
-	defer func() { os.Stdout = origStdout }()
+	out := &outputBuffer{}
+	events := &eventCollector{}
 
-	var events []CheckEvent
-	p.registerCheckEvent(&events, item)
+	// Lua 状态由执行脚本的 goroutine 独占，执行结束后由其关闭
+	L := p.newLState(out)
+	p.registerCheckEvent(L, events, item)
 
 	// 获取超时时间，如果未设置或为0，则使用默认60秒
 	timeoutSeconds := item.TimeoutSeconds
@@ -136,22 +136,23 @@ func (p *Inspection) runLuaCheck(item *models.InspectionLuaScript) CheckResult {
 	timeout := time.Duration(timeoutSeconds) * time.Second
 
 	start := time.Now()
-	
+
 	// 创建可取消的上下文
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
-	
+
 	// 为 Lua 状态设置上下文，使其能够响应取消信号
-	p.lua.SetContext(ctx)
-	
+	L.SetContext(ctx)
+
 	// 使用channel来处理超时
 	type result struct {
 		err error
 	}
 	resultChan := make(chan result, 1)
-	
+
 	// 在goroutine中执行Lua脚本
 	go func() {
+		defer L.Close()
 		defer func() {
 			// 捕获可能的 panic，防止程序崩溃
 			if r := recover(); r != nil {
@@ -159,8 +160,8 @@ func (p *Inspection) runLuaCheck(item *models.InspectionLuaScript) CheckResult {
 				resultChan <- result{err: fmt.Errorf("脚本执行发生panic: %v", r)}
 			}
 		}()
-		
-		err := p.lua.DoString(item.Script)
+
+		err := L.DoString(item.Script)
 		resultChan <- result{err: err}
 	}()
 
@@ -180,27 +181,84 @@ func (p *Inspection) runLuaCheck(item *models.InspectionLuaScript) CheckResult {
 		}
 	}
 
-	_ = w.Close()
-	os.Stdout = origStdout
-
-	_, _ = buf.ReadFrom(r)
-	_ = r.Close() // 读取完立即关闭
-	output := buf.String()
 	end := time.Now()
 
 	return CheckResult{
 		Name:         item.Name,
 		StartTime:    start,
 		EndTime:      end,
-		LuaRunOutput: output,
+		LuaRunOutput: out.String(),
 		LuaRunError:  err,
-		Events:       events,
+		Events:       events.List(),
+	}
+}
+
+// outputBuffer 单个脚本的输出缓冲，超时后脚本可能仍在写入，读写需加锁
+type outputBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *outputBuffer) WriteString(s string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.buf.WriteString(s)
+}
+
+func (o *outputBuffer) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
+
+// eventCollector 单个脚本上报的检查事件
+type eventCollector struct {
+	mu     sync.Mutex
+	events []CheckEvent
+}
+
+func (c *eventCollector) Add(e CheckEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e)
+}
+
+func (c *eventCollector) List() []CheckEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]CheckEvent(nil), c.events...)
+}
+
+// printFunc 替换 Lua 内置 print，与原生行为一致：参数以制表符分隔并换行，输出写入脚本自己的缓冲
+func printFunc(out *outputBuffer) lua.LGFunction {
+	return func(L *lua.LState) int {
+		top := L.GetTop()
+		parts := make([]string, 0, top)
+		for i := 1; i <= top; i++ {
+			parts = append(parts, L.ToStringMeta(L.Get(i)).String())
+		}
+		out.WriteString(strings.Join(parts, "\t") + "\n")
+		return 0
+	}
+}
+
+// captureLogFunc 实现 Lua 调用 log(obj)，使用 json.Marshal 格式化后写入日志及脚本输出
+func captureLogFunc(out *outputBuffer) lua.LGFunction {
+	return func(L *lua.LState) int {
+		data, err := json.MarshalIndent(lValueToGoValue(L.CheckAny(1)), "", "  ")
+		if err != nil {
+			klog.V(6).Infof("[log] json.Marshal error:%v", err)
+			return 0
+		}
+		klog.V(6).Infof("[log] %s", string(data))
+		out.WriteString(string(data) + "\n")
+		return 0
 	}
 }
 
 // 注册 check_event 到 Lua，自动补充上下文
-func (p *Inspection) registerCheckEvent(events *[]CheckEvent, item *models.InspectionLuaScript) {
-	p.lua.SetGlobal("check_event", p.lua.NewFunction(func(L *lua.LState) int {
+func (p *Inspection) registerCheckEvent(L *lua.LState, events *eventCollector, item *models.InspectionLuaScript) {
+	L.SetGlobal("check_event", L.NewFunction(func(L *lua.LState) int {
 		status := L.CheckString(1)
 		msg := L.CheckString(2)
 		var extra map[string]any
@@ -217,16 +275,56 @@ func (p *Inspection) registerCheckEvent(events *[]CheckEvent, item *models.Inspe
 		if v, ok := extra["namespace"]; ok {
 			namespace, _ = v.(string)
 		}
-		*events = append(*events, CheckEvent{
-			Name:       name,
-			Namespace:  namespace,
-			Status:     status,
-			Msg:        msg,
-			Extra:      extra,
-			ScriptName: item.Name,        // 检测脚本名称
-			Kind:       item.Kind,        // 检查的资源类型
-			CheckDesc:  item.Description, // 检查脚本内容描述
+		// 严重级别、分类、修复建议及文档链接默认取自脚本，extra 中同名字段可覆盖
+		severity := models.NormalizeSeverity(item.Severity, constants.LuaEventSeverityWarning)
+		if v, ok := extra["severity"].(string); ok {
+			severity = models.NormalizeSeverity(v, severity)
+		}
+		categories := utils.SplitAndTrim(item.Categories, ",")
+		if v, ok := extra["categories"]; ok {
+			categories = toStringList(v)
+		} else if v, ok := extra["category"]; ok {
+			categories = toStringList(v)
+		}
+		remediation := item.Remediation
+		if v, ok := extra["remediation"].(string); ok && v != "" {
+			remediation = v
+		}
+		docURL := item.DocURL
+		if v, ok := extra["doc_url"].(string); ok && v != "" {
+			docURL = v
+		}
+		events.Add(CheckEvent{
+			Name:        name,
+			Namespace:   namespace,
+			Status:      status,
+			Msg:         msg,
+			Extra:       extra,
+			ScriptName:  item.Name,        // 检测脚本名称
+			Kind:        item.Kind,        // 检查的资源类型
+			CheckDesc:   item.Description, // 检查脚本内容描述
+			Severity:    string(severity),
+			Categories:  categories,
+			Remediation: remediation,
+			DocURL:      docURL,
 		})
 		return 0
 	}))
 }
+
+// toStringList 将 Lua 传入的字符串（逗号分隔）或数组转换为字符串列表
+func toStringList(v any) []string {
+	switch val := v.(type) {
+	case string:
+		return utils.SplitAndTrim(val, ",")
+	case []any:
+		var list []string
+		for _, item := range val {
+			if str, ok := item.(string); ok && str != "" {
+				list = append(list, str)
+			}
+		}
+		return list
+	}
+	return nil
+}
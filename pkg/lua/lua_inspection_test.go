@@ -0,0 +1,57 @@
+package lua
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/weibaohui/k8m/pkg/models"
+)
+
+func TestRunLuaCheckCapturesOutputPerScript(t *testing.T) {
+	p := &Inspection{Cluster: "not-connected", Schedule: &models.InspectionSchedule{}}
+
+	const runs = 20
+	results := make([]CheckResult, runs)
+	var wg sync.WaitGroup
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = p.runLuaCheck(&models.InspectionLuaScript{
+				Name: fmt.Sprintf("script-%d", i),
+				Script: fmt.Sprintf(`
+for n = 1, 200 do
+  print("script-%d", n)
+end
+log({ script = "script-%d" })
+`, i, i),
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i, res := range results {
+		if res.LuaRunError != nil {
+			t.Fatalf("script-%d: unexpected error: %v", i, res.LuaRunError)
+		}
+		want := fmt.Sprintf("script-%d", i)
+		lines := strings.Split(strings.TrimSpace(res.LuaRunOutput), "\n")
+		printed := 0
+		for _, line := range lines {
+			if strings.HasPrefix(line, "script-") {
+				if !strings.HasPrefix(line, want+"\t") {
+					t.Fatalf("script-%d: output contains line from another script: %q", i, line)
+				}
+				printed++
+			}
+		}
+		if printed != 200 {
+			t.Errorf("script-%d: got %d printed lines, want 200", i, printed)
+		}
+		if !strings.Contains(res.LuaRunOutput, `"script": "`+want+`"`) {
+			t.Errorf("script-%d: log output missing, got %q", i, res.LuaRunOutput)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package lua
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"gorm.io/gorm"
+)
+
+// inspectionStatusSuccess 巡检记录执行成功的状态
+const inspectionStatusSuccess = "success"
+
+// PreviousRecord 获取同一巡检计划、同一集群中早于指定记录的最近一次执行成功的巡检记录，没有时返回 nil
+// 执行失败的记录中事件可能不完整，不作为比对基准
+func (s *ScheduleBackground) PreviousRecord(record *models.InspectionRecord) (*models.InspectionRecord, error) {
+	if record.ScheduleID == nil {
+		return nil, nil
+	}
+	var prev models.InspectionRecord
+	err := dao.DB().Where("schedule_id = ? AND cluster = ? AND id < ? AND status = ?", *record.ScheduleID, record.Cluster, record.ID, inspectionStatusSuccess).
+		Order("id desc").First(&prev).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &prev, nil
+}
+
+// DiffRecords 比对两次巡检记录的失败事件，baseID 为 0 时与同一计划、同一集群的上一次成功巡检比对
+// 任一记录中执行出错（如超时、API 不可达）的脚本不会产生事件，其事件不参与比对，避免误报为已恢复或新增
+func (s *ScheduleBackground) DiffRecords(baseID, recordID uint) (*RecordDiff, error) {
+	record := &models.InspectionRecord{}
+	if err := dao.DB().Where("id = ?", recordID).First(record).Error; err != nil {
+		return nil, fmt.Errorf("未找到对应的巡检记录: %d", recordID)
+	}
+
+	diff := &RecordDiff{RecordID: recordID, Cluster: record.Cluster}
+	if baseID == 0 {
+		prev, err := s.PreviousRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		if prev != nil {
+			baseID = prev.ID
+		}
+	}
+	diff.BaseRecordID = baseID
+
+	current, err := s.failedEvents(recordID)
+	if err != nil {
+		return nil, err
+	}
+	var base []*models.InspectionCheckEvent
+	recordIDs := []uint{recordID}
+	if baseID != 0 {
+		if base, err = s.failedEvents(baseID); err != nil {
+			return nil, err
+		}
+		recordIDs = append(recordIDs, baseID)
+	}
+	errored, err := s.erroredScripts(recordIDs)
+	if err != nil {
+		return nil, err
+	}
+	diff.Skipped = []string{}
+	skipped := map[string]bool{}
+	for _, names := range errored {
+		for name := range names {
+			if !skipped[name] {
+				skipped[name] = true
+				diff.Skipped = append(diff.Skipped, name)
+			}
+		}
+	}
+	withoutSkipped := func(events []*models.InspectionCheckEvent) []*models.InspectionCheckEvent {
+		var result []*models.InspectionCheckEvent
+		for _, e := range events {
+			if !skipped[e.ScriptName] {
+				result = append(result, e)
+			}
+		}
+		return result
+	}
+	diff.New, diff.Resolved, diff.Persisting = models.DiffCheckEvents(withoutSkipped(base), withoutSkipped(current))
+	return diff, nil
+}
+
+// erroredScripts 查询巡检记录中执行出错的脚本，记录ID -> 脚本名称集合
+func (s *ScheduleBackground) erroredScripts(recordIDs []uint) (map[uint]map[string]bool, error) {
+	var results []*models.InspectionScriptResult
+	err := dao.DB().Select("record_id", "script_name").
+		Where("record_id in ? AND error_msg <> ?", recordIDs, "").Find(&results).Error
+	if err != nil {
+		return nil, err
+	}
+	errored := map[uint]map[string]bool{}
+	for _, r := range results {
+		if errored[r.RecordID] == nil {
+			errored[r.RecordID] = map[string]bool{}
+		}
+		errored[r.RecordID][r.ScriptName] = true
+	}
+	return errored, nil
+}
+
+func (s *ScheduleBackground) failedEvents(recordID uint) ([]*models.InspectionCheckEvent, error) {
+	var events []*models.InspectionCheckEvent
+	err := dao.DB().Where("record_id = ? AND event_status = ?", recordID, constants.LuaEventStatusFailed).
+		Order("id asc").Find(&events).Error
+	return events, err
+}
+
+// RuleTrends 统计巡检计划在指定集群最近 runs 次巡检中每条规则的失败数，按时间先后排列
+func (s *ScheduleBackground) RuleTrends(scheduleID uint, cluster string, runs int) ([]*RuleTrend, error) {
+	if runs <= 0 {
+		runs = 10
+	}
+	var records []*models.InspectionRecord
+	err := dao.DB().Select("id", "start_time").
+		Where("schedule_id = ? AND cluster = ? AND status = ?", scheduleID, cluster, inspectionStatusSuccess).
+		Order("id desc").Limit(runs).Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return []*RuleTrend{}, nil
+	}
+	// 按时间先后排列
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	recordIDs := make([]uint, 0, len(records))
+	for _, r := range records {
+		recordIDs = append(recordIDs, r.ID)
+	}
+
+	errored, err := s.erroredScripts(recordIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	type row struct {
+		RecordID   uint
+		ScriptName string
+		Kind       string
+		Failed     int
+	}
+	var rows []row
+	err = dao.DB().Model(&models.InspectionCheckEvent{}).
+		Select("record_id, script_name, kind, count(*) as failed").
+		Where("record_id in ? AND event_status = ?", recordIDs, constants.LuaEventStatusFailed).
+		Group("record_id, script_name, kind").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// 规则 -> 记录 -> 失败数
+	failed := map[string]map[uint]int{}
+	kinds := map[string]string{}
+	var order []string
+	for _, r := range rows {
+		if _, ok := failed[r.ScriptName]; !ok {
+			failed[r.ScriptName] = map[uint]int{}
+			kinds[r.ScriptName] = r.Kind
+			order = append(order, r.ScriptName)
+		}
+		failed[r.ScriptName][r.RecordID] += r.Failed
+	}
+
+	trends := make([]*RuleTrend, 0, len(order))
+	for _, name := range order {
+		trend := &RuleTrend{ScriptName: name, Kind: kinds[name]}
+		for _, r := range records {
+			trend.Points = append(trend.Points, RuleTrendPoint{
+				RecordID:    r.ID,
+				RunTime:     r.StartTime,
+				FailedCount: failed[name][r.ID],
+				Errored:     errored[r.ID][name],
+			})
+		}
+		trend.Status = ruleTrendStatus(trend.Points)
+		trends = append(trends, trend)
+	}
+	return trends, nil
+}
+
+// ruleTrendStatus 根据最近两次脚本执行正常的巡检的失败数判断规则的变化
+func ruleTrendStatus(points []RuleTrendPoint) string {
+	var valid []int
+	for i := len(points) - 1; i >= 0 && len(valid) < 2; i-- {
+		if !points[i].Errored {
+			valid = append(valid, points[i].FailedCount)
+		}
+	}
+	if len(valid) == 0 {
+		return RuleTrendPassing
+	}
+	last, prev := valid[0], 0
+	if len(valid) > 1 {
+		prev = valid[1]
+	}
+	switch {
+	case last > 0 && prev == 0:
+		return RuleTrendNew
+	case last > 0:
+		return RuleTrendPersisting
+	case prev > 0:
+		return RuleTrendResolved
+	}
+	return RuleTrendPassing
+}
+
+// deltaPushContent 生成仅包含变化的推送内容，按计划的最低关注级别过滤，无新增及恢复的问题时 changed 为 false
+func (s *ScheduleBackground) deltaPushContent(recordID uint) (summary, raw string, changed bool, err error) {
+	record := &models.InspectionRecord{}
+	if err = dao.DB().Where("id = ?", recordID).First(record).Error; err != nil {
+		return "", "", false, fmt.Errorf("未找到对应的巡检记录: %d", recordID)
+	}
+	schedule := &models.InspectionSchedule{}
+	if record.ScheduleID != nil {
+		if err = dao.DB().Where("id = ?", *record.ScheduleID).First(schedule).Error; err != nil {
+			return "", "", false, fmt.Errorf("未找到对应的巡检计划: %v", err)
+		}
+	}
+
+	diff, err := s.DiffRecords(0, recordID)
+	if err != nil {
+		return "", "", false, err
+	}
+	filter := func(events []*models.InspectionCheckEvent) []*models.InspectionCheckEvent {
+		var result []*models.InspectionCheckEvent
+		for _, e := range events {
+			if schedule.MatchSeverity(e.Severity) {
+				result = append(result, e)
+			}
+		}
+		return result
+	}
+	diff.New = filter(diff.New)
+	diff.Resolved = filter(diff.Resolved)
+	diff.Persisting = filter(diff.Persisting)
+	if len(diff.New) == 0 && len(diff.Resolved) == 0 {
+		return "", "", false, nil
+	}
+
+	summary = fmt.Sprintf(`📊 巡检变化报告
+📋 巡检计划：%s
+☸️ 巡检集群：%s
+🆕 新增问题：%d
+✅ 已恢复：%d
+⏳ 持续存在：%d`, record.ScheduleName, record.Cluster, len(diff.New), len(diff.Resolved), len(diff.Persisting))
+	for _, e := range diff.New {
+		summary += fmt.Sprintf("\n🆕 [%s] %s", e.Severity, e.EventMsg)
+	}
+	for _, e := range diff.Resolved {
+		summary += fmt.Sprintf("\n✅ %s", e.EventMsg)
+	}
+
+	// 持续存在的问题只推送数量，不推送明细
+	diff.Persisting = nil
+	b, err := json.Marshal(diff)
+	if err != nil {
+		return "", "", false, err
+	}
+	return summary, string(b), true, nil
+}
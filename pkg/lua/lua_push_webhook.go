@@ -3,8 +3,10 @@ package lua
 import (
 	"fmt"
 
+	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/webhook"
+	"k8s.io/klog/v2"
 )
 
 // PushToHooksByRecordID 根据巡检记录ID发送webhook通知
@@ -19,13 +21,51 @@ func (s *ScheduleBackground) PushToHooksByRecordID(recordID uint) ([]*webhook.Se
 	if err != nil {
 		return nil, fmt.Errorf("查询webhooks失败: %v", err)
 	}
-	record := &models.InspectionRecord{}
-	summary, resultRaw, err := record.GetRecordBothContentById(recordID)
+	if len(receivers) == 0 {
+		return nil, nil
+	}
+
+	deltaOnly, err := s.pushDeltaOnly(recordID)
 	if err != nil {
-		return nil, fmt.Errorf("获取巡检记录id=%d的内容失败: %v", recordID, err)
+		return nil, err
+	}
+	var summary, resultRaw string
+	if deltaOnly {
+		// 仅推送与上次巡检相比的变化，无变化时不推送
+		var changed bool
+		summary, resultRaw, changed, err = s.deltaPushContent(recordID)
+		if err != nil {
+			return nil, fmt.Errorf("生成巡检记录id=%d的变化内容失败: %v", recordID, err)
+		}
+		if !changed {
+			klog.V(6).Infof("巡检记录 %d 与上次巡检相比无变化，跳过推送", recordID)
+			return nil, nil
+		}
+	} else {
+		record := &models.InspectionRecord{}
+		summary, resultRaw, err = record.GetRecordBothContentById(recordID)
+		if err != nil {
+			return nil, fmt.Errorf("获取巡检记录id=%d的内容失败: %v", recordID, err)
+		}
 	}
 
 	results := webhook.PushMsgToAllTargets(summary, resultRaw, receivers)
 
 	return results, nil
 }
+
+// pushDeltaOnly 巡检记录所属计划是否只推送变化
+func (s *ScheduleBackground) pushDeltaOnly(recordID uint) (bool, error) {
+	record := &models.InspectionRecord{}
+	if err := dao.DB().Select("schedule_id").Where("id = ?", recordID).First(record).Error; err != nil {
+		return false, fmt.Errorf("未找到对应的巡检记录: %d", recordID)
+	}
+	if record.ScheduleID == nil {
+		return false, nil
+	}
+	schedule := &models.InspectionSchedule{}
+	if err := dao.DB().Select("push_delta_only").Where("id = ?", *record.ScheduleID).First(schedule).Error; err != nil {
+		return false, fmt.Errorf("未找到对应的巡检计划: %v", err)
+	}
+	return schedule.PushDeltaOnly, nil
+}
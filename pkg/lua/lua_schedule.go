@@ -87,6 +87,11 @@ const TriggerTypeCron = "cron"
 // cluster: 目标集群
 // triggerType: 触发类型（manual/cron）
 func (s *ScheduleBackground) RunByCluster(ctx context.Context, scheduleID *uint, cluster string, triggerType string) (*models.InspectionRecord, error) {
+	return s.runByCluster(ctx, scheduleID, cluster, triggerType, nil)
+}
+
+// runByCluster 执行单个集群的巡检，semaphore 为同一次计划执行共用的并发令牌，为空时按计划并发数新建
+func (s *ScheduleBackground) runByCluster(ctx context.Context, scheduleID *uint, cluster string, triggerType string, semaphore chan struct{}) (*models.InspectionRecord, error) {
 	k := kom.Cluster(cluster)
 	if k == nil {
 		klog.V(6).Infof("巡检 集群【%s】，但是该集群未连接，尝试连接该集群", cluster)
@@ -131,6 +136,7 @@ func (s *ScheduleBackground) RunByCluster(ctx context.Context, scheduleID *uint,
 	// 使用defer确保无论如何都会更新记录状态
 	var finalStatus = "success"
 	var finalErrorCount int
+	var finalScore int
 	defer func() {
 		// 捕获panic并设置为失败状态
 		if r := recover(); r != nil {
@@ -143,11 +149,12 @@ func (s *ScheduleBackground) RunByCluster(ctx context.Context, scheduleID *uint,
 		record.Status = finalStatus
 		record.EndTime = &endTime
 		record.ErrorCount = finalErrorCount
+		record.Score = finalScore
 
 		// 强制保存状态，即使出错也要记录
 		// 使用选择性更新，避免覆盖AI总结字段
 		if saveErr := record.Save(nil, func(db *gorm.DB) *gorm.DB {
-			return db.Select("status", "end_time", "error_count")
+			return db.Select("status", "end_time", "error_count", "score")
 		}); saveErr != nil {
 			klog.Errorf("更新巡检记录状态失败，记录ID=%d, 错误: %v", record.ID, saveErr)
 		} else {
@@ -166,6 +173,7 @@ func (s *ScheduleBackground) RunByCluster(ctx context.Context, scheduleID *uint,
 
 	// 执行所有巡检脚本
 	inspection := NewLuaInspection(schedule, cluster)
+	inspection.Semaphore = semaphore
 	results := inspection.Start()
 
 	var scriptResults []*models.InspectionScriptResult
@@ -198,13 +206,21 @@ func (s *ScheduleBackground) RunByCluster(ctx context.Context, scheduleID *uint,
 				Namespace:   e.Namespace,
 				Name:        e.Name,
 				Cluster:     cluster,
+				Severity:    e.Severity,
+				Categories:  strings.Join(e.Categories, ","),
+				Remediation: e.Remediation,
+				DocURL:      e.DocURL,
 			}
 			if s.IsEventStatusPass(e.Status) {
 				ce.EventStatus = string(constants.LuaEventStatusNormal) // 统一状态描述为正常
 			} else {
-				errorCount += 1
-				finalErrorCount = errorCount // 同步更新finalErrorCount
 				ce.EventStatus = string(constants.LuaEventStatusFailed)
+				// 低于计划最低关注级别的失败事件照常记录，但不计入错误数和分数
+				if schedule.MatchSeverity(e.Severity) {
+					errorCount += 1
+					finalErrorCount = errorCount // 同步更新finalErrorCount
+					finalScore += schedule.SeverityWeight(e.Severity)
+				}
 			}
 			checkEvents = append(checkEvents, ce)
 
@@ -236,6 +252,40 @@ func (s *ScheduleBackground) RunByCluster(ctx context.Context, scheduleID *uint,
 	return record, nil
 }
 
+// RunByClusters 并行巡检多个集群，clusters 为逗号分隔的集群列表
+// 同时巡检的集群数不超过计划并发数，所有集群的脚本共用一组并发令牌，同时运行的 Lua 状态总数也不超过计划并发数
+// 收到取消信号后不再启动新的集群巡检，已开始的集群巡检执行完毕后返回
+func (s *ScheduleBackground) RunByClusters(ctx context.Context, scheduleID *uint, clusters string, triggerType string) {
+	concurrency := models.DefaultInspectionConcurrency
+	if scheduleID != nil {
+		schedule := &models.InspectionSchedule{}
+		schedule.ID = *scheduleID
+		if item, err := schedule.GetOne(nil); err == nil {
+			concurrency = item.GetConcurrency()
+		}
+	}
+
+	clusterSlots := make(chan struct{}, concurrency)
+	scriptSlots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, cluster := range utils.SplitAndTrim(clusters, ",") {
+		select {
+		case <-ctx.Done():
+			klog.V(6).Infof("巡检计划[%v] 被取消，停止后续集群", scheduleID)
+			wg.Wait()
+			return
+		case clusterSlots <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(cluster string) {
+			defer wg.Done()
+			defer func() { <-clusterSlots }()
+			_, _ = s.runByCluster(ctx, scheduleID, cluster, triggerType, scriptSlots)
+		}(cluster)
+	}
+	wg.Wait()
+}
+
 // IsEventStatusPass 判断事件状态是否为通过
 // 这里的通过状态包括：正常、pass、ok、success、通过
 // 入库前将状态描述文字统一为正常、失败两种
@@ -304,21 +354,7 @@ func (s *ScheduleBackground) Add(scheduleID uint) {
 		// 添加定时任务到TaskManager，而不是立即执行
 		addErr := localTaskManager.Add(fmt.Sprintf("%d", scheduleIDCopy), cronExpr, func(ctx context.Context) {
 			klog.V(6).Infof("定时巡检任务 [%s] 开始执行", item.Name)
-			// 执行巡检任务：逐项检查取消信号，并清洗 cluster 列表
-			clusters := strings.Split(clustersCopy, ",")
-			for _, cluster := range clusters {
-				select {
-				case <-ctx.Done():
-					klog.V(6).Infof("定时巡检任务 [%s] 被取消，停止后续集群", item.Name)
-					return
-				default:
-				}
-				cluster = strings.TrimSpace(cluster)
-				if cluster == "" {
-					continue
-				}
-				_, _ = s.RunByCluster(ctx, &scheduleIDCopy, cluster, TriggerTypeCron)
-			}
+			s.RunByClusters(ctx, &scheduleIDCopy, clustersCopy, TriggerTypeCron)
 			klog.V(6).Infof("定时巡检任务 [%s] 执行完成", item.Name)
 		})
 		if addErr != nil {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
@@ -51,14 +52,28 @@ func (s *ScheduleBackground) GetSummaryMsg(recordID uint) (*SummaryMsg, error) {
 	scriptCodes := utils.SplitAndTrim(schedule.ScriptCodes, ",")
 	totalRules := len(scriptCodes)
 
-	// 4. 统计失败数
-	eventModel := &models.InspectionCheckEvent{}
+	// 4. 统计失败数，按计划的最低关注级别过滤，并按严重级别从高到低排序
+	// 需要全部失败事件，不能使用默认分页查询
 	failedCount := 0
-	events, _, err := eventModel.List(nil, func(db *gorm.DB) *gorm.DB {
-		return db.Where("record_id = ? AND event_status = ?", recordID, constants.LuaEventStatusFailed)
-	})
+	score := 0
+	severityCounts := map[string]int{}
+	var events []*models.InspectionCheckEvent
+	var allEvents []*models.InspectionCheckEvent
+	err = dao.DB().Where("record_id = ? AND event_status = ?", recordID, constants.LuaEventStatusFailed).
+		Order("id asc").Find(&allEvents).Error
 
 	if err == nil {
+		for _, e := range allEvents {
+			if !schedule.MatchSeverity(e.Severity) {
+				continue
+			}
+			events = append(events, e)
+			severityCounts[string(models.NormalizeSeverity(e.Severity, constants.LuaEventSeverityWarning))]++
+			score += schedule.SeverityWeight(e.Severity)
+		}
+		sort.SliceStable(events, func(i, j int) bool {
+			return models.SeverityRank(events[i].Severity) > models.SeverityRank(events[j].Severity)
+		})
 		failedCount = len(events)
 	}
 
@@ -78,6 +93,9 @@ func (s *ScheduleBackground) GetSummaryMsg(recordID uint) (*SummaryMsg, error) {
 		TotalRules:       totalRules,
 		FailedCount:      failedCount,
 		FailedList:       events,
+		SeverityCounts:   severityCounts,
+		Score:            score,
+		MinSeverity:      schedule.MinSeverity,
 		AIEnabled:        schedule.AIEnabled,
 		AIPromptTemplate: schedule.AIPromptTemplate,
 	}
@@ -164,6 +182,12 @@ func (s *ScheduleBackground) generateBasicSummary(msg *SummaryMsg) (summary stri
 		resultMsg = "✅ 巡检完成，未发现问题。"
 	} else {
 		resultMsg = fmt.Sprintf("⚠️ 巡检完成，共发现 %d 个问题需要关注。", failedCount)
+		resultMsg += fmt.Sprintf("\n🔴 严重：%d  🟠 警告：%d  🔵 提示：%d  📈 风险分：%d",
+			msg.SeverityCounts[string(constants.LuaEventSeverityCritical)],
+			msg.SeverityCounts[string(constants.LuaEventSeverityWarning)],
+			msg.SeverityCounts[string(constants.LuaEventSeverityInfo)],
+			msg.Score,
+		)
 	}
 
 	// 使用统一的模板生成汇总
@@ -194,6 +218,7 @@ func (s *ScheduleBackground) generateAISummary(ctx context.Context, msg *Summary
 		1、仅做汇总，不要解释
 		2、不需要解决方案。
 		3、可以合理使用表情符号。
+		4、按严重级别（critical、warning、info）从高到低汇总，严重问题优先列出。
 	
 	    附加要求：
 		%s
@@ -203,6 +228,8 @@ func (s *ScheduleBackground) generateAISummary(ctx context.Context, msg *Summary
 		`
 	prompt = fmt.Sprintf(prompt, customTemplate, utils.ToJSON(msg))
 
+	ctx = service.AIService().WithFeature(ctx, constants.AIFeatureInspectionSummary)
+	ctx = service.AIUsageService().WithCluster(ctx, msg.Cluster)
 	summary, err := service.ChatService().ChatWithCtxNoHistory(ctx, prompt)
 	if err != nil {
 		return "", fmt.Errorf("AI汇总请求失败: %v", err)
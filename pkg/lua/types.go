@@ -16,6 +16,11 @@ type CheckEvent struct {
 	CheckDesc  string         `json:"checkDesc"`  // 检查脚本内容描述
 	Namespace  string         `json:"ns"`         // 资源命名空间
 	Name       string         `json:"name"`       // 资源名称
+
+	Severity    string   `json:"severity"`              // 严重级别 info/warning/critical
+	Categories  []string `json:"categories,omitempty"`  // 分类标签
+	Remediation string   `json:"remediation,omitempty"` // 修复建议
+	DocURL      string   `json:"docUrl,omitempty"`      // 参考文档链接
 }
 
 type CheckResult struct {
@@ -30,14 +35,52 @@ type CheckResult struct {
 // SummaryMsg 巡检记录汇总信息结构体
 // 用于替代原来的 map[string]any 返回类型，提供类型安全和更好的性能
 type SummaryMsg struct {
-	RecordDate        string                          `json:"record_date"`        // 巡检记录日期（本地时间格式）
-	RecordID          uint                            `json:"record_id"`          // 巡检记录ID
-	ScheduleID        *uint                           `json:"schedule_id"`        // 巡检计划ID
-	ScheduleName      string                          `json:"schedule_name"`      // 巡检计划名称
-	Cluster           string                          `json:"cluster"`            // 集群名称
-	TotalRules        int                             `json:"total_rules"`        // 总规则数
-	FailedCount       int                             `json:"failed_count"`       // 失败数量
-	FailedList        []*models.InspectionCheckEvent  `json:"failed_list"`        // 失败事件列表
-	AIEnabled         bool                            `json:"ai_enabled"`         // 是否启用AI汇总
-	AIPromptTemplate  string                          `json:"ai_prompt_template"` // AI提示模板
+	RecordDate       string                         `json:"record_date"`        // 巡检记录日期（本地时间格式）
+	RecordID         uint                           `json:"record_id"`          // 巡检记录ID
+	ScheduleID       *uint                          `json:"schedule_id"`        // 巡检计划ID
+	ScheduleName     string                         `json:"schedule_name"`      // 巡检计划名称
+	Cluster          string                         `json:"cluster"`            // 集群名称
+	TotalRules       int                            `json:"total_rules"`        // 总规则数
+	FailedCount      int                            `json:"failed_count"`       // 失败数量
+	SeverityCounts   map[string]int                 `json:"severity_counts"`    // 各严重级别的失败数量
+	Score            int                            `json:"score"`              // 按严重级别加权的失败分数
+	MinSeverity      string                         `json:"min_severity"`       // 最低关注级别
+	FailedList       []*models.InspectionCheckEvent `json:"failed_list"`        // 失败事件列表
+	AIEnabled        bool                           `json:"ai_enabled"`         // 是否启用AI汇总
+	AIPromptTemplate string                         `json:"ai_prompt_template"` // AI提示模板
+}
+
+// RecordDiff 两次巡检记录失败事件的比对结果，事件按检测脚本、资源类型、命名空间及资源名比对
+type RecordDiff struct {
+	BaseRecordID uint                           `json:"base_record_id"` // 对比的基准记录ID，为 0 表示没有更早的记录
+	RecordID     uint                           `json:"record_id"`      // 当前记录ID
+	Cluster      string                         `json:"cluster"`        // 集群名称
+	New          []*models.InspectionCheckEvent `json:"new"`            // 新增的失败
+	Resolved     []*models.InspectionCheckEvent `json:"resolved"`       // 已恢复的失败
+	Persisting   []*models.InspectionCheckEvent `json:"persisting"`     // 持续存在的失败
+	Skipped      []string                       `json:"skipped"`        // 任一记录中执行出错的脚本，其事件不参与比对
+}
+
+// RuleTrendPoint 规则在一次巡检中的失败数
+type RuleTrendPoint struct {
+	RecordID    uint      `json:"record_id"`
+	RunTime     time.Time `json:"run_time"`
+	FailedCount int       `json:"failed_count"`
+	Errored     bool      `json:"errored,omitempty"` // 该次巡检中脚本执行出错，失败数不可信
 }
+
+// RuleTrend 单条规则在最近多次巡检中的失败数时间序列
+type RuleTrend struct {
+	ScriptName string           `json:"script_name"`
+	Kind       string           `json:"kind"`
+	Status     string           `json:"status"` // 最近两次巡检间的变化：new/resolved/persisting/passing
+	Points     []RuleTrendPoint `json:"points"`
+}
+
+// 规则趋势状态
+const (
+	RuleTrendNew        = "new"
+	RuleTrendResolved   = "resolved"
+	RuleTrendPersisting = "persisting"
+	RuleTrendPassing    = "passing"
+)
@@ -16,6 +16,11 @@ type AIModelConfig struct {
 	ApiKey      string    `json:"api_key"`
 	ApiURL      string    `json:"api_url"`
 	ApiModel    string    `json:"api_model"`
+	Provider    string    `json:"provider"`     // 厂商：openai、azure、anthropic、ollama，为空时按 openai 兼容接口处理
+	ApiVersion  string    `json:"api_version"`  // Azure OpenAI 的 api-version
+	Deployment  string    `json:"deployment"`   // Azure OpenAI 的部署名称
+	InputPrice  float64   `json:"input_price"`  // 输入单价，每百万 token，用于用量费用统计
+	OutputPrice float64   `json:"output_price"` // 输出单价，每百万 token
 	Temperature float32   `json:"temperature"`
 	TopP        float32   `json:"top_p"`
 	Think       bool      `json:"think"` // 是否关闭思考模式
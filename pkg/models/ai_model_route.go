@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"gorm.io/gorm"
+)
+
+// AIModelRoute AI功能的模型路由
+// 按功能（提示词类型、GPTShell、巡检汇总）指定使用的模型，主模型请求失败或超时后按顺序使用备用模型；未配置路由的功能使用默认模型
+type AIModelRoute struct {
+	ID               uint                   `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Feature          constants.AIPromptType `gorm:"size:50;uniqueIndex" json:"feature,omitempty"` // 功能
+	ModelID          uint                   `json:"model_id,omitempty"`                           // 主模型，AIModelConfig 的ID
+	FallbackModelIDs string                 `json:"fallback_model_ids,omitempty"`                 // 备用模型ID，逗号分隔，按顺序尝试
+	Timeout          int                    `json:"timeout,omitempty"`                            // 单个模型的请求超时（秒），0表示不限制
+	Enabled          bool                   `json:"enabled,omitempty"`
+	Description      string                 `json:"description,omitempty"`
+	CreatedBy        string                 `json:"created_by,omitempty"`
+	CreatedAt        time.Time              `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt        time.Time              `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
+}
+
+func (c *AIModelRoute) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*AIModelRoute, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *AIModelRoute) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, c, queryFuncs...)
+}
+
+func (c *AIModelRoute) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+func (c *AIModelRoute) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*AIModelRoute, error) {
+	return dao.GenericGetOne(params, c, queryFuncs...)
+}
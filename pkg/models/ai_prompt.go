@@ -1,8 +1,14 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/weibaohui/htpl"
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/constants"
@@ -19,6 +25,11 @@ type AIPrompt struct {
 	Content     string                 `json:"content" gorm:"type:text;not null"`         // 提示词内容
 	IsBuiltin   bool                   `json:"is_builtin" gorm:"default:false;index"`     // 是否为内置提示词
 	IsEnabled   bool                   `json:"is_enabled" gorm:"default:false;index"`     // 是否启用
+	Variables   string                 `json:"variables" gorm:"type:text"`                // 变量定义，JSON格式的 []AIPromptVariable
+	Clusters    string                 `json:"clusters" gorm:"size:500;default:''"`       // 适用集群，逗号分隔，为空表示全部集群
+	UserGroups  string                 `json:"user_groups" gorm:"size:500;default:''"`    // 适用用户组，逗号分隔，为空表示全部用户组
+	Priority    int                    `json:"priority" gorm:"default:0"`                 // 同一类型的多个变体同时匹配时，优先级高的生效
+	Version     int                    `json:"version" gorm:"default:0"`                  // 当前版本号，每次修改内容后递增
 	CreatedAt   time.Time              `json:"created_at,omitempty" gorm:"<-:create"`
 	UpdatedAt   time.Time              `json:"updated_at,omitempty"`
 }
@@ -48,6 +59,173 @@ func (m *AIPrompt) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm
 	return dao.GenericGetOne(params, m, queryFuncs...)
 }
 
+// 提示词变量类型
+const (
+	AIPromptVariableTypeString = "string"
+	AIPromptVariableTypeNumber = "number"
+	AIPromptVariableTypeBool   = "bool"
+	AIPromptVariableTypeEnum   = "enum"
+)
+
+// 所有提示词均可使用的标准变量，由调用方根据请求自动填充
+const (
+	AIPromptVarCluster   = "Cluster"
+	AIPromptVarKind      = "Kind"
+	AIPromptVarNamespace = "Namespace"
+	AIPromptVarLanguage  = "Language"
+	AIPromptVarLocale    = "Locale"
+)
+
+// AIPromptVariable 提示词变量定义
+// 渲染时按类型转换传入的值，未传入时使用默认值，必填变量缺失时报错
+type AIPromptVariable struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type,omitempty"` // string（默认）、number、bool、enum
+	Default     string   `json:"default,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Options     []string `json:"options,omitempty"` // enum 类型的可选值
+	Description string   `json:"description,omitempty"`
+}
+
+// GetVariables 解析提示词中的变量定义
+func (m *AIPrompt) GetVariables() ([]AIPromptVariable, error) {
+	var vars []AIPromptVariable
+	if strings.TrimSpace(m.Variables) == "" {
+		return vars, nil
+	}
+	if err := json.Unmarshal([]byte(m.Variables), &vars); err != nil {
+		return nil, fmt.Errorf("解析提示词变量失败: %w", err)
+	}
+	for _, v := range vars {
+		if v.Name == "" {
+			return nil, fmt.Errorf("提示词变量名称不能为空")
+		}
+		switch v.Type {
+		case "", AIPromptVariableTypeString, AIPromptVariableTypeNumber, AIPromptVariableTypeBool:
+		case AIPromptVariableTypeEnum:
+			if len(v.Options) == 0 {
+				return nil, fmt.Errorf("枚举变量 %s 的可选值不能为空", v.Name)
+			}
+		default:
+			return nil, fmt.Errorf("变量 %s 的类型 %s 无效", v.Name, v.Type)
+		}
+	}
+	return vars, nil
+}
+
+// MatchScore 计算提示词变体与集群、用户组的匹配度，不匹配时返回-1
+// 限定了集群或用户组的变体比通用变体更具体，匹配度更高
+func (m *AIPrompt) MatchScore(cluster string, groups []string) int {
+	score := 0
+	if clusters := splitList(m.Clusters); len(clusters) > 0 {
+		if !slices.Contains(clusters, cluster) {
+			return -1
+		}
+		score += 2
+	}
+	if userGroups := splitList(m.UserGroups); len(userGroups) > 0 {
+		if !slices.ContainsFunc(userGroups, func(g string) bool { return slices.Contains(groups, g) }) {
+			return -1
+		}
+		score++
+	}
+	return score
+}
+
+// Render 使用变量值渲染提示词
+// values 中未定义的变量原样传入模板；已定义的变量按类型转换，缺失时使用默认值
+func (m *AIPrompt) Render(values map[string]any) (string, error) {
+	defs, err := m.GetVariables()
+	if err != nil {
+		return "", err
+	}
+	ctx := make(map[string]any, len(values)+len(defs))
+	for k, v := range values {
+		ctx[k] = v
+	}
+	for _, def := range defs {
+		raw, ok := ctx[def.Name]
+		if !ok || raw == nil || raw == "" {
+			if def.Default == "" {
+				if def.Required {
+					return "", fmt.Errorf("缺少必填变量 %s", def.Name)
+				}
+				ctx[def.Name] = ""
+				continue
+			}
+			raw = def.Default
+		}
+		v, err := def.convert(raw)
+		if err != nil {
+			return "", err
+		}
+		ctx[def.Name] = v
+	}
+
+	tpl, err := htpl.NewEngine().ParseString(m.Content)
+	if err != nil {
+		return "", fmt.Errorf("解析提示词模板失败: %w", err)
+	}
+	result, err := tpl.Render(ctx)
+	if err != nil {
+		return "", fmt.Errorf("渲染提示词模板失败: %w", err)
+	}
+	return result, nil
+}
+
+// convert 按变量类型转换变量值
+func (v AIPromptVariable) convert(raw any) (any, error) {
+	str := fmt.Sprint(raw)
+	switch v.Type {
+	case AIPromptVariableTypeNumber:
+		if _, ok := raw.(float64); ok {
+			return raw, nil
+		}
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return nil, fmt.Errorf("变量 %s 应为数字: %s", v.Name, str)
+		}
+		return f, nil
+	case AIPromptVariableTypeBool:
+		if _, ok := raw.(bool); ok {
+			return raw, nil
+		}
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return nil, fmt.Errorf("变量 %s 应为布尔值: %s", v.Name, str)
+		}
+		return b, nil
+	case AIPromptVariableTypeEnum:
+		if !slices.Contains(v.Options, str) {
+			return nil, fmt.Errorf("变量 %s 的值 %s 不在可选范围 %v 内", v.Name, str, v.Options)
+		}
+		return str, nil
+	}
+	return str, nil
+}
+
+// PromptLanguage 根据用户的语言区域确定回答使用的语言，默认中文
+func PromptLanguage(locale string) string {
+	locale = strings.ToLower(locale)
+	if locale == "" || strings.HasPrefix(locale, "zh") {
+		return "中文"
+	}
+	if strings.HasPrefix(locale, "en") {
+		return "English"
+	}
+	return locale
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 // GetBuiltinPromptContent 根据提示词类型获取内置提示词内容
 // 参数:
 //   - promptType: 提示词类型
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAIPromptRender(t *testing.T) {
+	p := &AIPrompt{
+		Content: `分析 ${Kind} ${Name}，最多 ${MaxItems} 条，使用${Language}回答${Verbose ? "，请详细说明" : ""}`,
+		Variables: `[
+			{"name":"Name","required":true},
+			{"name":"MaxItems","type":"number","default":"3"},
+			{"name":"Verbose","type":"bool","default":"false"},
+			{"name":"Language","type":"enum","options":["中文","English"],"default":"中文"}
+		]`,
+	}
+	got, err := p.Render(map[string]any{"Kind": "Pod", "Name": "nginx", "Verbose": "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(got) != "分析 Pod nginx，最多 3 条，使用中文回答，请详细说明" {
+		t.Fatalf("unexpected render: %q", got)
+	}
+
+	if _, err = p.Render(map[string]any{"Kind": "Pod"}); err == nil || !strings.Contains(err.Error(), "Name") {
+		t.Fatalf("expected required error, got %v", err)
+	}
+	if _, err = p.Render(map[string]any{"Name": "nginx", "Language": "日本語"}); err == nil {
+		t.Fatal("expected enum error")
+	}
+}
+
+func TestAIPromptMatchScore(t *testing.T) {
+	generic := &AIPrompt{}
+	prod := &AIPrompt{Clusters: "prod, prod-2"}
+	sre := &AIPrompt{UserGroups: "sre"}
+
+	if generic.MatchScore("dev", nil) != 0 || prod.MatchScore("dev", nil) != -1 || prod.MatchScore("prod-2", nil) != 2 {
+		t.Fatal("unexpected cluster match")
+	}
+	if sre.MatchScore("dev", []string{"dev-team"}) != -1 || sre.MatchScore("dev", []string{"dev-team", "sre"}) != 1 {
+		t.Fatal("unexpected group match")
+	}
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"gorm.io/gorm"
+)
+
+// AIPromptVersion AI提示词的历史版本
+// 提示词每次修改内容后保存一份快照，用于查看历史及回滚
+type AIPromptVersion struct {
+	ID          uint                   `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	PromptID    uint                   `json:"prompt_id" gorm:"index"`
+	Version     int                    `json:"version"`
+	Name        string                 `json:"name" gorm:"size:100"`
+	Description string                 `json:"description" gorm:"size:500"`
+	PromptType  constants.AIPromptType `json:"prompt_type" gorm:"size:50"`
+	Content     string                 `json:"content" gorm:"type:text"`
+	Variables   string                 `json:"variables" gorm:"type:text"`
+	Clusters    string                 `json:"clusters" gorm:"size:500"`
+	UserGroups  string                 `json:"user_groups" gorm:"size:500"`
+	Priority    int                    `json:"priority"`
+	Comment     string                 `json:"comment"` // 版本说明，如回滚来源
+	CreatedBy   string                 `json:"created_by,omitempty"`
+	CreatedAt   time.Time              `json:"created_at,omitempty" gorm:"<-:create"`
+}
+
+func (v *AIPromptVersion) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*AIPromptVersion, int64, error) {
+	return dao.GenericQuery(params, v, queryFuncs...)
+}
+
+func (v *AIPromptVersion) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*AIPromptVersion, error) {
+	return dao.GenericGetOne(params, v, queryFuncs...)
+}
+
+// NewAIPromptVersion 创建提示词当前内容的快照
+func NewAIPromptVersion(p *AIPrompt, comment string) *AIPromptVersion {
+	return &AIPromptVersion{
+		PromptID:    p.ID,
+		Version:     p.Version,
+		Name:        p.Name,
+		Description: p.Description,
+		PromptType:  p.PromptType,
+		Content:     p.Content,
+		Variables:   p.Variables,
+		Clusters:    p.Clusters,
+		UserGroups:  p.UserGroups,
+		Priority:    p.Priority,
+		Comment:     comment,
+	}
+}
+
+// ApplyTo 将快照内容写回提示词，不修改启用状态及版本号
+func (v *AIPromptVersion) ApplyTo(p *AIPrompt) {
+	p.Name = v.Name
+	p.Description = v.Description
+	p.PromptType = v.PromptType
+	p.Content = v.Content
+	p.Variables = v.Variables
+	p.Clusters = v.Clusters
+	p.UserGroups = v.UserGroups
+	p.Priority = v.Priority
+}
+
+// SameContent 提示词内容与快照是否一致
+func (v *AIPromptVersion) SameContent(p *AIPrompt) bool {
+	return v.Name == p.Name && v.Description == p.Description && v.PromptType == p.PromptType &&
+		v.Content == p.Content && v.Variables == p.Variables && v.Clusters == p.Clusters &&
+		v.UserGroups == p.UserGroups && v.Priority == p.Priority
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+// AITokenUsage 大模型 token 用量，每次请求一条
+type AITokenUsage struct {
+	ID               uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	UserName         string    `gorm:"index" json:"username,omitempty"`
+	Provider         string    `json:"provider,omitempty"`
+	ModelConfigID    uint      `gorm:"index" json:"model_config_id,omitempty"` // 处理请求的模型配置ID
+	Model            string    `gorm:"index" json:"model,omitempty"`
+	Feature          string    `gorm:"index" json:"feature,omitempty"` // AI功能，即提示词类型、GPTShell、巡检汇总等
+	Cluster          string    `gorm:"index" json:"cluster,omitempty"`
+	PromptTokens     int       `json:"prompt_tokens,omitempty"`
+	CompletionTokens int       `json:"completion_tokens,omitempty"`
+	TotalTokens      int       `json:"total_tokens,omitempty"`
+	Cost             float64   `json:"cost,omitempty"` // 按请求时模型单价计算的费用
+	Stream           bool      `json:"stream,omitempty"`
+	Estimated        bool      `json:"estimated,omitempty"` // 厂商未返回用量，按内容估算
+	CreatedAt        time.Time `gorm:"index" json:"created_at,omitempty"`
+}
+
+func (c *AITokenUsage) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*AITokenUsage, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *AITokenUsage) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, c, queryFuncs...)
+}
+
+func (c *AITokenUsage) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+func (c *AITokenUsage) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*AITokenUsage, error) {
+	return dao.GenericGetOne(params, c, queryFuncs...)
+}
+
+// 配额范围
+const (
+	AITokenQuotaScopeUser  = "user"
+	AITokenQuotaScopeGroup = "group"
+)
+
+// 配额周期
+const (
+	AITokenQuotaPeriodDaily   = "daily"
+	AITokenQuotaPeriodMonthly = "monthly"
+)
+
+// AITokenQuota 大模型 token 配额
+// 用户组配额对组内每个用户单独计算；同一周期内用户配额优先于用户组配额
+type AITokenQuota struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Scope       string    `gorm:"index" json:"scope,omitempty"`  // user、group
+	Target      string    `gorm:"index" json:"target,omitempty"` // 用户名或用户组名称
+	Period      string    `json:"period,omitempty"`              // daily、monthly
+	TokenLimit  int64     `json:"token_limit,omitempty"`         // 周期内可使用的 token 总数
+	Enabled     bool      `json:"enabled,omitempty"`
+	Description string    `json:"description,omitempty"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
+}
+
+func (c *AITokenQuota) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*AITokenQuota, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *AITokenQuota) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, c, queryFuncs...)
+}
+
+func (c *AITokenQuota) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+func (c *AITokenQuota) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*AITokenQuota, error) {
+	return dao.GenericGetOne(params, c, queryFuncs...)
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+// 审批请求状态
+const (
+	ApprovalStatusPending   = "pending"   // 待审批
+	ApprovalStatusRejected  = "rejected"  // 已驳回
+	ApprovalStatusExecuting = "executing" // 审批通过，正在后台执行，执行中断时由Leader标记为失败
+	ApprovalStatusExecuted  = "executed"  // 审批通过并执行成功
+	ApprovalStatusFailed    = "failed"    // 审批通过但执行失败
+)
+
+// ApprovalPolicy 高危操作审批策略
+// 命中策略的操作不会立即执行，而是生成待审批请求，审批通过后再以申请人身份执行
+type ApprovalPolicy struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Name         string    `json:"name,omitempty"`          // 策略名称
+	Cluster      string    `json:"cluster,omitempty"`       // 集群ID，为空或*表示全部集群
+	Namespaces   string    `json:"namespaces,omitempty"`    // 命名空间列表，逗号分割，为空表示全部命名空间
+	Actions      string    `json:"actions,omitempty"`       // 需要审批的操作，逗号分割，如 node_drain,force_remove
+	ApproverRole string    `json:"approver_role,omitempty"` // 审批人需具备的集群角色，为空表示具备approve权限即可
+	Webhooks     string    `json:"webhooks,omitempty"`      // 审批通知的webhook ID列表，逗号分割
+	Enabled      bool      `json:"enabled"`                 // 是否启用
+	CreatedBy    string    `json:"created_by,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt    time.Time `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
+}
+
+func (c *ApprovalPolicy) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*ApprovalPolicy, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *ApprovalPolicy) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, c, queryFuncs...)
+}
+
+func (c *ApprovalPolicy) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+func (c *ApprovalPolicy) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*ApprovalPolicy, error) {
+	return dao.GenericGetOne(params, c, queryFuncs...)
+}
+
+// Match 判断操作是否命中该策略
+// nsList 为操作涉及的命名空间，任意一个命名空间在策略范围内即视为命中
+func (c *ApprovalPolicy) Match(cluster string, nsList []string, action string) bool {
+	if !c.Enabled {
+		return false
+	}
+	if c.Cluster != "" && c.Cluster != "*" && c.Cluster != cluster {
+		return false
+	}
+	if !utils.AnyIn([]string{action}, splitTrim(c.Actions)) {
+		return false
+	}
+	namespaces := splitTrim(c.Namespaces)
+	if len(namespaces) == 0 || utils.AnyIn([]string{"*"}, namespaces) {
+		return true
+	}
+	return utils.AnyIn(nsList, namespaces)
+}
+
+// ApprovalRequest 高危操作审批请求
+// 记录申请人提交的操作及其参数，审批通过后按参数重放执行
+type ApprovalRequest struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	PolicyID    uint       `json:"policy_id,omitempty"`                   // 命中的审批策略
+	Cluster     string     `gorm:"index" json:"cluster,omitempty"`        // 集群ID
+	Namespace   string     `json:"namespace,omitempty"`                   // 命名空间
+	Group       string     `json:"group,omitempty"`                       // 资源group
+	Version     string     `json:"version,omitempty"`                     // 资源version
+	Kind        string     `json:"kind,omitempty"`                        // 资源kind
+	Name        string     `json:"name,omitempty"`                        // 资源名称
+	Action      string     `json:"action,omitempty"`                      // 操作类型，如 node_drain
+	Params      string     `gorm:"type:text" json:"params,omitempty"`     // 操作参数，如待删除的YAML
+	Status      string     `gorm:"index" json:"status,omitempty"`         // 状态 pending/rejected/executing/executed/failed
+	RequestedBy string     `gorm:"index" json:"requested_by,omitempty"`   // 申请人
+	ApprovedBy  string     `json:"approved_by,omitempty"`                 // 审批人
+	Comment     string     `json:"comment,omitempty"`                     // 审批意见
+	Result      string     `gorm:"type:text" json:"result,omitempty"`     // 执行结果
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`                  // 审批时间
+	CreatedAt   time.Time  `json:"created_at,omitempty" gorm:"<-:create"` // Automatically managed by GORM for creation time
+	UpdatedAt   time.Time  `json:"updated_at,omitempty"`                  // Automatically managed by GORM for update time
+}
+
+func (c *ApprovalRequest) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*ApprovalRequest, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *ApprovalRequest) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, c, queryFuncs...)
+}
+
+func (c *ApprovalRequest) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+func (c *ApprovalRequest) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*ApprovalRequest, error) {
+	return dao.GenericGetOne(params, c, queryFuncs...)
+}
+
+func splitTrim(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+// 审计日志外发类型
+const (
+	AuditSinkTypeFile   = "file"   // 本地JSON Lines文件，按大小滚动
+	AuditSinkTypeSyslog = "syslog" // RFC5424 syslog，支持tcp/udp
+	AuditSinkTypeHTTP   = "http"   // HTTP批量推送
+)
+
+// 审计日志来源
+const (
+	AuditSourceOperation = "operation" // 资源操作日志
+	AuditSourceShell     = "shell"     // 容器/节点终端命令日志
+	AuditSourceMCP       = "mcp"       // MCP工具执行日志
+)
+
+// AuditSink 审计日志外发配置
+// 操作日志、终端命令日志、MCP工具执行日志写入数据库后，按配置同步推送到外部日志平台（如SIEM）
+type AuditSink struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Name          string    `json:"name,omitempty"`                             // 名称
+	Type          string    `json:"type,omitempty"`                             // 类型 file/syslog/http
+	Enabled       bool      `json:"enabled"`                                    // 是否启用
+	Sources       string    `json:"sources,omitempty"`                          // 推送的日志来源，逗号分割，为空表示全部
+	FilePath      string    `json:"file_path,omitempty"`                        // file：文件路径
+	MaxSizeMB     int       `gorm:"default:100" json:"max_size_mb,omitempty"`   // file：单个文件最大大小（MB），超出后滚动
+	MaxBackups    int       `gorm:"default:5" json:"max_backups,omitempty"`     // file：保留的历史文件个数
+	Network       string    `json:"network,omitempty"`                          // syslog：tcp/udp
+	Address       string    `json:"address,omitempty"`                          // syslog：服务地址 host:port
+	AppName       string    `json:"app_name,omitempty"`                         // syslog：APP-NAME，默认k8m
+	URL           string    `json:"url,omitempty"`                              // http：推送地址
+	Headers       string    `gorm:"type:text" json:"headers,omitempty"`         // http：自定义请求头，每行一个，格式 Key: Value
+	BatchSize     int       `gorm:"default:100" json:"batch_size,omitempty"`    // 每批推送的最大条数
+	FlushInterval int       `gorm:"default:5" json:"flush_interval,omitempty"`  // 批量推送间隔（秒）
+	BufferSize    int       `gorm:"default:10000" json:"buffer_size,omitempty"` // 内存缓冲队列长度，外部平台不可用时暂存待推送日志
+	CreatedBy     string    `json:"created_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt     time.Time `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
+}
+
+func (c *AuditSink) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*AuditSink, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *AuditSink) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, c, queryFuncs...)
+}
+
+func (c *AuditSink) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+func (c *AuditSink) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*AuditSink, error) {
+	return dao.GenericGetOne(params, c, queryFuncs...)
+}
+
+// AcceptSource 判断是否推送指定来源的日志
+func (c *AuditSink) AcceptSource(source string) bool {
+	sources := splitTrim(c.Sources)
+	if len(sources) == 0 {
+		return true
+	}
+	return utils.AnyIn([]string{source}, sources)
+}
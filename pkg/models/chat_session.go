@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+// ChatSession AI对话会话
+// 每个用户可以有多个独立的会话，会话可选绑定集群/命名空间，消息保存在 ChatMessage 中
+type ChatSession struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	UserName  string    `gorm:"index" json:"username,omitempty"`
+	Title     string    `json:"title,omitempty"`
+	Cluster   string    `json:"cluster,omitempty"`   // 绑定的集群，为空表示不绑定
+	Namespace string    `json:"namespace,omitempty"` // 绑定的命名空间，为空表示不绑定
+	CreatedAt time.Time `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
+}
+
+func (c *ChatSession) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*ChatSession, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *ChatSession) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, c, queryFuncs...)
+}
+
+func (c *ChatSession) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+func (c *ChatSession) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*ChatSession, error) {
+	return dao.GenericGetOne(params, c, queryFuncs...)
+}
+
+// ChatMessage AI对话会话中的一条消息
+type ChatMessage struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	SessionID  uint      `gorm:"index" json:"session_id,omitempty"`
+	Role       string    `json:"role,omitempty"`
+	Content    string    `gorm:"type:text" json:"content,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	ToolCallID string    `json:"tool_call_id,omitempty"`
+	ToolCalls  string    `gorm:"type:text" json:"tool_calls,omitempty"` // 工具调用，JSON格式
+	CreatedAt  time.Time `json:"created_at,omitempty" gorm:"<-:create"`
+}
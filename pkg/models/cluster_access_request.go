@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+// 临时授权申请状态
+const (
+	AccessRequestStatusPending  = "pending"  // 待审批
+	AccessRequestStatusApproved = "approved" // 已批准，授权生效中
+	AccessRequestStatusRejected = "rejected" // 已驳回
+	AccessRequestStatusExpired  = "expired"  // 授权已到期回收
+)
+
+// ClusterAccessRequest 用户自助申请的临时集群授权
+// 批准后生成带有效期的 ClusterUserRole，到期后由后台任务回收
+type ClusterAccessRequest struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Username   string     `gorm:"index" json:"username,omitempty"`   // 申请人
+	Cluster    string     `json:"cluster,omitempty"`                 // 集群ID
+	Role       string     `json:"role,omitempty"`                    // 申请的集群角色
+	Namespaces string     `json:"namespaces,omitempty"`              // 申请的命名空间，逗号分割，为空表示不限制
+	Hours      int        `json:"hours,omitempty"`                   // 申请时长（小时）
+	Reason     string     `gorm:"type:text" json:"reason,omitempty"` // 申请理由
+	Status     string     `gorm:"index" json:"status,omitempty"`     // 状态 pending/approved/rejected/expired
+	ApprovedBy string     `json:"approved_by,omitempty"`             // 审批人
+	Comment    string     `json:"comment,omitempty"`                 // 审批意见
+	GrantID    uint       `json:"grant_id,omitempty"`                // 批准后生成的授权ID
+	ValidUntil *time.Time `json:"valid_until,omitempty"`             // 授权到期时间
+	CreatedAt  time.Time  `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt  time.Time  `json:"updated_at,omitempty"`
+}
+
+func (c *ClusterAccessRequest) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*ClusterAccessRequest, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *ClusterAccessRequest) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, c, queryFuncs...)
+}
+
+func (c *ClusterAccessRequest) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+func (c *ClusterAccessRequest) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*ClusterAccessRequest, error) {
+	return dao.GenericGetOne(params, c, queryFuncs...)
+}
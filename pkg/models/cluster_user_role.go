@@ -1,12 +1,15 @@
 package models
 
 import (
+	"path"
+	"strings"
 	"time"
 
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/constants"
 	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // ClusterUserRole 集群用户权限表
@@ -14,12 +17,16 @@ import (
 // 如果是Group，那么代表这个组有哪些权限，这个组可能会有多个用户，那么这多个用户都有相关的权限
 type ClusterUserRole struct {
 	ID                  uint                               `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
-	Cluster             string                             `gorm:"index" json:"cluster,omitempty"`  // 集群名称
-	Username            string                             `gorm:"index" json:"username,omitempty"` // 用户名
-	Role                string                             `gorm:"index" json:"role,omitempty"`     // 角色类型：只读、读写、Exec
-	Namespaces          string                             `json:"namespaces,omitempty"`            // Namespaces列表，逗号分割 ，该用户可以访问的Ns
-	BlacklistNamespaces string                             `json:"blacklist_namespaces,omitempty"`  // 黑名单Namespaces列表，逗号分割，禁止访问的Ns
-	AuthorizationType   constants.ClusterAuthorizationType `json:"authorization_type,omitempty"`    // 用户类型。User\Group两种，默认为User，空为User。Group指用户组
+	Cluster             string                             `gorm:"index" json:"cluster,omitempty"`     // 集群名称
+	Username            string                             `gorm:"index" json:"username,omitempty"`    // 用户名
+	Role                string                             `gorm:"index" json:"role,omitempty"`        // 角色类型：只读、读写、Exec
+	Namespaces          string                             `json:"namespaces,omitempty"`               // Namespaces列表，逗号分割 ，该用户可以访问的Ns
+	BlacklistNamespaces string                             `json:"blacklist_namespaces,omitempty"`     // 黑名单Namespaces列表，逗号分割，禁止访问的Ns
+	AuthorizationType   constants.ClusterAuthorizationType `json:"authorization_type,omitempty"`       // 用户类型。User\Group两种，默认为User，空为User。Group指用户组
+	LabelSelector       string                             `json:"label_selector,omitempty"`           // 标签选择器，如 team=payments，为空表示不限制
+	NamePatterns        string                             `json:"name_patterns,omitempty"`            // 资源名称通配符列表，逗号分割，如 payments-*，为空表示不限制
+	ValidFrom           *time.Time                         `json:"valid_from,omitempty"`               // 生效时间，为空表示立即生效
+	ValidUntil          *time.Time                         `gorm:"index" json:"valid_until,omitempty"` // 失效时间，为空表示永久有效
 	CreatedAt           time.Time                          `json:"created_at,omitempty" gorm:"<-:create"`
 	UpdatedAt           time.Time                          `json:"updated_at,omitempty"`
 }
@@ -39,3 +46,48 @@ func (c *ClusterUserRole) Delete(params *dao.Params, ids string, queryFuncs ...f
 func (c *ClusterUserRole) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*ClusterUserRole, error) {
 	return dao.GenericGetOne(params, c, queryFuncs...)
 }
+
+// IsActive 判断授权在指定时间是否处于有效期内
+func (c *ClusterUserRole) IsActive(now time.Time) bool {
+	if c.ValidFrom != nil && now.Before(*c.ValidFrom) {
+		return false
+	}
+	if c.ValidUntil != nil && !now.Before(*c.ValidUntil) {
+		return false
+	}
+	return true
+}
+
+// HasScope 判断授权是否限定了标签选择器或资源名称
+func (c *ClusterUserRole) HasScope() bool {
+	return strings.TrimSpace(c.LabelSelector) != "" || strings.TrimSpace(c.NamePatterns) != ""
+}
+
+// MatchName 判断资源名称是否符合授权的名称通配符，name为空（如列表查询）时视为符合
+func (c *ClusterUserRole) MatchName(name string) bool {
+	if name == "" || strings.TrimSpace(c.NamePatterns) == "" {
+		return true
+	}
+	for _, pattern := range strings.Split(c.NamePatterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchLabels 判断资源标签是否符合授权的标签选择器，选择器格式错误时视为不符合
+func (c *ClusterUserRole) MatchLabels(lbs map[string]string) bool {
+	if strings.TrimSpace(c.LabelSelector) == "" {
+		return true
+	}
+	selector, err := labels.Parse(c.LabelSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(lbs))
+}
@@ -0,0 +1,35 @@
+package models
+
+import "testing"
+
+func TestClusterUserRoleScope(t *testing.T) {
+	grant := &ClusterUserRole{
+		LabelSelector: "team=payments",
+		NamePatterns:  "payments-*, billing",
+	}
+
+	tests := []struct {
+		name   string
+		res    string
+		labels map[string]string
+		want   bool
+	}{
+		{"matched name and labels", "payments-api", map[string]string{"team": "payments"}, true},
+		{"exact name", "billing", map[string]string{"team": "payments"}, true},
+		{"other team's labels", "payments-api", map[string]string{"team": "orders"}, false},
+		{"name not in patterns", "orders-api", map[string]string{"team": "payments"}, false},
+		{"no labels", "payments-api", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := grant.MatchName(tt.res) && grant.MatchLabels(tt.labels)
+			if got != tt.want {
+				t.Errorf("match(%s, %v) = %v, want %v", tt.res, tt.labels, got, tt.want)
+			}
+		})
+	}
+
+	if !(&ClusterUserRole{}).MatchName("anything") || !(&ClusterUserRole{}).MatchLabels(nil) {
+		t.Errorf("unscoped grant should match everything")
+	}
+}
@@ -29,6 +29,7 @@ type Config struct {
 	ReconnectMaxIntervalSeconds int       `gorm:"default:3600" json:"reconnect_max_interval_seconds,omitempty"` // 重连最大间隔时间（秒）
 	MaxRetryAttempts            int       `gorm:"default:100" json:"max_retry_attempts,omitempty"`              // 最大重试次数，默认100次
 	ModelID                     uint      `json:"model_id"`
+	SensitiveKeys               string    `json:"sensitive_keys,omitempty"` // 敏感字段关键字，逗号分割，用于脱敏
 	CreatedAt                   time.Time `json:"created_at,omitempty" gorm:"<-:create"`
 	UpdatedAt                   time.Time `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
 }
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+// CustomRole 自定义集群角色
+// 由平台管理员定义，按 动作 × 资源组/资源类型 × 命名空间 的组合进行授权。
+// ClusterUserRole.Role 填写自定义角色的名称即可引用该角色。
+type CustomRole struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Name        string    `gorm:"index" json:"name,omitempty"`      // 角色名称，不可与内置角色重名
+	Description string    `json:"description,omitempty"`            // 描述
+	Rules       string    `gorm:"type:text" json:"rules,omitempty"` // 授权规则，JSON格式的 []CustomRoleRule
+	CreatedBy   string    `json:"created_by,omitempty"`             // 创建者
+	CreatedAt   time.Time `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
+}
+
+// CustomRoleRule 自定义角色中的一条授权规则
+// 各字段中的 * 表示匹配全部。
+// Groups、Kinds、Namespaces 为空表示不限制；Verbs 为空表示不授予任何动作。
+type CustomRoleRule struct {
+	Verbs      []string `json:"verbs"`      // 动作：get、list、describe、logs、create、update、patch、delete、exec
+	Groups     []string `json:"groups"`     // 资源组，core组使用空字符串
+	Kinds      []string `json:"kinds"`      // 资源类型，如 Deployment、Pod，不区分大小写
+	Namespaces []string `json:"namespaces"` // 命名空间
+}
+
+func (c *CustomRole) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*CustomRole, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *CustomRole) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, c, queryFuncs...)
+}
+
+func (c *CustomRole) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+func (c *CustomRole) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*CustomRole, error) {
+	return dao.GenericGetOne(params, c, queryFuncs...)
+}
+
+// GetRules 解析角色中的授权规则
+func (c *CustomRole) GetRules() ([]CustomRoleRule, error) {
+	var rules []CustomRoleRule
+	if strings.TrimSpace(c.Rules) == "" {
+		return rules, nil
+	}
+	if err := json.Unmarshal([]byte(c.Rules), &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Allow 判断角色中是否有任意一条规则允许对指定资源执行该动作
+// nsList 为操作涉及的命名空间，为空代表集群级或跨命名空间操作
+func (c *CustomRole) Allow(action, group, kind string, nsList []string) bool {
+	rules, err := c.GetRules()
+	if err != nil {
+		return false
+	}
+	for _, rule := range rules {
+		if rule.Allow(action, group, kind, nsList) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allow 判断该规则是否允许对指定资源执行该动作
+func (r *CustomRoleRule) Allow(action, group, kind string, nsList []string) bool {
+	if !matchRuleItem(r.Verbs, action, false) {
+		return false
+	}
+	if len(r.Groups) > 0 && !matchRuleItem(r.Groups, group, false) {
+		return false
+	}
+	if len(r.Kinds) > 0 && !matchRuleItem(r.Kinds, kind, true) {
+		return false
+	}
+	if len(r.Namespaces) > 0 && !slicesContainsWildcard(r.Namespaces) {
+		// 限定了命名空间的规则，不能用于集群级或跨命名空间的操作
+		if len(nsList) == 0 || !utils.AllIn(nsList, r.Namespaces) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchRuleItem 判断value是否命中规则项列表，* 表示全部
+func matchRuleItem(items []string, value string, ignoreCase bool) bool {
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "*" {
+			return true
+		}
+		if ignoreCase && strings.EqualFold(item, value) {
+			return true
+		}
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func slicesContainsWildcard(items []string) bool {
+	for _, item := range items {
+		if strings.TrimSpace(item) == "*" {
+			return true
+		}
+	}
+	return false
+}
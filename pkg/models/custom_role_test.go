@@ -0,0 +1,47 @@
+package models
+
+import "testing"
+
+func TestCustomRoleAllow(t *testing.T) {
+	role := &CustomRole{
+		Name: "team-a-operator",
+		Rules: `[
+			{"verbs":["get","list","patch"],"groups":["apps"],"kinds":["Deployment"],"namespaces":["team-a"]},
+			{"verbs":["logs"],"kinds":["pod"],"namespaces":["team-a"]}
+		]`,
+	}
+
+	tests := []struct {
+		name   string
+		action string
+		group  string
+		kind   string
+		nsList []string
+		want   bool
+	}{
+		{"restart deployment in team-a", "patch", "apps", "Deployment", []string{"team-a"}, true},
+		{"read logs in team-a", "logs", "", "Pod", []string{"team-a"}, true},
+		{"delete deployment not granted", "delete", "apps", "Deployment", []string{"team-a"}, false},
+		{"edit secret not granted", "update", "", "Secret", []string{"team-a"}, false},
+		{"other namespace", "patch", "apps", "Deployment", []string{"team-b"}, false},
+		{"cluster wide list", "list", "apps", "Deployment", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := role.Allow(tt.action, tt.group, tt.kind, tt.nsList); got != tt.want {
+				t.Errorf("Allow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomRoleRuleWildcard(t *testing.T) {
+	rule := &CustomRoleRule{Verbs: []string{"*"}, Namespaces: []string{"*"}}
+	if !rule.Allow("delete", "", "Secret", nil) {
+		t.Errorf("wildcard rule should allow all actions")
+	}
+	empty := &CustomRoleRule{}
+	if empty.Allow("get", "", "Pod", []string{"default"}) {
+		t.Errorf("rule without verbs should allow nothing")
+	}
+}
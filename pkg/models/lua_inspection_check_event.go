@@ -1,26 +1,32 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/constants"
 	"gorm.io/gorm"
 )
 
 // InspectionCheckEvent  用于记录每次检测的详细信息，包括检测状态、消息、额外上下文、脚本名称、资源类型、描述、命名空间和资源名。
 type InspectionCheckEvent struct {
 	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
-	RecordID    uint      `json:"record_id"`                        // 关联的巡检执行记录ID
-	EventStatus string    `json:"event_status"`                     // 事件状态（如“正常”、“失败”）
-	EventMsg    string    `json:"event_msg"`                        // 事件消息
-	Extra       string    `gorm:"type:text" json:"extra,omitempty"` // 额外上下文
-	ScriptName  string    `json:"script_name"`                      // 检测脚本名称
-	Kind        string    `json:"kind"`                             // 检查的资源类型
-	CheckDesc   string    `json:"check_desc"`                       // 检查脚本内容描述
-	Cluster     string    `json:"cluster"`                          // 检查集群
-	Namespace   string    `json:"namespace"`                        // 资源命名空间
-	Name        string    `json:"name"`                             // 资源名称
+	RecordID    uint      `json:"record_id"`                              // 关联的巡检执行记录ID
+	EventStatus string    `json:"event_status"`                           // 事件状态（如“正常”、“失败”）
+	EventMsg    string    `json:"event_msg"`                              // 事件消息
+	Extra       string    `gorm:"type:text" json:"extra,omitempty"`       // 额外上下文
+	ScriptName  string    `json:"script_name"`                            // 检测脚本名称
+	Kind        string    `json:"kind"`                                   // 检查的资源类型
+	CheckDesc   string    `json:"check_desc"`                             // 检查脚本内容描述
+	Cluster     string    `json:"cluster"`                                // 检查集群
+	Namespace   string    `json:"namespace"`                              // 资源命名空间
+	Name        string    `json:"name"`                                   // 资源名称
+	Severity    string    `gorm:"index" json:"severity"`                  // 严重级别 info/warning/critical
+	Categories  string    `json:"categories,omitempty"`                   // 分类标签，逗号分隔
+	Remediation string    `gorm:"type:text" json:"remediation,omitempty"` // 修复建议
+	DocURL      string    `json:"doc_url,omitempty"`                      // 参考文档链接
 	CreatedAt   time.Time `json:"created_at,omitempty" gorm:"<-:create"`
 	UpdatedAt   time.Time `json:"updated_at,omitempty"`  // Automatically managed by GORM for update time
 	ScheduleID  *uint     `json:"schedule_id,omitempty"` // 关联的定时任务ID
@@ -50,3 +56,73 @@ func (c *InspectionCheckEvent) GetOne(params *dao.Params, queryFuncs ...func(*go
 func (c *InspectionCheckEvent) BatchSave(params *dao.Params, events []*InspectionCheckEvent, batchSize int, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
 	return dao.GenericBatchSave(params, events, batchSize, queryFuncs...)
 }
+
+// DefaultSeverityWeights 未配置权重时各严重级别的权重
+var DefaultSeverityWeights = map[constants.LuaEventSeverity]int{
+	constants.LuaEventSeverityInfo:     1,
+	constants.LuaEventSeverityWarning:  3,
+	constants.LuaEventSeverityCritical: 10,
+}
+
+// NormalizeSeverity 规范化严重级别，兼容中文及常见别名，无法识别时返回 fallback
+func NormalizeSeverity(severity string, fallback constants.LuaEventSeverity) constants.LuaEventSeverity {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "info", "low", "提示", "低":
+		return constants.LuaEventSeverityInfo
+	case "warning", "warn", "medium", "警告", "中":
+		return constants.LuaEventSeverityWarning
+	case "critical", "high", "error", "严重", "高":
+		return constants.LuaEventSeverityCritical
+	}
+	return fallback
+}
+
+// SeverityRank 严重级别排序值，级别越高值越大，未设置时返回 0
+func SeverityRank(severity string) int {
+	switch NormalizeSeverity(severity, "") {
+	case constants.LuaEventSeverityInfo:
+		return 1
+	case constants.LuaEventSeverityWarning:
+		return 2
+	case constants.LuaEventSeverityCritical:
+		return 3
+	}
+	return 0
+}
+
+// Key 事件的比对键，由检测脚本、资源类型、命名空间及资源名组成，用于跨巡检记录比对同一问题
+func (c *InspectionCheckEvent) Key() string {
+	return strings.Join([]string{c.ScriptName, c.Kind, c.Namespace, c.Name}, "|")
+}
+
+// DiffCheckEvents 比对两次巡检的失败事件，返回新增、已恢复及持续存在的失败事件
+// 新增及持续存在的事件取自 current，已恢复的事件取自 base，同一比对键只保留第一条
+func DiffCheckEvents(base, current []*InspectionCheckEvent) (added, resolved, persisting []*InspectionCheckEvent) {
+	baseKeys := make(map[string]bool, len(base))
+	for _, e := range base {
+		baseKeys[e.Key()] = true
+	}
+	currentKeys := make(map[string]bool, len(current))
+	for _, e := range current {
+		key := e.Key()
+		if currentKeys[key] {
+			continue
+		}
+		currentKeys[key] = true
+		if baseKeys[key] {
+			persisting = append(persisting, e)
+		} else {
+			added = append(added, e)
+		}
+	}
+	seen := map[string]bool{}
+	for _, e := range base {
+		key := e.Key()
+		if currentKeys[key] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		resolved = append(resolved, e)
+	}
+	return added, resolved, persisting
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/weibaohui/k8m/pkg/constants"
+)
+
+func TestSeverityFilterAndWeight(t *testing.T) {
+	if got := NormalizeSeverity("严重", constants.LuaEventSeverityInfo); got != constants.LuaEventSeverityCritical {
+		t.Fatalf("NormalizeSeverity(严重) = %s", got)
+	}
+	if got := NormalizeSeverity("unknown", constants.LuaEventSeverityWarning); got != constants.LuaEventSeverityWarning {
+		t.Fatalf("NormalizeSeverity fallback = %s", got)
+	}
+
+	s := &InspectionSchedule{MinSeverity: "warning", SeverityWeights: "critical=20"}
+	if s.MatchSeverity("info") || !s.MatchSeverity("warning") || !s.MatchSeverity("critical") {
+		t.Fatal("MatchSeverity does not honor MinSeverity")
+	}
+	if w := s.SeverityWeight("critical"); w != 20 {
+		t.Fatalf("configured weight = %d, want 20", w)
+	}
+	if w := s.SeverityWeight("warning"); w != DefaultSeverityWeights[constants.LuaEventSeverityWarning] {
+		t.Fatalf("default weight = %d", w)
+	}
+	if !(&InspectionSchedule{}).MatchSeverity("") {
+		t.Fatal("schedule without MinSeverity should match all events")
+	}
+}
+
+func TestValidateSeverity(t *testing.T) {
+	valid := &InspectionSchedule{MinSeverity: "warning", SeverityWeights: "critical=20, 警告=5,info=0"}
+	if err := valid.ValidateSeverity(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, s := range []*InspectionSchedule{
+		{MinSeverity: "urgent"},
+		{SeverityWeights: "critical=high"},
+		{SeverityWeights: "fatal=10"},
+		{SeverityWeights: "critical"},
+		{SeverityWeights: "info=-1"},
+	} {
+		if err := s.ValidateSeverity(); err == nil {
+			t.Errorf("expected error for min=%q weights=%q", s.MinSeverity, s.SeverityWeights)
+		}
+	}
+}
+
+func TestDiffCheckEvents(t *testing.T) {
+	ev := func(script, ns, name string) *InspectionCheckEvent {
+		return &InspectionCheckEvent{ScriptName: script, Kind: "Pod", Namespace: ns, Name: name}
+	}
+	base := []*InspectionCheckEvent{ev("pod", "default", "a"), ev("pod", "default", "b"), ev("pod", "default", "b")}
+	current := []*InspectionCheckEvent{ev("pod", "default", "b"), ev("pod", "kube-system", "a")}
+
+	added, resolved, persisting := DiffCheckEvents(base, current)
+	if len(added) != 1 || added[0].Namespace != "kube-system" {
+		t.Fatalf("added = %v", added)
+	}
+	if len(resolved) != 1 || resolved[0].Name != "a" || resolved[0].Namespace != "default" {
+		t.Fatalf("resolved = %v", resolved)
+	}
+	if len(persisting) != 1 || persisting[0].Name != "b" {
+		t.Fatalf("persisting = %v", persisting)
+	}
+}
@@ -26,6 +26,7 @@ type InspectionRecord struct {
 	StartTime    time.Time  `json:"start_time"`
 	EndTime      *time.Time `json:"end_time,omitempty"`
 	ErrorCount   int        `json:"error_count"`
+	Score        int        `json:"score"`                                 // 按严重级别加权的失败分数
 	AISummary    string     `gorm:"type:text" json:"ai_summary,omitempty"` // AI生成的巡检总结
 	AISummaryErr string     `json:"ai_summary_err,omitempty"`              // AI生成错误
 	ResultRaw    string     `gorm:"type:text" json:"result_raw,omitempty"` // AI总结前的原始巡检结果，JSON字符串格式
@@ -1,11 +1,15 @@
 package models
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/robfig/cron/v3"
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/constants"
 	"gorm.io/gorm"
 )
 
@@ -26,6 +30,10 @@ type InspectionSchedule struct {
 	Enabled          bool         `json:"enabled"`                             // 是否启用该任务
 	AIEnabled        bool         `json:"ai_enabled"`                          // 是否启用AI总结功能
 	AIPromptTemplate string       `gorm:"type:text" json:"ai_prompt_template"` // AI总结提示词模板
+	MinSeverity      string       `json:"min_severity"`                        // 最低关注级别，低于该级别的失败事件不计入错误数、汇总及推送
+	SeverityWeights  string       `json:"severity_weights"`                    // 严重级别权重，如 critical=10,warning=3,info=1，为空使用默认权重
+	Concurrency      int          `json:"concurrency"`                         // 并发数，同时巡检的集群数，以及所有集群同时运行的脚本总数，0 使用默认值
+	PushDeltaOnly    bool         `json:"push_delta_only"`                     // 仅推送与上次巡检相比新增及恢复的问题，无变化时不推送
 	CronRunID        cron.EntryID `json:"cron_run_id"`                         // cron 运行ID，可用于删除
 	LastRunTime      *time.Time   `json:"last_run_time"`                       // 上次运行时间
 	ErrorCount       int          `json:"error_count"`                         // 错误次数
@@ -53,3 +61,64 @@ func (c *InspectionSchedule) Delete(params *dao.Params, ids string, queryFuncs .
 func (c *InspectionSchedule) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*InspectionSchedule, error) {
 	return dao.GenericGetOne(params, c, queryFuncs...)
 }
+
+// DefaultInspectionConcurrency 巡检计划未设置并发数时的默认并发数
+const DefaultInspectionConcurrency = 4
+
+// maxInspectionConcurrency 并发数上限，避免对 API Server 造成过大压力
+const maxInspectionConcurrency = 32
+
+// GetConcurrency 获取巡检并发数，分别限制同时巡检的集群数及所有集群同时运行的脚本总数
+func (c *InspectionSchedule) GetConcurrency() int {
+	switch {
+	case c.Concurrency <= 0:
+		return DefaultInspectionConcurrency
+	case c.Concurrency > maxInspectionConcurrency:
+		return maxInspectionConcurrency
+	}
+	return c.Concurrency
+}
+
+// MatchSeverity 失败事件的严重级别是否达到计划的最低关注级别
+func (c *InspectionSchedule) MatchSeverity(severity string) bool {
+	if c.MinSeverity == "" {
+		return true
+	}
+	return SeverityRank(severity) >= SeverityRank(c.MinSeverity)
+}
+
+// ValidateSeverity 校验最低关注级别及严重级别权重配置
+// 级别需为 critical、warning、info（或其别名），权重需为非负整数
+func (c *InspectionSchedule) ValidateSeverity() error {
+	if strings.TrimSpace(c.MinSeverity) != "" && NormalizeSeverity(c.MinSeverity, "") == "" {
+		return fmt.Errorf("未知的最低关注级别: %s", c.MinSeverity)
+	}
+	for _, item := range strings.Split(c.SeverityWeights, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("严重级别权重格式错误: %s，应为 级别=权重", item)
+		}
+		if NormalizeSeverity(k, "") == "" {
+			return fmt.Errorf("严重级别权重中包含未知级别: %s", strings.TrimSpace(k))
+		}
+		if w, err := strconv.Atoi(strings.TrimSpace(v)); err != nil || w < 0 {
+			return fmt.Errorf("严重级别 %s 的权重必须为非负整数: %s", strings.TrimSpace(k), strings.TrimSpace(v))
+		}
+	}
+	return nil
+}
+
+// SeverityWeight 获取严重级别在该计划中的权重
+func (c *InspectionSchedule) SeverityWeight(severity string) int {
+	sev := NormalizeSeverity(severity, constants.LuaEventSeverityWarning)
+	for _, item := range strings.Split(c.SeverityWeights, ",") {
+		k, v, ok := strings.Cut(item, "=")
+		if ok && NormalizeSeverity(k, "") == sev {
+			return utils.ToInt(strings.TrimSpace(v))
+		}
+	}
+	return DefaultSeverityWeights[sev]
+}
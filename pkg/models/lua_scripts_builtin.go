@@ -5,7 +5,7 @@ import (
 )
 
 // BuiltinLuaScriptsVersion 统一管理所有内置脚本的版本号
-const BuiltinLuaScriptsVersion = "v1"
+const BuiltinLuaScriptsVersion = "v2"
 
 // BuiltinLuaScripts 内置检查脚本列表
 var BuiltinLuaScripts = []InspectionLuaScript{
@@ -1521,3 +1521,54 @@ print("\n✅ Pod 资源用量检查完成")
 		`,
 	},
 }
+
+// builtinLuaScriptMeta 内置脚本的严重级别、分类、修复建议及文档链接，按 ScriptCode 对应
+var builtinLuaScriptMeta = map[string]InspectionLuaScript{
+	"Builtin_Service_001": {Severity: "warning", Categories: "reliability,network", Remediation: "检查 Service 的 spec.selector 是否与目标 Pod 的标签一致，或确认后端工作负载已正常运行。", DocURL: "https://kubernetes.io/docs/concepts/services-networking/service/"},
+	"Builtin_ConfigMap_002": {Severity: "info", Categories: "cost,hygiene", Remediation: "确认 ConfigMap 不再使用后删除，避免配置堆积。", DocURL: "https://kubernetes.io/docs/concepts/configuration/configmap/"},
+	"Builtin_ConfigMap_003": {Severity: "info", Categories: "hygiene", Remediation: "补充 data 或 binaryData 内容，或删除无用的空 ConfigMap。", DocURL: "https://kubernetes.io/docs/concepts/configuration/configmap/"},
+	"Builtin_ConfigMap_004": {Severity: "warning", Categories: "reliability", Remediation: "将大体积配置拆分，或改用存储卷挂载，ConfigMap 单个对象不能超过 1MB。", DocURL: "https://kubernetes.io/docs/concepts/configuration/configmap/"},
+	"Builtin_Deployment_005": {Severity: "critical", Categories: "reliability", Remediation: "查看 Deployment 事件及 Pod 状态，排查镜像拉取、调度或探针失败导致的副本不足。", DocURL: "https://kubernetes.io/docs/concepts/workloads/controllers/deployment/"},
+	"Builtin_CronJob_006": {Severity: "warning", Categories: "reliability", Remediation: "检查 CronJob 的调度表达式、挂起状态及最近 Job 的执行结果。", DocURL: "https://kubernetes.io/docs/concepts/workloads/controllers/cron-jobs/"},
+	"Builtin_Gateway_007": {Severity: "warning", Categories: "network", Remediation: "确认 Gateway 引用的 GatewayClass 存在且已被控制器接受。", DocURL: "https://gateway-api.sigs.k8s.io/api-types/gateway/"},
+	"Builtin_GatewayClass_008": {Severity: "warning", Categories: "network", Remediation: "确认 GatewayClass 对应的控制器已安装并接受该 GatewayClass。", DocURL: "https://gateway-api.sigs.k8s.io/api-types/gatewayclass/"},
+	"Builtin_HPA_Condition_009": {Severity: "warning", Categories: "scalability", Remediation: "根据 HPA Condition 排查指标采集（metrics-server）或扩缩容限制问题。", DocURL: "https://kubernetes.io/docs/tasks/run-application/horizontal-pod-autoscale/"},
+	"Builtin_HPA_ScaleTargetRef_010": {Severity: "critical", Categories: "scalability", Remediation: "修正 HPA 的 scaleTargetRef，使其指向存在的工作负载。", DocURL: "https://kubernetes.io/docs/tasks/run-application/horizontal-pod-autoscale/"},
+	"Builtin_HPA_Resource_011": {Severity: "warning", Categories: "scalability", Remediation: "为目标工作负载的容器设置 CPU/内存 requests，HPA 依赖 requests 计算使用率。", DocURL: "https://kubernetes.io/docs/tasks/run-application/horizontal-pod-autoscale/"},
+	"Builtin_HTTPRoute_Backend_012": {Severity: "critical", Categories: "network", Remediation: "确认 HTTPRoute 引用的后端 Service 及端口存在。", DocURL: "https://gateway-api.sigs.k8s.io/api-types/httproute/"},
+	"Builtin_HTTPRoute_Backend_013": {Severity: "critical", Categories: "network", Remediation: "确认 HTTPRoute 引用的后端 Service 及端口存在。", DocURL: "https://gateway-api.sigs.k8s.io/api-types/httproute/"},
+	"Builtin_HTTPRoute_Gateway_014": {Severity: "warning", Categories: "network", Remediation: "确认 HTTPRoute 引用的 Gateway 存在，且 Gateway 的 allowedRoutes 允许该命名空间。", DocURL: "https://gateway-api.sigs.k8s.io/api-types/httproute/"},
+	"Builtin_Ingress_015": {Severity: "warning", Categories: "network", Remediation: "检查 Ingress 的 IngressClass、后端 Service 及 TLS Secret 是否存在。", DocURL: "https://kubernetes.io/docs/concepts/services-networking/ingress/"},
+	"Builtin_Job_016": {Severity: "warning", Categories: "reliability", Remediation: "查看失败 Job 的 Pod 日志，修复后清理或重新运行。", DocURL: "https://kubernetes.io/docs/concepts/workloads/controllers/job/"},
+	"Builtin_MutatingWebhook_017": {Severity: "critical", Categories: "security,reliability", Remediation: "确认 Webhook 引用的 Service 存在且可用，不可用的 Webhook 可能阻塞资源创建。", DocURL: "https://kubernetes.io/docs/reference/access-authn-authz/extensible-admission-controllers/"},
+	"Builtin_NetworkPolicy_018": {Severity: "warning", Categories: "security,network", Remediation: "确认 NetworkPolicy 的 podSelector 能匹配到 Pod，避免策略未生效。", DocURL: "https://kubernetes.io/docs/concepts/services-networking/network-policies/"},
+	"Builtin_Node_019": {Severity: "critical", Categories: "reliability", Remediation: "排查节点 NotReady 或压力状态，检查 kubelet、容器运行时及节点资源。", DocURL: "https://kubernetes.io/docs/concepts/architecture/nodes/"},
+	"Builtin_Pod_020": {Severity: "critical", Categories: "reliability", Remediation: "根据 Pod 状态及事件排查 CrashLoopBackOff、镜像拉取失败或调度失败等问题。", DocURL: "https://kubernetes.io/docs/tasks/debug/debug-application/debug-pods/"},
+	"Builtin_PVC_021": {Severity: "warning", Categories: "storage", Remediation: "检查 PVC 的 StorageClass 及存储供应器，确认卷能正常绑定。", DocURL: "https://kubernetes.io/docs/concepts/storage/persistent-volumes/"},
+	"Builtin_ReplicaSet_022": {Severity: "warning", Categories: "reliability", Remediation: "查看 ReplicaSet 事件，排查副本无法创建的原因。", DocURL: "https://kubernetes.io/docs/concepts/workloads/controllers/replicaset/"},
+	"Builtin_Security_SA_023": {Severity: "warning", Categories: "security", Remediation: "为工作负载创建专用 ServiceAccount，并按最小权限授权。", DocURL: "https://kubernetes.io/docs/concepts/security/service-accounts/"},
+	"Builtin_Security_RoleBinding_024": {Severity: "critical", Categories: "security", Remediation: "移除角色中的通配符权限，按最小权限原则授予具体资源和动词。", DocURL: "https://kubernetes.io/docs/concepts/security/rbac-good-practices/"},
+	"Builtin_Security_Pod_025": {Severity: "warning", Categories: "security", Remediation: "为 Pod 设置 securityContext，如 runAsNonRoot、禁止特权及只读根文件系统。", DocURL: "https://kubernetes.io/docs/tasks/configure-pod-container/security-context/"},
+	"Builtin_StatefulSet_026": {Severity: "critical", Categories: "reliability", Remediation: "检查 StatefulSet 的 Pod 状态及存储卷，排查副本未就绪原因。", DocURL: "https://kubernetes.io/docs/concepts/workloads/controllers/statefulset/"},
+	"Builtin_StorageClass_027": {Severity: "warning", Categories: "storage", Remediation: "确认集群只有一个默认 StorageClass，且供应器可用。", DocURL: "https://kubernetes.io/docs/concepts/storage/storage-classes/"},
+	"Builtin_PV_028": {Severity: "warning", Categories: "storage", Remediation: "检查 PV 的状态，清理 Released/Failed 状态的卷。", DocURL: "https://kubernetes.io/docs/concepts/storage/persistent-volumes/"},
+	"Builtin_PVC_029": {Severity: "warning", Categories: "storage", Remediation: "检查 PVC 绑定状态及容量使用情况。", DocURL: "https://kubernetes.io/docs/concepts/storage/persistent-volumes/"},
+	"Builtin_ValidatingWebhook_030": {Severity: "critical", Categories: "security,reliability", Remediation: "确认 Webhook 引用的 Service 存在且可用，不可用的 Webhook 可能阻塞资源变更。", DocURL: "https://kubernetes.io/docs/reference/access-authn-authz/extensible-admission-controllers/"},
+	"Builtin_Pod_Log_Error_031": {Severity: "warning", Categories: "reliability", Remediation: "根据日志中的错误信息排查应用问题。", DocURL: "https://kubernetes.io/docs/tasks/debug/debug-application/debug-running-pod/"},
+	"Builtin_Pod_ResourceUsage_032": {Severity: "info", Categories: "cost,scalability", Remediation: "根据实际用量调整容器的 requests/limits。", DocURL: "https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/"},
+}
+
+// builtinLuaScriptsWithMeta 返回补充了元数据的内置脚本列表
+func builtinLuaScriptsWithMeta() []InspectionLuaScript {
+	scripts := make([]InspectionLuaScript, 0, len(BuiltinLuaScripts))
+	for _, script := range BuiltinLuaScripts {
+		if meta, ok := builtinLuaScriptMeta[script.ScriptCode]; ok {
+			script.Severity = meta.Severity
+			script.Categories = meta.Categories
+			script.Remediation = meta.Remediation
+			script.DocURL = meta.DocURL
+		}
+		scripts = append(scripts, script)
+	}
+	return scripts
+}
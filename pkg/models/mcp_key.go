@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/weibaohui/k8m/internal/dao"
@@ -10,16 +12,59 @@ import (
 
 // McpKey MCP访问密钥
 type McpKey struct {
-	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
-	Username    string    `gorm:"index;not null" json:"username,omitempty"` // 所属用户
-	McpKey      string    `gorm:"type:text" json:"mcp_key,omitempty"`       // MCP密钥值
-	Description string    `json:"description,omitempty"`                    // 描述信息
-	Enabled     bool      `gorm:"default:true" json:"enabled,omitempty"`    // 是否启用
-	Jwt         string    `gorm:"type:text" json:"jwt"`                     //  JWT
-	LastUsedAt  time.Time `json:"last_used_at,omitempty"`                   // 最后使用时间
-	CreatedAt   time.Time `json:"created_at,omitempty" gorm:"<-:create"`
-	UpdatedAt   time.Time `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
-	CreatedBy   string    `json:"created_by,omitempty"` // 创建者
+	ID          uint       `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Username    string     `gorm:"index;not null" json:"username,omitempty"` // 所属用户
+	McpKey      string     `gorm:"type:text" json:"mcp_key,omitempty"`       // MCP密钥值
+	Description string     `json:"description,omitempty"`                    // 描述信息
+	Enabled     bool       `gorm:"default:true" json:"enabled,omitempty"`    // 是否启用
+	Jwt         string     `gorm:"type:text" json:"jwt"`                     //  JWT
+	LastUsedAt  time.Time  `json:"last_used_at,omitempty"`                   // 最后使用时间
+	ToolMode    string     `json:"tool_mode,omitempty"`                      // 工具过滤模式：allow 仅允许 Tools 中的工具，deny 禁止 Tools 中的工具，空为不限制
+	Tools       string     `gorm:"type:text" json:"tools,omitempty"`         // 工具名称，逗号分隔
+	ReadOnly    bool       `json:"read_only"`                                // 只读密钥，只能调用非变更类工具
+	Clusters    string     `gorm:"type:text" json:"clusters,omitempty"`      // 允许访问的集群，逗号分隔，空为不限制
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`                     // 过期时间，空为永不过期
+	CreatedAt   time.Time  `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt   time.Time  `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
+	CreatedBy   string     `json:"created_by,omitempty"` // 创建者
+}
+
+// MCP密钥工具过滤模式
+const (
+	McpKeyToolModeAllow = "allow"
+	McpKeyToolModeDeny  = "deny"
+)
+
+// Expired 密钥是否已过期
+func (c *McpKey) Expired() bool {
+	return c.ExpiresAt != nil && !c.ExpiresAt.IsZero() && time.Now().After(*c.ExpiresAt)
+}
+
+// ClusterList 允许访问的集群列表，为空表示不限制
+func (c *McpKey) ClusterList() []string {
+	return splitList(c.Clusters)
+}
+
+// AllowCluster 密钥是否允许访问该集群
+func (c *McpKey) AllowCluster(cluster string) bool {
+	clusters := c.ClusterList()
+	return len(clusters) == 0 || slices.Contains(clusters, cluster)
+}
+
+// CheckTool 按工具白名单/黑名单检查密钥是否允许调用该工具，只读限制由调用方结合工具类型判断
+func (c *McpKey) CheckTool(toolName string) error {
+	tools := splitList(c.Tools)
+	switch c.ToolMode {
+	case McpKeyToolModeAllow:
+		if !slices.Contains(tools, toolName) {
+			return fmt.Errorf("MCP密钥未授权调用工具 %s", toolName)
+		}
+	case McpKeyToolModeDeny:
+		if slices.Contains(tools, toolName) {
+			return fmt.Errorf("MCP密钥禁止调用工具 %s", toolName)
+		}
+	}
+	return nil
 }
 
 func (c *McpKey) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*McpKey, int64, error) {
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMcpKeyRestrictions(t *testing.T) {
+	k := &McpKey{ToolMode: McpKeyToolModeAllow, Tools: "list_k8s_resource, get_pod_logs", Clusters: "a/ctx"}
+	if err := k.CheckTool("get_pod_logs"); err != nil {
+		t.Fatalf("allowed tool rejected: %v", err)
+	}
+	if err := k.CheckTool("delete_k8s_resource"); err == nil {
+		t.Fatal("tool outside allowlist accepted")
+	}
+	if !k.AllowCluster("a/ctx") || k.AllowCluster("b/ctx") {
+		t.Fatal("cluster restriction not applied")
+	}
+
+	k = &McpKey{ToolMode: McpKeyToolModeDeny, Tools: "delete_k8s_resource"}
+	if err := k.CheckTool("delete_k8s_resource"); err == nil {
+		t.Fatal("denied tool accepted")
+	}
+	if !k.AllowCluster("any") {
+		t.Fatal("unrestricted key rejected cluster")
+	}
+
+	past := time.Now().Add(-time.Minute)
+	if k.Expired() {
+		t.Fatal("key without expiry reported expired")
+	}
+	k.ExpiresAt = &past
+	if !k.Expired() {
+		t.Fatal("expired key not detected")
+	}
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/weibaohui/k8m/internal/dao"
@@ -11,13 +13,148 @@ import (
 // MCPServerConfig MCP服务器配置
 type MCPServerConfig struct {
 	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
-	URL       string    `gorm:"not null" json:"url,omitempty"`
+	URL       string    `json:"url,omitempty"`
 	Name      string    `gorm:"uniqueIndex;not null;type:varchar(255)" json:"name,omitempty"`
 	Enabled   bool      `gorm:"default:false" json:"enabled,omitempty"`
+	Transport string    `gorm:"default:sse" json:"transport,omitempty"` // 传输方式：sse、streamable_http、stdio
+	Headers   string    `gorm:"type:text" json:"headers,omitempty"`     // 自定义请求头，每行一个 Key: Value
+	Token     string    `gorm:"type:text" json:"token,omitempty"`       // Bearer 令牌，设置后替代默认的用户令牌
+	Command   string    `json:"command,omitempty"`                      // stdio 启动命令
+	Args      string    `gorm:"type:text" json:"args,omitempty"`        // stdio 启动参数，每行一个
+	Env       string    `gorm:"type:text" json:"env,omitempty"`         // stdio 环境变量，每行一个 KEY=VALUE
+	Timeout   int       `json:"timeout,omitempty"`                      // 工具调用超时时间（秒），0 使用默认值
 	CreatedAt time.Time `json:"created_at,omitempty" gorm:"<-:create"`
 	UpdatedAt time.Time `json:"updated_at,omitempty"`
 }
 
+// MCP服务器传输方式
+const (
+	MCPTransportSSE            = "sse"
+	MCPTransportStreamableHTTP = "streamable_http"
+	MCPTransportStdio          = "stdio"
+)
+
+// DefaultMCPCallTimeout 未设置超时时间时单次工具调用的超时时间
+const DefaultMCPCallTimeout = 2 * time.Minute
+
+// Validate 按传输方式校验必填项
+func (c *MCPServerConfig) Validate() error {
+	switch c.Transport {
+	case "", MCPTransportSSE, MCPTransportStreamableHTTP:
+		if c.URL == "" {
+			return fmt.Errorf("MCP服务器[%s]未设置URL", c.Name)
+		}
+	case MCPTransportStdio:
+		if c.Command == "" {
+			return fmt.Errorf("MCP服务器[%s]未设置启动命令", c.Name)
+		}
+	default:
+		return fmt.Errorf("不支持的传输方式: %s", c.Transport)
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("超时时间不能小于0")
+	}
+	return nil
+}
+
+// HeaderMap 解析自定义请求头
+func (c *MCPServerConfig) HeaderMap() map[string]string {
+	headers := map[string]string{}
+	for _, line := range splitLines(c.Headers) {
+		if k, v, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(k) != "" {
+			headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return headers
+}
+
+// ArgList stdio 启动参数
+func (c *MCPServerConfig) ArgList() []string {
+	return splitLines(c.Args)
+}
+
+// EnvList stdio 环境变量，追加在 k8m 进程环境变量之后
+func (c *MCPServerConfig) EnvList() []string {
+	var env []string
+	for _, line := range splitLines(c.Env) {
+		if strings.Contains(line, "=") {
+			env = append(env, line)
+		}
+	}
+	return env
+}
+
+// CallTimeout 工具调用超时时间
+func (c *MCPServerConfig) CallTimeout() time.Duration {
+	if c.Timeout <= 0 {
+		return DefaultMCPCallTimeout
+	}
+	return time.Duration(c.Timeout) * time.Second
+}
+
+// MaskSecrets 对返回前端的配置脱敏：令牌、请求头的值及环境变量的值替换为脱敏占位值
+func (c *MCPServerConfig) MaskSecrets() {
+	if c.Token != "" {
+		c.Token = utils.RedactedValue
+	}
+	c.Headers = maskLines(c.Headers, ":", ": ")
+	c.Env = maskLines(c.Env, "=", "=")
+}
+
+// RestoreSecrets 保存时，提交的值仍为脱敏占位值的字段还原为已保存的原值
+func (c *MCPServerConfig) RestoreSecrets(stored *MCPServerConfig) {
+	if c.Token == utils.RedactedValue {
+		c.Token = stored.Token
+	}
+	c.Headers = restoreLines(c.Headers, stored.Headers, ":")
+	c.Env = restoreLines(c.Env, stored.Env, "=")
+}
+
+// maskLines 将每行 key<sep>value 中的 value 替换为脱敏占位值，join 为输出时 key 与占位值之间的连接符
+func maskLines(s, sep, join string) string {
+	lines := splitLines(s)
+	for i, line := range lines {
+		if k, _, ok := strings.Cut(line, sep); ok {
+			lines[i] = strings.TrimSpace(k) + join + utils.RedactedValue
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// restoreLines 每行 key<sep>value 中 value 为脱敏占位值的，按 key 还原为 stored 中的原值，原值不存在时去除该行
+func restoreLines(s, stored, sep string) string {
+	original := map[string]string{}
+	for _, line := range splitLines(stored) {
+		if k, _, ok := strings.Cut(line, sep); ok {
+			original[strings.TrimSpace(k)] = line
+		}
+	}
+	var lines []string
+	for _, line := range splitLines(s) {
+		k, v, ok := strings.Cut(line, sep)
+		if ok && strings.TrimSpace(v) == utils.RedactedValue {
+			orig, found := original[strings.TrimSpace(k)]
+			if !found {
+				continue
+			}
+			line = orig
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitLines 按行切分并去除空行
+func splitLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
 func (c *MCPServerConfig) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*MCPServerConfig, int64, error) {
 	return dao.GenericQuery(params, c, queryFuncs...)
 }
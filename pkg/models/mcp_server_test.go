@@ -0,0 +1,54 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+)
+
+func TestMCPServerConfigMaskAndRestoreSecrets(t *testing.T) {
+	stored := &MCPServerConfig{
+		Token:   "secret-token",
+		Headers: "Authorization: Bearer abc\nX-Tenant: t1",
+		Env:     "API_KEY=xyz\nDEBUG=1",
+	}
+
+	masked := *stored
+	masked.MaskSecrets()
+	for _, s := range []string{masked.Token, masked.Headers, masked.Env} {
+		if strings.Contains(s, "secret-token") || strings.Contains(s, "abc") || strings.Contains(s, "xyz") {
+			t.Fatalf("secret leaked after masking: %q", s)
+		}
+	}
+	if masked.Token != utils.RedactedValue {
+		t.Fatalf("token not masked: %q", masked.Token)
+	}
+	if masked.Headers != "Authorization: "+utils.RedactedValue+"\nX-Tenant: "+utils.RedactedValue {
+		t.Fatalf("headers masked as %q", masked.Headers)
+	}
+	if masked.Env != "API_KEY="+utils.RedactedValue+"\nDEBUG="+utils.RedactedValue {
+		t.Fatalf("env masked as %q", masked.Env)
+	}
+
+	// 未修改的脱敏值还原为原值，修改和新增的值保持提交内容
+	submitted := masked
+	submitted.Headers += "\nX-New: v"
+	submitted.Env = "API_KEY=" + utils.RedactedValue + "\nDEBUG=0\nMISSING=" + utils.RedactedValue
+	submitted.RestoreSecrets(stored)
+	if submitted.Token != "secret-token" {
+		t.Errorf("token = %q, want stored value", submitted.Token)
+	}
+	if submitted.Headers != "Authorization: Bearer abc\nX-Tenant: t1\nX-New: v" {
+		t.Errorf("headers = %q", submitted.Headers)
+	}
+	if submitted.Env != "API_KEY=xyz\nDEBUG=0" {
+		t.Errorf("env = %q", submitted.Env)
+	}
+
+	changed := &MCPServerConfig{Token: "new-token"}
+	changed.RestoreSecrets(stored)
+	if changed.Token != "new-token" {
+		t.Errorf("changed token overwritten: %q", changed.Token)
+	}
+}
@@ -30,6 +30,7 @@ func init() {
 	_ = MigrateAIModel()
 	_ = AddBuiltinLuaScripts()
 	_ = InitBuiltinAIPrompts()
+	_ = InitBuiltinRedactionRules()
 }
 func AutoMigrate() error {
 
@@ -48,9 +49,36 @@ func AutoMigrate() error {
 	if err := dao.DB().AutoMigrate(&ClusterUserRole{}); err != nil {
 		errs = append(errs, err)
 	}
+	if err := dao.DB().AutoMigrate(&CustomRole{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&ApprovalPolicy{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&ApprovalRequest{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&ClusterAccessRequest{}); err != nil {
+		errs = append(errs, err)
+	}
 	if err := dao.DB().AutoMigrate(&OperationLog{}); err != nil {
 		errs = append(errs, err)
 	}
+	if err := dao.DB().AutoMigrate(&AuditSink{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&RetentionPolicy{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&TerminalSession{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&ChatSession{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&ChatMessage{}); err != nil {
+		errs = append(errs, err)
+	}
 	if err := dao.DB().AutoMigrate(&ShellLog{}); err != nil {
 		errs = append(errs, err)
 	}
@@ -101,6 +129,27 @@ func AutoMigrate() error {
 	if err := dao.DB().AutoMigrate(&AIModelConfig{}); err != nil {
 		errs = append(errs, err)
 	}
+	if err := dao.DB().AutoMigrate(&AIModelRoute{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&AITokenUsage{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&AITokenQuota{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&RedactionRule{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&RedactionLog{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&Runbook{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&RunbookChunk{}); err != nil {
+		errs = append(errs, err)
+	}
 	if err := dao.DB().AutoMigrate(&InspectionCheckEvent{}); err != nil {
 		errs = append(errs, err)
 	}
@@ -131,6 +180,9 @@ func AutoMigrate() error {
 	if err := dao.DB().AutoMigrate(&AIPrompt{}); err != nil {
 		errs = append(errs, err)
 	}
+	if err := dao.DB().AutoMigrate(&AIPromptVersion{}); err != nil {
+		errs = append(errs, err)
+	}
 	// 删除 user 表 name 字段，已弃用
 	if dao.DB().Migrator().HasColumn(&User{}, "Role") {
 		if err := dao.DB().Migrator().DropColumn(&User{}, "Role"); err != nil {
@@ -169,7 +221,7 @@ func AddBuiltinLuaScripts() error {
 		return err
 	}
 	// 插入最新内置脚本
-	if err := db.CreateInBatches(BuiltinLuaScripts, 100).Error; err != nil {
+	if err := db.CreateInBatches(builtinLuaScriptsWithMeta(), 100).Error; err != nil {
 		klog.Errorf("插入内置巡检脚本失败: %v", err)
 		return err
 	}
@@ -426,3 +478,20 @@ func InitBuiltinAIPrompts() error {
 
 	return nil
 }
+
+// InitBuiltinRedactionRules 初始化内置脱敏规则
+func InitBuiltinRedactionRules() error {
+	var count int64
+	dao.DB().Model(&RedactionRule{}).Where("is_builtin = ?", true).Count(&count)
+	if count > 0 {
+		return nil // 已经初始化过了
+	}
+	for _, rule := range BuiltinRedactionRules {
+		rule.Enabled = true
+		rule.IsBuiltin = true
+		if err := dao.DB().Create(&rule).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
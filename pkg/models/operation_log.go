@@ -20,6 +20,7 @@ type OperationLog struct {
 	Kind         string    `json:"kind,omitempty"`                        // 资源kind
 	Action       string    `json:"action,omitempty"`                      // 操作类型
 	Params       string    `gorm:"type:text" json:"params,omitempty"`     // 操作参数
+	Diff         string    `gorm:"type:text" json:"diff,omitempty"`       // 变更前后差异（unified diff），仅update/patch记录
 	ActionResult string    `json:"action_result,omitempty"`               // 操作结果
 	CreatedAt    time.Time `json:"created_at,omitempty" gorm:"<-:create"` // Automatically managed by GORM for creation time
 	UpdatedAt    time.Time `json:"updated_at,omitempty"`                  // Automatically managed by GORM for update time
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/redact"
+	"gorm.io/gorm"
+)
+
+// 脱敏规则类型，builtin 的 Pattern 为内置规则名称
+const (
+	RedactionRuleTypeRegex   = redact.RuleTypeRegex
+	RedactionRuleTypeKeyword = redact.RuleTypeKeyword
+	RedactionRuleTypeBuiltin = "builtin"
+)
+
+// RedactionRule 发送给大模型前的脱敏规则
+// 命中的内容替换为 [REDACTED_<类别>_<序号>] 形式的占位符，模型回答中的占位符在展示前还原
+type RedactionRule struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Name        string    `gorm:"size:100" json:"name,omitempty"`
+	RuleType    string    `gorm:"size:20" json:"rule_type,omitempty"` // regex、keyword、builtin
+	Pattern     string    `json:"pattern,omitempty"`                  // 正则表达式、关键字或内置规则名称；正则中名为 value 的分组存在时仅替换该分组
+	Category    string    `gorm:"size:50" json:"category,omitempty"`  // 类别，用于占位符及审计统计
+	Enabled     bool      `json:"enabled,omitempty"`
+	IsBuiltin   bool      `json:"is_builtin,omitempty"`
+	Description string    `json:"description,omitempty"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
+}
+
+func (c *RedactionRule) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*RedactionRule, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *RedactionRule) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, c, queryFuncs...)
+}
+
+func (c *RedactionRule) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+func (c *RedactionRule) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*RedactionRule, error) {
+	return dao.GenericGetOne(params, c, queryFuncs...)
+}
+
+// BuiltinRedactionRules 内置脱敏规则，默认启用
+var BuiltinRedactionRules = []RedactionRule{
+	{Name: "Secret 数据", RuleType: RedactionRuleTypeBuiltin, Pattern: redact.BuiltinSecret, Category: "SECRET", Description: "Secret 资源 data、stringData 中的值"},
+	{Name: "令牌及密码", RuleType: RedactionRuleTypeBuiltin, Pattern: redact.BuiltinToken, Category: "TOKEN", Description: "JWT、Bearer 令牌、云厂商 AccessKey 及 password、token 等键的值"},
+	{Name: "IP 地址", RuleType: RedactionRuleTypeBuiltin, Pattern: redact.BuiltinIP, Category: "IP", Description: "IPv4 地址"},
+	{Name: "邮箱", RuleType: RedactionRuleTypeBuiltin, Pattern: redact.BuiltinEmail, Category: "EMAIL", Description: "邮箱地址"},
+}
+
+// RedactionLog 脱敏审计，记录每次请求中各类别被脱敏的次数，不记录原值
+type RedactionLog struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	UserName   string    `gorm:"index" json:"user_name,omitempty"`
+	Feature    string    `gorm:"size:50" json:"feature,omitempty"`
+	Cluster    string    `json:"cluster,omitempty"`
+	Categories string    `json:"categories,omitempty"` // 各类别的脱敏次数，JSON 格式
+	Total      int       `json:"total,omitempty"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at,omitempty"`
+}
+
+func (c *RedactionLog) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*RedactionLog, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+// 可配置保留策略的日志表
+const (
+	RetentionTableOperationLog     = "operation_log"     // 操作日志
+	RetentionTableShellLog         = "shell_log"         // 终端命令日志
+	RetentionTableWebhookLog       = "webhook_log"       // Webhook发送记录
+	RetentionTableMCPToolLog       = "mcp_tool_log"      // MCP工具执行日志
+	RetentionTableInspectionRecord = "inspection_record" // 集群巡检记录（含脚本执行结果及检查事件）
+	RetentionTableTerminalSession  = "terminal_session"  // 终端会话录像（含录像文件）
+)
+
+// RetentionPolicy 日志表数据保留策略
+// 超过保留天数或超出保留条数的数据由Leader节点定时清理，可选在删除前归档为压缩的JSON文件
+type RetentionPolicy struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	LogTable   string     `gorm:"uniqueIndex" json:"log_table,omitempty"` // 日志表，见 RetentionTable* 常量
+	Days       int        `json:"days,omitempty"`                         // 保留天数，0表示不按时间清理
+	MaxRows    int        `json:"max_rows,omitempty"`                     // 保留的最大条数，0表示不按条数清理
+	Archive    bool       `json:"archive"`                                // 删除前是否归档
+	ArchiveDir string     `json:"archive_dir,omitempty"`                  // 归档目录，为空时使用数据库文件所在目录下的archive目录
+	Enabled    bool       `json:"enabled"`                                // 是否启用
+	LastRunAt  *time.Time `json:"last_run_at,omitempty"`                  // 最近一次执行时间
+	LastResult string     `gorm:"type:text" json:"last_result,omitempty"` // 最近一次执行结果
+	RunPending bool       `json:"run_pending"`                            // 已请求立即执行，等待Leader节点执行
+	CreatedBy  string     `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt  time.Time  `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
+}
+
+func (c *RetentionPolicy) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*RetentionPolicy, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *RetentionPolicy) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, c, queryFuncs...)
+}
+
+func (c *RetentionPolicy) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+func (c *RetentionPolicy) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*RetentionPolicy, error) {
+	return dao.GenericGetOne(params, c, queryFuncs...)
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+// 运维手册格式
+const (
+	RunbookFormatMarkdown = "markdown"
+	RunbookFormatText     = "text"
+)
+
+// Runbook 运维手册、故障复盘等团队文档
+// 保存时切分为 RunbookChunk 并建立本地索引，AI 对话时检索相关片段作为参考资料
+type Runbook struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Title       string    `gorm:"size:200" json:"title,omitempty"`
+	Format      string    `gorm:"size:20" json:"format,omitempty"` // markdown、text
+	Content     string    `gorm:"type:text" json:"content,omitempty"`
+	Tags        string    `json:"tags,omitempty"` // 标签，逗号分隔
+	Enabled     bool      `json:"enabled,omitempty"`
+	ChunkCount  int       `json:"chunk_count,omitempty"`
+	Description string    `json:"description,omitempty"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
+}
+
+func (c *Runbook) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*Runbook, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *Runbook) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, c, queryFuncs...)
+}
+
+func (c *Runbook) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+func (c *Runbook) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*Runbook, error) {
+	return dao.GenericGetOne(params, c, queryFuncs...)
+}
+
+// RunbookChunk 运维手册分块
+type RunbookChunk struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	RunbookID uint   `gorm:"index" json:"runbook_id,omitempty"`
+	Seq       int    `json:"seq,omitempty"`
+	Heading   string `json:"heading,omitempty"` // 所在章节标题
+	Content   string `gorm:"type:text" json:"content,omitempty"`
+}
@@ -6,4 +6,6 @@ type MCPToolCallResult struct {
 	Parameters any    `json:"parameters"`
 	Result     string `json:"result"`
 	Error      string `json:"error,omitempty"`
+	// Confirmation 用户对变更类工具调用的确认结果：approved、edited、rejected、timeout，无需确认时为空
+	Confirmation string `json:"confirmation,omitempty"`
 }
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+// TerminalSession 终端会话录像
+// 录像文件为 asciicast v2 格式，保存在 --terminal-record-path 目录下，表中记录会话元数据及文件路径
+type TerminalSession struct {
+	ID            uint       `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	UserName      string     `gorm:"index" json:"username,omitempty"`
+	Cluster       string     `gorm:"index" json:"cluster,omitempty"`
+	Namespace     string     `json:"namespace,omitempty"`
+	PodName       string     `gorm:"index" json:"pod_name,omitempty"`
+	ContainerName string     `json:"container_name,omitempty"`
+	NodeName      string     `json:"node_name,omitempty"` // 节点终端时记录节点名称
+	FilePath      string     `json:"-"`                   // 录像文件路径
+	Size          int64      `json:"size,omitempty"`      // 录像文件大小（字节）
+	Duration      int64      `json:"duration,omitempty"`  // 会话时长（毫秒）
+	EndedAt       *time.Time `json:"ended_at,omitempty"`  // 会话结束时间，为空表示会话进行中或异常中断
+	Error         string     `json:"error,omitempty"`     // 录制错误
+	CreatedAt     time.Time  `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt     time.Time  `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
+}
+
+func (c *TerminalSession) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*TerminalSession, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *TerminalSession) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, c, queryFuncs...)
+}
+
+func (c *TerminalSession) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+func (c *TerminalSession) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*TerminalSession, error) {
+	return dao.GenericGetOne(params, c, queryFuncs...)
+}
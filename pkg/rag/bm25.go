@@ -0,0 +1,136 @@
+package rag
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Hit 检索结果
+type Hit struct {
+	ID    uint
+	Score float64
+}
+
+type document struct {
+	id     uint
+	length int
+	tf     map[string]int
+}
+
+// Index 基于 BM25 的本地全文索引，非线程安全，由调用方加锁
+type Index struct {
+	docs     []document
+	df       map[string]int
+	totalLen int
+}
+
+// NewIndex 创建空索引
+func NewIndex() *Index {
+	return &Index{df: make(map[string]int)}
+}
+
+// Len 已索引的文档数
+func (ix *Index) Len() int {
+	return len(ix.docs)
+}
+
+// Add 添加文档
+func (ix *Index) Add(id uint, text string) {
+	tokens := Tokenize(text)
+	tf := make(map[string]int)
+	for _, t := range tokens {
+		tf[t]++
+	}
+	for t := range tf {
+		ix.df[t]++
+	}
+	ix.docs = append(ix.docs, document{id: id, length: len(tokens), tf: tf})
+	ix.totalLen += len(tokens)
+}
+
+// Search 检索与 query 最相关的 k 个文档，只返回得分大于0的结果
+func (ix *Index) Search(query string, k int) []Hit {
+	if len(ix.docs) == 0 || k <= 0 {
+		return nil
+	}
+	terms := make(map[string]struct{})
+	for _, t := range Tokenize(query) {
+		terms[t] = struct{}{}
+	}
+	n := float64(len(ix.docs))
+	avgLen := float64(ix.totalLen) / n
+	var hits []Hit
+	for _, doc := range ix.docs {
+		score := 0.0
+		for t := range terms {
+			f := float64(doc.tf[t])
+			if f == 0 {
+				continue
+			}
+			df := float64(ix.df[t])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			score += idf * f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*float64(doc.length)/avgLen))
+		}
+		if score > 0 {
+			hits = append(hits, Hit{ID: doc.id, Score: score})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits
+}
+
+// Tokenize 分词：字母数字按单词切分并转小写，中日韩文字按单字及相邻两字切分
+func Tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		if len(word) > 1 {
+			tokens = append(tokens, strings.ToLower(string(word)))
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		for i, r := range cjk {
+			tokens = append(tokens, string(r))
+			if i+1 < len(cjk) {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-':
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
@@ -0,0 +1,108 @@
+package rag
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultChunkSize 默认的分块长度（字符数）
+const DefaultChunkSize = 800
+
+// Chunk 文档分块
+type Chunk struct {
+	Heading string // 所在章节标题，多级标题以 / 连接
+	Content string
+}
+
+// Split 将文档切分为分块
+// Markdown 按标题划分章节，章节内按段落合并为不超过 size 个字符的分块；超长段落按行切分
+func Split(text string, markdown bool, size int) []Chunk {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	var chunks []Chunk
+	var headings []string
+	var section []string
+	inCode := false
+
+	flush := func() {
+		heading := strings.Join(headings, " / ")
+		for _, content := range pack(section, size) {
+			chunks = append(chunks, Chunk{Heading: heading, Content: content})
+		}
+		section = nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if markdown && strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+		}
+		if markdown && !inCode && strings.HasPrefix(trimmed, "#") {
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			title := strings.TrimSpace(trimmed[level:])
+			if level <= 6 && title != "" {
+				flush()
+				if level > len(headings) {
+					level = len(headings) + 1
+				}
+				headings = append(headings[:level-1], title)
+				continue
+			}
+		}
+		section = append(section, line)
+	}
+	flush()
+	return chunks
+}
+
+// pack 将行按段落合并为不超过 size 个字符的分块
+func pack(lines []string, size int) []string {
+	var paragraphs []string
+	var current []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				paragraphs = append(paragraphs, strings.Join(current, "\n"))
+				current = nil
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		paragraphs = append(paragraphs, strings.Join(current, "\n"))
+	}
+
+	var result []string
+	var buf strings.Builder
+	add := func(s string) {
+		if buf.Len() > 0 && utf8.RuneCountInString(buf.String())+utf8.RuneCountInString(s) > size {
+			result = append(result, buf.String())
+			buf.Reset()
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n\n")
+		}
+		buf.WriteString(s)
+	}
+	for _, p := range paragraphs {
+		if utf8.RuneCountInString(p) <= size {
+			add(p)
+			continue
+		}
+		// 超长段落按行切分，单行仍超长时按字符截断
+		for _, line := range strings.Split(p, "\n") {
+			for utf8.RuneCountInString(line) > size {
+				r := []rune(line)
+				add(string(r[:size]))
+				line = string(r[size:])
+			}
+			add(line)
+		}
+	}
+	if buf.Len() > 0 {
+		result = append(result, buf.String())
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+)
+
+const runbook = `# 数据库故障手册
+
+## 连接数耗尽
+
+现象：应用日志出现 too many connections。
+
+处理：扩大 max_connections，并排查连接泄漏。
+
+## 磁盘满
+
+` + "```" + `
+# 这不是标题
+df -h
+` + "```" + `
+清理 binlog。
+`
+
+func TestSplit(t *testing.T) {
+	chunks := Split(runbook, true, 100)
+	if len(chunks) != 2 {
+		t.Fatalf("unexpected chunks: %+v", chunks)
+	}
+	if chunks[0].Heading != "数据库故障手册 / 连接数耗尽" || !strings.Contains(chunks[0].Content, "max_connections") {
+		t.Fatalf("unexpected chunk: %+v", chunks[0])
+	}
+	if chunks[1].Heading != "数据库故障手册 / 磁盘满" || !strings.Contains(chunks[1].Content, "# 这不是标题") {
+		t.Fatalf("unexpected chunk: %+v", chunks[1])
+	}
+	long := strings.Repeat("节点NotReady排查步骤。", 30)
+	for _, c := range Split(long, false, 50) {
+		if len([]rune(c.Content)) > 50 {
+			t.Fatalf("chunk too long: %d", len([]rune(c.Content)))
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ix := NewIndex()
+	for i, c := range Split(runbook, true, 100) {
+		ix.Add(uint(i+1), c.Heading+"\n"+c.Content)
+	}
+	ix.Add(3, "Pod 处于 CrashLoopBackOff 时先查看容器日志")
+
+	hits := ix.Search("MySQL too many connections 报错", 2)
+	if len(hits) == 0 || hits[0].ID != 1 {
+		t.Fatalf("unexpected hits: %+v", hits)
+	}
+	hits = ix.Search("磁盘空间满了怎么办", 1)
+	if len(hits) != 1 || hits[0].ID != 2 {
+		t.Fatalf("unexpected hits: %+v", hits)
+	}
+	if hits = ix.Search("crashloopbackoff", 3); len(hits) != 1 || hits[0].ID != 3 {
+		t.Fatalf("unexpected hits: %+v", hits)
+	}
+}
@@ -0,0 +1,88 @@
+package redact
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 内置规则名称
+const (
+	BuiltinSecret = "secret"
+	BuiltinToken  = "token"
+	BuiltinIP     = "ip"
+	BuiltinEmail  = "email"
+)
+
+// BuiltinNames 内置规则名称列表
+var BuiltinNames = []string{BuiltinSecret, BuiltinToken, BuiltinIP, BuiltinEmail}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// ipv4Pattern 前面不能是数字或点，IP 可能紧跟在字母之后，因此不使用 \b
+	ipv4Pattern = regexp.MustCompile(`(?:^|[^0-9.])(?P<value>(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d))\b`)
+	// tokenPattern JWT、Bearer 令牌、常见云厂商 AccessKey 及 password/token/secret 等键的值
+	tokenPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]{8,}\.[A-Za-z0-9_\-]{8,}\.[A-Za-z0-9_\-]{8,}` +
+		`|(?i:bearer)\s+(?P<value>[A-Za-z0-9._~+/\-]{16,}=*)` +
+		`|\b(?:AKIA|ASIA)[A-Z0-9]{16}\b|\bLTAI[A-Za-z0-9]{12,20}\b` +
+		`|(?i:password|passwd|pwd|token|secret|api[_-]?key|access[_-]?key)["']?\s*[:=]\s*["']?(?P<value>[^\s"',}]{4,})`)
+	// secretDataKey Secret 中的 data、stringData 段
+	secretDataKey = regexp.MustCompile(`^(\s*)(data|stringData):\s*$`)
+	secretKind    = regexp.MustCompile(`(?m)^\s*kind:\s*Secret\s*$|"kind"\s*:\s*"Secret"`)
+	yamlKeyValue  = regexp.MustCompile(`^(\s*[^\s:#]+:\s*)(\S.*)$`)
+	jsonSecretKV  = regexp.MustCompile(`"(?:data|stringData)"\s*:\s*\{([^{}]*)\}`)
+	jsonValue     = regexp.MustCompile(`(:\s*")([^"]+)(")`)
+)
+
+// Builtin 获取内置规则，未知名称返回nil
+func Builtin(name string) *Rule {
+	switch name {
+	case BuiltinSecret:
+		return &Rule{Name: name, Category: "SECRET", Func: redactSecretData}
+	case BuiltinToken:
+		return &Rule{Name: name, Category: "TOKEN", Pattern: tokenPattern}
+	case BuiltinIP:
+		return &Rule{Name: name, Category: "IP", Pattern: ipv4Pattern}
+	case BuiltinEmail:
+		return &Rule{Name: name, Category: "EMAIL", Pattern: emailPattern}
+	}
+	return nil
+}
+
+// redactSecretData 替换 Secret 资源 data、stringData 中的所有值，支持 YAML 及 JSON 格式
+func redactSecretData(text string, replace func(value string) string) string {
+	if !secretKind.MatchString(text) {
+		return text
+	}
+	text = jsonSecretKV.ReplaceAllStringFunc(text, func(block string) string {
+		return jsonValue.ReplaceAllStringFunc(block, func(kv string) string {
+			m := jsonValue.FindStringSubmatch(kv)
+			return m[1] + replace(m[2]) + m[3]
+		})
+	})
+
+	lines := strings.Split(text, "\n")
+	inData := false
+	indent := ""
+	for i, line := range lines {
+		if m := secretDataKey.FindStringSubmatch(line); m != nil {
+			inData = true
+			indent = m[1]
+			continue
+		}
+		if !inData {
+			continue
+		}
+		// 缩进不大于 data 键时，data 段结束
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, indent+" ") && !strings.HasPrefix(line, indent+"\t") {
+			inData = false
+			continue
+		}
+		if m := yamlKeyValue.FindStringSubmatch(line); m != nil {
+			lines[i] = m[1] + replace(m[2])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,222 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// 规则类型
+const (
+	RuleTypeRegex   = "regex"
+	RuleTypeKeyword = "keyword"
+)
+
+// placeholderPattern 占位符格式：[REDACTED_<类别>_<序号>]
+var placeholderPattern = regexp.MustCompile(`\[REDACTED_[A-Z0-9_]+_\d+\]`)
+
+// maxPlaceholderLen 占位符的最大长度，流式还原时据此判断未闭合的 [ 是否可能是占位符的开头
+const maxPlaceholderLen = 64
+
+// Rule 脱敏规则
+// Pattern 匹配的内容被替换为占位符；Pattern 中有名为 value 的分组时仅替换该分组，用于保留 key 等上下文
+type Rule struct {
+	Name     string
+	Category string
+	Pattern  *regexp.Regexp
+	// Func 不为空时使用自定义的替换逻辑，replace 返回原值对应的占位符
+	Func func(text string, replace func(value string) string) string
+}
+
+// Compile 编译规则，keyword 类型按关键字字面量忽略大小写匹配
+func Compile(name, ruleType, pattern, category string) (*Rule, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("规则 %s 的匹配内容为空", name)
+	}
+	expr := pattern
+	if ruleType == RuleTypeKeyword {
+		expr = "(?i)" + regexp.QuoteMeta(pattern)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("规则 %s 的正则表达式无效: %v", name, err)
+	}
+	return &Rule{Name: name, Category: normalizeCategory(category, name), Pattern: re}, nil
+}
+
+// normalizeCategory 类别用于占位符，仅保留大写字母、数字及下划线
+func normalizeCategory(category, fallback string) string {
+	if category == "" {
+		category = fallback
+	}
+	category = strings.ToUpper(category)
+	var sb strings.Builder
+	for _, r := range category {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('_')
+		}
+	}
+	if sb.Len() == 0 {
+		return "TEXT"
+	}
+	return sb.String()
+}
+
+// Redactor 可还原的脱敏器
+// 同一原值在一个脱敏器内始终对应同一占位符，多轮对话共用一个脱敏器时，模型回答中引用的占位符均可还原
+type Redactor struct {
+	rules   []*Rule
+	mu      sync.RWMutex
+	forward map[string]string // 原值 -> 占位符
+	reverse map[string]string // 占位符 -> 原值
+	seq     map[string]int    // 类别 -> 已分配序号
+}
+
+// New 创建脱敏器
+func New(rules []*Rule) *Redactor {
+	return &Redactor{
+		rules:   rules,
+		forward: make(map[string]string),
+		reverse: make(map[string]string),
+		seq:     make(map[string]int),
+	}
+}
+
+// Redact 脱敏文本，stats 不为nil时累加各类别的替换次数
+func (r *Redactor) Redact(text string, stats map[string]int) string {
+	if r == nil || text == "" {
+		return text
+	}
+	for _, rule := range r.rules {
+		replace := func(value string) string {
+			if value == "" || placeholderPattern.MatchString(value) {
+				return value
+			}
+			if stats != nil {
+				stats[rule.Category]++
+			}
+			return r.placeholder(rule.Category, value)
+		}
+		if rule.Func != nil {
+			text = rule.Func(text, replace)
+			continue
+		}
+		names := rule.Pattern.SubexpNames()
+		hasValue := slices.Contains(names, "value")
+		text = rule.Pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if !hasValue {
+				return replace(match)
+			}
+			sub := rule.Pattern.FindStringSubmatchIndex(match)
+			if sub == nil {
+				return match
+			}
+			// 多个分支可能各有 value 分组，取实际匹配到的那个；都未匹配时替换整体
+			for i, name := range names {
+				if name == "value" && sub[2*i] >= 0 {
+					start, end := sub[2*i], sub[2*i+1]
+					return match[:start] + replace(match[start:end]) + match[end:]
+				}
+			}
+			return replace(match)
+		})
+	}
+	return text
+}
+
+// Restore 将文本中的占位符还原为原值，不认识的占位符保持不变
+func (r *Redactor) Restore(text string) string {
+	return r.restore(text, false)
+}
+
+// RestoreJSON 将 JSON 文本（如工具调用参数）中的占位符还原为按 JSON 字符串转义后的原值，
+// 原值中的引号、反斜杠等字符不会破坏 JSON 结构
+func (r *Redactor) RestoreJSON(text string) string {
+	return r.restore(text, true)
+}
+
+func (r *Redactor) restore(text string, escape bool) string {
+	if r == nil || !strings.Contains(text, "[REDACTED_") {
+		return text
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return placeholderPattern.ReplaceAllStringFunc(text, func(p string) string {
+		v, ok := r.reverse[p]
+		if !ok {
+			return p
+		}
+		if escape {
+			bs, _ := json.Marshal(v)
+			return string(bs[1 : len(bs)-1])
+		}
+		return v
+	})
+}
+
+func (r *Redactor) placeholder(category, value string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.forward[value]; ok {
+		return p
+	}
+	r.seq[category]++
+	p := fmt.Sprintf("[REDACTED_%s_%d]", category, r.seq[category])
+	r.forward[value] = p
+	r.reverse[p] = value
+	return p
+}
+
+// StreamRestorer 流式还原，占位符可能被拆分到多个分片中，未闭合的部分暂存到下一个分片
+type StreamRestorer struct {
+	r       *Redactor
+	pending string
+	json    bool
+}
+
+// NewStreamRestorer 创建流式还原器
+func (r *Redactor) NewStreamRestorer() *StreamRestorer {
+	return &StreamRestorer{r: r}
+}
+
+// NewJSONStreamRestorer 创建 JSON 文本的流式还原器，原值按 JSON 字符串转义
+func (r *Redactor) NewJSONStreamRestorer() *StreamRestorer {
+	return &StreamRestorer{r: r, json: true}
+}
+
+// Write 写入一个分片，返回可以输出的还原后内容
+func (s *StreamRestorer) Write(chunk string) string {
+	buf := s.pending + chunk
+	s.pending = ""
+	if i := strings.LastIndexByte(buf, '['); i >= 0 && !strings.Contains(buf[i:], "]") &&
+		len(buf)-i < maxPlaceholderLen && strings.HasPrefix("[REDACTED_", prefixOf(buf[i:], len("[REDACTED_"))) {
+		s.pending = buf[i:]
+		buf = buf[:i]
+	}
+	return s.r.restore(buf, s.json)
+}
+
+// Flush 输出暂存的内容
+func (s *StreamRestorer) Flush() string {
+	buf := s.pending
+	s.pending = ""
+	return s.r.restore(buf, s.json)
+}
+
+// Pending 是否有暂存的内容
+func (s *StreamRestorer) Pending() bool {
+	return s.pending != ""
+}
+
+func prefixOf(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,71 @@
+package retention
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// archiveWriter 将待删除的数据写入 gzip 压缩的 JSON Lines 文件
+// 每行为 {"table": 表名, "data": 行数据}，写入过程中使用 .tmp 后缀，完成后重命名
+type archiveWriter struct {
+	path string
+	file *os.File
+	gz   *gzip.Writer
+	enc  *json.Encoder
+	rows int
+}
+
+type archiveLine struct {
+	Table string         `json:"table"`
+	Data  map[string]any `json:"data"`
+}
+
+func newArchiveWriter(dir, table string) (*archiveWriter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.jsonl.gz", table, time.Now().Format("20060102-150405")))
+	file, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(file)
+	return &archiveWriter{path: path, file: file, gz: gz, enc: json.NewEncoder(gz)}, nil
+}
+
+func (w *archiveWriter) Write(table string, rows []map[string]any) error {
+	for _, row := range rows {
+		if err := w.enc.Encode(archiveLine{Table: table, Data: row}); err != nil {
+			return err
+		}
+		w.rows++
+	}
+	return nil
+}
+
+// Sync 将已写入的数据落盘，删除数据库记录前调用，保证已删除的数据均已归档
+func (w *archiveWriter) Sync() error {
+	if err := w.gz.Flush(); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+// Close 完成归档文件，未写入任何数据时删除文件
+func (w *archiveWriter) Close() (string, error) {
+	if err := w.gz.Close(); err != nil {
+		_ = w.file.Close()
+		return "", err
+	}
+	if err := w.file.Close(); err != nil {
+		return "", err
+	}
+	if w.rows == 0 {
+		return "", os.Remove(w.path + ".tmp")
+	}
+	return w.path, os.Rename(w.path+".tmp", w.path)
+}
@@ -0,0 +1,331 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/flag"
+	"github.com/weibaohui/k8m/pkg/lua"
+	"github.com/weibaohui/k8m/pkg/models"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+const (
+	purgeTaskName   = "retention_purge"
+	purgeSpec       = "@hourly"
+	pendingTaskName = "retention_pending"
+	pendingSpec     = "@every 30s"
+	purgeBatchSize  = 1000
+)
+
+var (
+	mu      sync.Mutex
+	tm      *lua.TaskManager
+	running sync.Map
+)
+
+// StartPurgeInBackground 启动日志清理定时任务，仅在Leader节点运行
+// 每次执行时从数据库读取最新的保留策略，策略变更无需通知Leader
+func StartPurgeInBackground() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if tm != nil {
+		tm.Stop()
+	}
+	tm = lua.NewTaskManager()
+	tm.Start()
+	if err := tm.Add(purgeTaskName, purgeSpec, PurgeAll); err != nil {
+		klog.Errorf("新增日志清理定时任务失败: %v", err)
+		return
+	}
+	if err := tm.Add(pendingTaskName, pendingSpec, RunPending); err != nil {
+		klog.Errorf("新增日志清理手动执行任务失败: %v", err)
+		return
+	}
+	klog.V(6).Infof("启动日志清理定时任务")
+}
+
+// RequestRun 请求立即执行保留策略
+// 与定时清理一样只在Leader节点执行：标记策略待执行，当前节点是Leader时立即执行，否则由Leader节点的轮询任务执行
+func RequestRun(id uint) error {
+	if err := dao.DB().Model(&models.RetentionPolicy{}).Where("id = ?", id).Update("run_pending", true).Error; err != nil {
+		return err
+	}
+	mu.Lock()
+	leading := tm != nil
+	mu.Unlock()
+	if leading {
+		go RunPending(context.Background())
+	}
+	return nil
+}
+
+// RunPending 执行已请求立即执行的保留策略，仅在Leader节点运行
+func RunPending(ctx context.Context) {
+	var policies []*models.RetentionPolicy
+	if err := dao.DB().Where("run_pending = ?", true).Find(&policies).Error; err != nil {
+		klog.Errorf("获取待执行的日志保留策略失败: %v", err)
+		return
+	}
+	for _, p := range policies {
+		if ctx.Err() != nil {
+			return
+		}
+		// 先清除标记，避免多次轮询重复执行
+		result := dao.DB().Model(&models.RetentionPolicy{}).Where("id = ? and run_pending = ?", p.ID, true).Update("run_pending", false)
+		if result.Error != nil || result.RowsAffected == 0 {
+			continue
+		}
+		if _, err := Run(ctx, p); err != nil {
+			klog.Errorf("清理日志表[%s]失败: %v", p.LogTable, err)
+		}
+	}
+}
+
+// StopPurgeInBackground 停止日志清理定时任务
+func StopPurgeInBackground() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if tm != nil {
+		klog.V(6).Infof("停止日志清理定时任务")
+		tm.Remove(purgeTaskName)
+		tm.Stop()
+		tm = nil
+	}
+}
+
+// PurgeAll 按所有已启用的保留策略清理数据
+func PurgeAll(ctx context.Context) {
+	m := &models.RetentionPolicy{}
+	policies, _, err := m.List(dao.BuildDefaultParams(), func(db *gorm.DB) *gorm.DB {
+		return db.Where("enabled = ?", true)
+	})
+	if err != nil {
+		klog.Errorf("获取日志保留策略失败: %v", err)
+		return
+	}
+	for _, p := range policies {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err = Run(ctx, p); err != nil {
+			klog.Errorf("清理日志表[%s]失败: %v", p.LogTable, err)
+		}
+	}
+}
+
+// Run 按保留策略清理单个日志表，并将执行结果写回策略
+// 同一日志表同时只允许一个清理任务
+func Run(ctx context.Context, p *models.RetentionPolicy) (string, error) {
+	if _, loaded := running.LoadOrStore(p.LogTable, struct{}{}); loaded {
+		return "", fmt.Errorf("日志表[%s]正在清理中", p.LogTable)
+	}
+	defer running.Delete(p.LogTable)
+
+	deleted, archive, err := purge(ctx, p)
+	result := fmt.Sprintf("删除%d条", deleted)
+	if archive != "" {
+		result += fmt.Sprintf("，归档至%s", archive)
+	}
+	if err != nil {
+		result += fmt.Sprintf("，执行失败: %v", err)
+	}
+	klog.V(4).Infof("清理日志表[%s]: %s", p.LogTable, result)
+
+	now := time.Now()
+	if saveErr := dao.DB().Model(&models.RetentionPolicy{}).Where("id = ?", p.ID).
+		Updates(map[string]any{"last_run_at": &now, "last_result": result}).Error; saveErr != nil {
+		klog.Errorf("更新日志保留策略#%d 执行结果失败: %v", p.ID, saveErr)
+	}
+	return result, err
+}
+
+// purge 分批删除超出保留范围的数据，需要归档时先将本批数据及关联表数据写入归档文件并落盘，再删除
+func purge(ctx context.Context, p *models.RetentionPolicy) (deleted int64, archive string, err error) {
+	t, ok := targets[p.LogTable]
+	if !ok {
+		return 0, "", fmt.Errorf("不支持的日志表[%s]", p.LogTable)
+	}
+	cond, args, err := condition(p, t)
+	if err != nil || cond == "" {
+		return 0, "", err
+	}
+
+	var aw *archiveWriter
+	if p.Archive {
+		if aw, err = newArchiveWriter(archiveDir(p), p.LogTable); err != nil {
+			return 0, "", err
+		}
+		defer func() {
+			path, closeErr := aw.Close()
+			archive = path
+			if err == nil {
+				err = closeErr
+			}
+		}()
+	}
+
+	for {
+		if err = ctx.Err(); err != nil {
+			return deleted, "", err
+		}
+		var rows []map[string]any
+		if err = dao.DB().Model(t.model).Where(cond, args...).Order("id asc").Limit(purgeBatchSize).Find(&rows).Error; err != nil {
+			return deleted, "", err
+		}
+		if len(rows) == 0 {
+			return deleted, "", nil
+		}
+		ids := make([]any, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row["id"])
+		}
+
+		var moves []fileMove
+		if aw != nil {
+			if t.fileColumn != "" {
+				// 归档记录中使用归档后的文件路径，文件在删除事务提交后再移动
+				if moves, err = planFileMoves(rows, t.fileColumn, filepath.Join(archiveDir(p), p.LogTable)); err != nil {
+					return deleted, "", err
+				}
+			}
+			if err = archiveRows(aw, p.LogTable, t, rows, ids); err != nil {
+				return deleted, "", err
+			}
+		}
+
+		err = dao.DB().Transaction(func(tx *gorm.DB) error {
+			for _, c := range t.children {
+				if txErr := tx.Where(c.foreignKey+" in ?", ids).Delete(c.model).Error; txErr != nil {
+					return txErr
+				}
+			}
+			return tx.Where("id in ?", ids).Delete(t.model).Error
+		})
+		if err != nil {
+			return deleted, "", err
+		}
+		if aw != nil {
+			moveFiles(moves)
+		} else if t.fileColumn != "" {
+			removeFiles(rows, t.fileColumn)
+		}
+		deleted += int64(len(ids))
+		if len(rows) < purgeBatchSize {
+			return deleted, "", nil
+		}
+	}
+}
+
+// condition 生成待清理数据的查询条件：早于保留天数，或超出保留条数
+// 保留条数按ID倒序计算，在清理开始时确定边界，清理期间新写入的数据不受影响
+func condition(p *models.RetentionPolicy, t target) (string, []any, error) {
+	var conds []string
+	var args []any
+	if p.Days > 0 {
+		conds = append(conds, "created_at < ?")
+		args = append(args, time.Now().AddDate(0, 0, -p.Days))
+	}
+	if p.MaxRows > 0 {
+		var ids []uint
+		if err := dao.DB().Model(t.model).Order("id desc").Offset(p.MaxRows).Limit(1).Pluck("id", &ids).Error; err != nil {
+			return "", nil, err
+		}
+		if len(ids) > 0 {
+			conds = append(conds, "id <= ?")
+			args = append(args, ids[0])
+		}
+	}
+	if len(conds) == 0 {
+		return "", nil, nil
+	}
+	return "(" + strings.Join(conds, " or ") + ")", args, nil
+}
+
+func archiveRows(aw *archiveWriter, table string, t target, rows []map[string]any, ids []any) error {
+	if err := aw.Write(table, rows); err != nil {
+		return err
+	}
+	for _, c := range t.children {
+		var childRows []map[string]any
+		if err := dao.DB().Model(c.model).Where(c.foreignKey+" in ?", ids).Find(&childRows).Error; err != nil {
+			return err
+		}
+		if err := aw.Write(c.name, childRows); err != nil {
+			return err
+		}
+	}
+	return aw.Sync()
+}
+
+func archiveDir(p *models.RetentionPolicy) string {
+	if p.ArchiveDir != "" {
+		return p.ArchiveDir
+	}
+	if cfg := flag.Init(); cfg.SqlitePath != "" {
+		return filepath.Join(filepath.Dir(cfg.SqlitePath), "archive")
+	}
+	return filepath.Join("data", "archive")
+}
+
+type fileMove struct {
+	src string
+	dst string
+}
+
+// planFileMoves 计算数据关联的文件在归档目录中的路径，并更新行数据中的文件路径
+func planFileMoves(rows []map[string]any, column string, dir string) ([]fileMove, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	var moves []fileMove
+	for _, row := range rows {
+		src := fileOf(row, column)
+		if src == "" {
+			continue
+		}
+		dst := filepath.Join(dir, fmt.Sprintf("%v-%s", row["id"], filepath.Base(src)))
+		moves = append(moves, fileMove{src: src, dst: dst})
+		row[column] = dst
+	}
+	return moves, nil
+}
+
+// moveFiles 将已删除数据关联的文件移动到归档目录
+func moveFiles(moves []fileMove) {
+	for _, m := range moves {
+		if err := os.Rename(m.src, m.dst); err != nil && !os.IsNotExist(err) {
+			klog.Errorf("归档文件[%s]失败: %v", m.src, err)
+		}
+	}
+}
+
+// removeFiles 删除数据关联的文件
+func removeFiles(rows []map[string]any, column string) {
+	for _, row := range rows {
+		if path := fileOf(row, column); path != "" {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				klog.Errorf("删除文件[%s]失败: %v", path, err)
+			}
+		}
+	}
+}
+
+func fileOf(row map[string]any, column string) string {
+	switch v := row[column].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package retention
+
+import (
+	"github.com/weibaohui/k8m/pkg/models"
+)
+
+// child 随主表记录一起清理的关联表
+type child struct {
+	name       string
+	model      any
+	foreignKey string
+}
+
+// target 可配置保留策略的日志表
+type target struct {
+	label      string
+	model      any
+	children   []child
+	fileColumn string // 记录关联文件路径的字段，清理时一并删除文件，需要归档时移动到归档目录
+}
+
+var targets = map[string]target{
+	models.RetentionTableOperationLog: {label: "操作日志", model: &models.OperationLog{}},
+	models.RetentionTableShellLog:     {label: "终端命令日志", model: &models.ShellLog{}},
+	models.RetentionTableWebhookLog:   {label: "Webhook发送记录", model: &models.WebhookLogRecord{}},
+	models.RetentionTableMCPToolLog:   {label: "MCP工具执行日志", model: &models.MCPToolLog{}},
+	models.RetentionTableInspectionRecord: {
+		label: "集群巡检记录",
+		model: &models.InspectionRecord{},
+		children: []child{
+			{name: "inspection_script_result", model: &models.InspectionScriptResult{}, foreignKey: "record_id"},
+			{name: "inspection_check_event", model: &models.InspectionCheckEvent{}, foreignKey: "record_id"},
+		},
+	},
+	models.RetentionTableTerminalSession: {label: "终端会话录像", model: &models.TerminalSession{}, fileColumn: "file_path"},
+}
+
+// IsSupported 判断日志表是否支持配置保留策略
+func IsSupported(table string) bool {
+	_, ok := targets[table]
+	return ok
+}
+
+// Options 可配置保留策略的日志表选项
+func Options() []map[string]string {
+	var options []map[string]string
+	for _, name := range []string{
+		models.RetentionTableOperationLog,
+		models.RetentionTableShellLog,
+		models.RetentionTableWebhookLog,
+		models.RetentionTableMCPToolLog,
+		models.RetentionTableInspectionRecord,
+		models.RetentionTableTerminalSession,
+	} {
+		options = append(options, map[string]string{"label": targets[name].label, "value": name})
+	}
+	return options
+}
@@ -1,11 +1,18 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/ai"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/constants"
 	"github.com/weibaohui/k8m/pkg/flag"
+	"github.com/weibaohui/k8m/pkg/models"
 	"k8s.io/klog/v2"
 )
 
@@ -23,6 +30,14 @@ func (c *aiService) SetVars(apikey, apiUrl, model string) {
 
 var local ai.IAI
 
+// routeClients 按功能缓存的客户端，AI功能 -> *routeEntry
+var routeClients sync.Map
+
+// routeEntry 功能路由的缓存，client 为nil表示该功能未配置路由，使用默认客户端
+type routeEntry struct {
+	client ai.IAI
+}
+
 func (c *aiService) DefaultClient() (ai.IAI, error) {
 	enable := c.IsEnabled()
 	if !enable {
@@ -46,6 +61,7 @@ func (c *aiService) ResetDefaultClient() error {
 		return fmt.Errorf("ChatGPT功能未开启")
 	}
 	local = nil
+	c.ResetRouteClients()
 	klog.V(6).Infof("AI DefaultClient Reset ")
 	return nil
 }
@@ -54,17 +70,22 @@ func (c *aiService) openAIClient() (ai.IAI, error) {
 	cfg := flag.Init()
 
 	aiProvider := ai.Provider{
-		Name:        "openai",
-		Model:       cfg.ApiModel,
-		Password:    cfg.ApiKey,
-		BaseURL:     cfg.ApiURL,
-		Temperature: 0.7,
-		TopP:        1,
-		MaxHistory:  10,
-		TopK:        0,
-		MaxTokens:   1000,
+		Name:          cfg.ApiProvider,
+		Model:         cfg.ApiModel,
+		Password:      cfg.ApiKey,
+		BaseURL:       cfg.ApiURL,
+		Engine:        cfg.ApiDeployment,
+		APIVersion:    cfg.ApiVersion,
+		ModelConfigID: cfg.ApiModelConfigID,
+		Temperature:   0.7,
+		TopP:          1,
+		MaxHistory:    10,
+		TopK:          0,
+		MaxTokens:     1000,
 	}
 	if cfg.EnableAI && cfg.UseBuiltInModel {
+		aiProvider.ModelConfigID = 0
+		aiProvider.Name = "openai"
 		aiProvider.BaseURL = c.innerApiUrl
 		aiProvider.Password = c.innerApiKey
 		aiProvider.Model = c.innerModel
@@ -84,6 +105,7 @@ func (c *aiService) openAIClient() (ai.IAI, error) {
 	}
 
 	if cfg.Debug {
+		klog.V(4).Infof("ai Provider: %v\n", aiProvider.Name)
 		klog.V(4).Infof("ai BaseURL: %v\n", aiProvider.BaseURL)
 		klog.V(4).Infof("ai Model : %v\n", aiProvider.Model)
 		klog.V(4).Infof("ai Key: %v\n", utils.MaskString(aiProvider.Password, 5))
@@ -103,15 +125,131 @@ func (c *aiService) IsEnabled() bool {
 	return enable
 }
 
-func (c *aiService) TestClient(url string, key string, model string) (ai.IAI, error) {
-	klog.V(6).Infof("TestClient url:%v key:%v model:%v\n", url, utils.MaskString(key, 5), model)
+func (c *aiService) TestClient(m *models.AIModelConfig) (ai.IAI, error) {
+	klog.V(6).Infof("TestClient provider:%v url:%v key:%v model:%v\n", m.Provider, m.ApiURL, utils.MaskString(m.ApiKey, 5), m.ApiModel)
 	aiProvider := ai.Provider{
-		Name:     "test",
-		Model:    model,
-		Password: key,
-		BaseURL:  url,
+		Name:          m.Provider,
+		Model:         m.ApiModel,
+		Password:      m.ApiKey,
+		BaseURL:       m.ApiURL,
+		Engine:        m.Deployment,
+		APIVersion:    m.ApiVersion,
+		ModelConfigID: m.ID,
+	}
+
+	aiClient := ai.NewClient(aiProvider.Name)
+	if err := aiClient.Configure(&aiProvider); err != nil {
+		return nil, err
+	}
+	return aiClient, nil
+}
+
+// WithFeature 在 ctx 中携带AI功能，ContextClient 据此选择模型
+func (c *aiService) WithFeature(ctx context.Context, feature constants.AIPromptType) context.Context {
+	return context.WithValue(ctx, constants.AIFeature, feature)
+}
+
+// ContextClient 按 ctx 中携带的AI功能获取客户端，未携带时使用默认客户端
+func (c *aiService) ContextClient(ctx context.Context) (ai.IAI, error) {
+	feature, _ := ctx.Value(constants.AIFeature).(constants.AIPromptType)
+	return c.ClientFor(feature)
+}
+
+// ClientFor 获取AI功能使用的客户端
+// 功能配置了启用的模型路由时使用路由指定的主模型及备用模型，否则或路由中的模型不可用时使用默认客户端
+func (c *aiService) ClientFor(feature constants.AIPromptType) (ai.IAI, error) {
+	if feature == "" {
+		return c.DefaultClient()
+	}
+	if !c.IsEnabled() {
+		return nil, fmt.Errorf("ChatGPT功能未开启")
+	}
+	if v, ok := routeClients.Load(feature); ok {
+		if entry := v.(*routeEntry); entry.client != nil {
+			return entry.client, nil
+		}
+		return c.DefaultClient()
+	}
+
+	entry := &routeEntry{}
+	var route models.AIModelRoute
+	err := dao.DB().Where("feature = ? and enabled = ?", feature, true).First(&route).Error
+	if err == nil {
+		entry.client, err = c.routeClient(&route)
+		if err != nil {
+			klog.Errorf("AI功能 %s 的模型路由不可用，使用默认模型: %v", feature, err)
+		}
+	}
+	routeClients.Store(feature, entry)
+	if entry.client != nil {
+		return entry.client, nil
+	}
+	return c.DefaultClient()
+}
+
+// ResetRouteClients 清空按功能缓存的客户端，模型或路由配置变更后调用
+func (c *aiService) ResetRouteClients() {
+	routeClients.Range(func(key, value any) bool {
+		routeClients.Delete(key)
+		return true
+	})
+}
+
+// routeClient 按路由创建客户端，主模型不可用时返回错误，备用模型不可用时跳过
+func (c *aiService) routeClient(route *models.AIModelRoute) (ai.IAI, error) {
+	primary, err := c.modelClient(route.ModelID)
+	if err != nil {
+		return nil, err
 	}
+	var fallbacks []ai.IAI
+	for _, id := range strings.Split(route.FallbackModelIDs, ",") {
+		if strings.TrimSpace(id) == "" {
+			continue
+		}
+		fallback, err := c.modelClient(utils.ToUInt(strings.TrimSpace(id)))
+		if err != nil {
+			klog.Errorf("AI功能 %s 的备用模型[%s]不可用: %v", route.Feature, id, err)
+			continue
+		}
+		fallbacks = append(fallbacks, fallback)
+	}
+	return ai.WithFallback(primary, time.Duration(route.Timeout)*time.Second, fallbacks...), nil
+}
 
+// modelClient 按 AIModelConfig 创建客户端
+func (c *aiService) modelClient(id uint) (ai.IAI, error) {
+	// ID 为 0 时 GetOne 会返回任意一条模型配置
+	if id == 0 {
+		return nil, fmt.Errorf("未指定模型")
+	}
+	m := &models.AIModelConfig{ID: id}
+	m, err := m.GetOne(nil)
+	if err != nil {
+		return nil, fmt.Errorf("模型[%d]不存在: %v", id, err)
+	}
+	cfg := flag.Init()
+	aiProvider := ai.Provider{
+		Name:          m.Provider,
+		Model:         m.ApiModel,
+		Password:      m.ApiKey,
+		BaseURL:       m.ApiURL,
+		Engine:        m.Deployment,
+		APIVersion:    m.ApiVersion,
+		ModelConfigID: m.ID,
+		Temperature:   0.7,
+		TopP:          1,
+		MaxHistory:    10,
+		MaxTokens:     1000,
+	}
+	if m.Temperature > 0 {
+		aiProvider.Temperature = m.Temperature
+	}
+	if m.TopP > 0 {
+		aiProvider.TopP = m.TopP
+	}
+	if cfg.MaxHistory > 0 {
+		aiProvider.MaxHistory = cfg.MaxHistory
+	}
 	aiClient := ai.NewClient(aiProvider.Name)
 	if err := aiClient.Configure(&aiProvider); err != nil {
 		return nil, err
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/ai"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+// aiUsageService 大模型 token 用量统计及配额
+// 实现 ai.UsageTracker，用量按用户、模型、AI功能、集群记录，请求前检查用户的日/月配额
+type aiUsageService struct {
+}
+
+func init() {
+	ai.RegisterUsageTracker(localAIUsageService)
+}
+
+// AIUsageReportItem 用量汇总
+type AIUsageReportItem struct {
+	Key              string  `gorm:"column:group_key" json:"key"`
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// AIUsageReportGroupBy 用量汇总支持的分组字段
+var AIUsageReportGroupBy = []string{"user_name", "model", "feature", "cluster", "provider"}
+
+// WithCluster 在 ctx 中携带AI请求相关的集群，用于用量统计
+func (s *aiUsageService) WithCluster(ctx context.Context, cluster string) context.Context {
+	if cluster == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, constants.AICluster, cluster)
+}
+
+// RecordUsage 记录一次请求的用量，费用按模型配置中的单价计算
+func (s *aiUsageService) RecordUsage(ctx context.Context, record *ai.UsageRecord) {
+	username, _ := ctx.Value(constants.JwtUserName).(string)
+	feature, _ := ctx.Value(constants.AIFeature).(constants.AIPromptType)
+	cluster, _ := ctx.Value(constants.AICluster).(string)
+	usage := &models.AITokenUsage{
+		UserName:         username,
+		Provider:         record.Provider,
+		ModelConfigID:    record.ModelConfigID,
+		Model:            record.Model,
+		Feature:          string(feature),
+		Cluster:          cluster,
+		PromptTokens:     record.PromptTokens,
+		CompletionTokens: record.CompletionTokens,
+		TotalTokens:      record.TotalTokens,
+		Stream:           record.Stream,
+		Estimated:        record.Estimated,
+	}
+	// 按处理请求的模型配置计价，不同配置可能使用同名模型但单价不同
+	var mc models.AIModelConfig
+	if record.ModelConfigID > 0 && dao.DB().Where("id = ?", record.ModelConfigID).First(&mc).Error == nil {
+		usage.Cost = (float64(record.PromptTokens)*mc.InputPrice + float64(record.CompletionTokens)*mc.OutputPrice) / 1e6
+	}
+	if err := dao.DB().Create(usage).Error; err != nil {
+		klog.Errorf("保存AI用量失败: %v", err)
+	}
+}
+
+// CheckQuota 检查用户在各周期内的用量是否超出配额
+// 同一周期内用户配额优先于用户组配额，多个用户组配额取最大值
+func (s *aiUsageService) CheckQuota(ctx context.Context) error {
+	username, _ := ctx.Value(constants.JwtUserName).(string)
+	if username == "" {
+		return nil
+	}
+	quotas, err := s.UserQuotas(username)
+	if err != nil {
+		klog.Errorf("读取用户 %s 的AI配额失败: %v", username, err)
+		return nil
+	}
+	for period, limit := range quotas {
+		used, err := s.UsedTokens(username, period)
+		if err != nil {
+			klog.Errorf("统计用户 %s 的AI用量失败: %v", username, err)
+			continue
+		}
+		if used >= limit {
+			return fmt.Errorf("AI token 用量已超出%s配额: 已使用 %d，配额 %d", periodName(period), used, limit)
+		}
+	}
+	return nil
+}
+
+// UserQuotas 获取用户生效的配额，周期 -> token 上限
+func (s *aiUsageService) UserQuotas(username string) (map[string]int64, error) {
+	groups, _ := UserService().GetGroupNames(username)
+	var list []*models.AITokenQuota
+	err := dao.DB().Where("enabled = ?", true).
+		Where(dao.DB().Where("scope = ? and target = ?", models.AITokenQuotaScopeUser, username).
+			Or("scope = ? and target in ?", models.AITokenQuotaScopeGroup, append(groups, ""))).
+		Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	userQuotas := map[string]int64{}
+	groupQuotas := map[string]int64{}
+	for _, q := range list {
+		if q.Period != models.AITokenQuotaPeriodDaily && q.Period != models.AITokenQuotaPeriodMonthly {
+			continue
+		}
+		if q.Scope == models.AITokenQuotaScopeUser {
+			userQuotas[q.Period] = q.TokenLimit
+			continue
+		}
+		if slices.Contains(groups, q.Target) && q.TokenLimit > groupQuotas[q.Period] {
+			groupQuotas[q.Period] = q.TokenLimit
+		}
+	}
+	for period, limit := range groupQuotas {
+		if _, ok := userQuotas[period]; !ok {
+			userQuotas[period] = limit
+		}
+	}
+	return userQuotas, nil
+}
+
+// UsedTokens 统计用户在当前周期内已使用的 token
+func (s *aiUsageService) UsedTokens(username, period string) (int64, error) {
+	var used int64
+	err := dao.DB().Model(&models.AITokenUsage{}).
+		Where("user_name = ? and created_at >= ?", username, periodStart(period, time.Now())).
+		Select("COALESCE(SUM(total_tokens), 0)").Scan(&used).Error
+	return used, err
+}
+
+// Report 按字段汇总用量，queryFuncs 用于限定时间范围等条件
+func (s *aiUsageService) Report(groupBy string, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*AIUsageReportItem, error) {
+	if !slices.Contains(AIUsageReportGroupBy, groupBy) {
+		return nil, fmt.Errorf("不支持的分组字段: %s", groupBy)
+	}
+	db := dao.DB().Model(&models.AITokenUsage{})
+	for _, f := range queryFuncs {
+		db = f(db)
+	}
+	var items []*AIUsageReportItem
+	err := db.Select(groupBy + " as group_key, count(*) as requests, " +
+		"COALESCE(SUM(prompt_tokens), 0) as prompt_tokens, COALESCE(SUM(completion_tokens), 0) as completion_tokens, " +
+		"COALESCE(SUM(total_tokens), 0) as total_tokens, COALESCE(SUM(cost), 0) as cost").
+		Group(groupBy).Order("total_tokens desc").Scan(&items).Error
+	return items, err
+}
+
+// periodStart 周期的起始时间
+func periodStart(period string, now time.Time) time.Time {
+	y, m, d := now.Date()
+	if period == models.AITokenQuotaPeriodMonthly {
+		return time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+}
+
+func periodName(period string) string {
+	if period == models.AITokenQuotaPeriodMonthly {
+		return "本月"
+	}
+	return "今日"
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/webhook"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+// ApprovalExecutor 审批通过后重放操作的执行器
+// ctx 中携带的是申请人身份，返回执行结果描述
+type ApprovalExecutor func(ctx context.Context, req *models.ApprovalRequest) (string, error)
+
+type approvalService struct {
+	executors     sync.Map
+	mu            sync.Mutex
+	reconcileCron *cron.Cron
+}
+
+const approvalPolicyCacheKey = "approval_policy:enabled"
+
+// RegisterExecutor 注册某类操作的执行器，由对应的controller在注册路由时调用
+func (s *approvalService) RegisterExecutor(action string, fn ApprovalExecutor) {
+	s.executors.Store(action, fn)
+}
+
+// MatchPolicy 查找操作命中的审批策略，未命中返回nil
+func (s *approvalService) MatchPolicy(cluster string, nsList []string, action string) *models.ApprovalPolicy {
+	policies, err := utils.GetOrSetCache(CacheService().CacheInstance(), approvalPolicyCacheKey, 5*time.Minute, func() ([]*models.ApprovalPolicy, error) {
+		m := &models.ApprovalPolicy{}
+		list, _, err := m.List(dao.BuildDefaultParams(), func(db *gorm.DB) *gorm.DB {
+			return db.Where("enabled = ?", true)
+		})
+		return list, err
+	})
+	if err != nil {
+		klog.Errorf("获取审批策略失败: %v", err)
+		return nil
+	}
+	for _, p := range policies {
+		if p.Match(cluster, nsList, action) {
+			return p
+		}
+	}
+	return nil
+}
+
+// ClearCache 清除审批策略缓存，策略变更后调用
+func (s *approvalService) ClearCache() {
+	utils.ClearCacheByKey(CacheService().CacheInstance(), approvalPolicyCacheKey)
+}
+
+// Submit 保存待审批请求，并通知策略中配置的webhook
+func (s *approvalService) Submit(policy *models.ApprovalPolicy, req *models.ApprovalRequest) error {
+	req.ID = 0
+	req.PolicyID = policy.ID
+	req.Status = models.ApprovalStatusPending
+	if err := req.Save(nil); err != nil {
+		return err
+	}
+	s.notify(policy.Webhooks, req, fmt.Sprintf("用户[%s]提交了审批申请#%d：集群[%s] %s %s", req.RequestedBy, req.ID, req.Cluster, req.Action, s.target(req)))
+	return nil
+}
+
+// GetPending 获取待审批的请求
+func (s *approvalService) GetPending(id uint) (*models.ApprovalRequest, error) {
+	m := &models.ApprovalRequest{}
+	req, err := m.GetOne(nil, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != models.ApprovalStatusPending {
+		return nil, fmt.Errorf("审批申请#%d 当前状态为[%s]，不可重复审批", id, req.Status)
+	}
+	return req, nil
+}
+
+// approvalExecuteTimeout 审批通过后重放操作的超时时间
+const approvalExecuteTimeout = 30 * time.Minute
+
+// approvalStaleAfter 审批通过超过该时间仍为 executing 的申请，视为执行节点重启等原因中断
+const approvalStaleAfter = approvalExecuteTimeout + 5*time.Minute
+
+// Approve 审批通过，并在后台以申请人身份重放操作
+// 重放可能耗时较长（如节点驱逐），不占用审批人的请求；执行期间状态为 executing，
+// 执行结果写回审批请求，并在操作日志中记录申请人与审批人
+func (s *approvalService) Approve(req *models.ApprovalRequest, approver, comment string) error {
+	fn, ok := s.executors.Load(req.Action)
+	if !ok {
+		return fmt.Errorf("不支持的审批操作类型[%s]", req.Action)
+	}
+	if err := s.decide(req, approver, comment, models.ApprovalStatusExecuting); err != nil {
+		return err
+	}
+	go s.execute(fn.(ApprovalExecutor), req, approver)
+	return nil
+}
+
+// execute 以申请人身份重放操作，记录执行结果
+func (s *approvalService) execute(fn ApprovalExecutor, req *models.ApprovalRequest, approver string) {
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), constants.JwtUserName, req.RequestedBy), approvalExecuteTimeout)
+	defer cancel()
+
+	var result string
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("执行发生panic: %v", r)
+			}
+		}()
+		result, err = fn(ctx, req)
+	}()
+
+	req.Status = models.ApprovalStatusExecuted
+	req.Result = result
+	log := &models.OperationLog{
+		Action:       req.Action,
+		Cluster:      req.Cluster,
+		Kind:         req.Kind,
+		Group:        req.Group,
+		Name:         req.Name,
+		Namespace:    req.Namespace,
+		UserName:     req.RequestedBy,
+		Params:       fmt.Sprintf("审批申请#%d，审批人[%s]", req.ID, approver),
+		ActionResult: "success",
+	}
+	if roles, roleErr := UserService().GetRolesByUserName(req.RequestedBy); roleErr == nil {
+		log.Role = strings.Join(roles, ",")
+	}
+	if err != nil {
+		req.Status = models.ApprovalStatusFailed
+		req.Result = strings.TrimSpace(result + "\n" + err.Error())
+		log.ActionResult = err.Error()
+		klog.Warningf("审批申请#%d 执行失败: %v", req.ID, err)
+	}
+	OperationLogService().Add(log)
+
+	if saveErr := dao.DB().Model(&models.ApprovalRequest{}).Where("id = ?", req.ID).
+		Updates(map[string]any{"status": req.Status, "result": req.Result}).Error; saveErr != nil {
+		klog.Errorf("更新审批申请#%d 执行结果失败: %v", req.ID, saveErr)
+	}
+	s.notifyDecision(req)
+}
+
+// FailStaleExecutions 将执行中断的申请标记为失败
+// 执行在审批所在节点的后台进行且受 approvalExecuteTimeout 限制，超过 approvalStaleAfter 仍为 executing 说明执行节点已重启或退出
+func (s *approvalService) FailStaleExecutions() {
+	var stale []*models.ApprovalRequest
+	err := dao.DB().Where("status = ? AND decided_at < ?", models.ApprovalStatusExecuting, time.Now().Add(-approvalStaleAfter)).
+		Find(&stale).Error
+	if err != nil {
+		klog.Errorf("查询执行中断的审批申请失败: %v", err)
+		return
+	}
+	for _, req := range stale {
+		result := strings.TrimSpace(req.Result + "\n执行超时或执行节点已重启，未能确认执行结果，请核实后重新提交申请")
+		tx := dao.DB().Model(&models.ApprovalRequest{}).
+			Where("id = ? AND status = ?", req.ID, models.ApprovalStatusExecuting).
+			Updates(map[string]any{"status": models.ApprovalStatusFailed, "result": result})
+		if tx.Error != nil {
+			klog.Errorf("更新审批申请#%d 状态失败: %v", req.ID, tx.Error)
+			continue
+		}
+		if tx.RowsAffected == 0 {
+			continue
+		}
+		klog.Warningf("审批申请#%d 执行中断，已标记为失败", req.ID)
+		req.Status = models.ApprovalStatusFailed
+		req.Result = result
+		s.notifyDecision(req)
+	}
+}
+
+// StartReconcileInBackground 启动执行中断申请的回收任务，仅在Leader节点运行
+func (s *approvalService) StartReconcileInBackground() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.reconcileCron != nil {
+		s.reconcileCron.Stop()
+	}
+	inst := cron.New()
+	_, err := inst.AddFunc("@every 5m", s.FailStaleExecutions)
+	if err != nil {
+		klog.Errorf("新增审批执行中断回收定时任务失败: %v", err)
+		return
+	}
+	inst.Start()
+	s.reconcileCron = inst
+	go s.FailStaleExecutions()
+	klog.V(6).Infof("启动审批执行中断回收定时任务")
+}
+
+// StopReconcileInBackground 停止执行中断申请的回收任务
+func (s *approvalService) StopReconcileInBackground() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.reconcileCron != nil {
+		klog.V(6).Infof("停止审批执行中断回收定时任务")
+		s.reconcileCron.Stop()
+		s.reconcileCron = nil
+	}
+}
+
+// Reject 驳回审批申请
+func (s *approvalService) Reject(req *models.ApprovalRequest, approver, comment string) error {
+	if err := s.decide(req, approver, comment, models.ApprovalStatusRejected); err != nil {
+		return err
+	}
+	s.notifyDecision(req)
+	return nil
+}
+
+// decide 将待审批请求更新为指定状态，仅pending状态可更新，避免并发重复审批
+func (s *approvalService) decide(req *models.ApprovalRequest, approver, comment, status string) error {
+	now := time.Now()
+	tx := dao.DB().Model(&models.ApprovalRequest{}).
+		Where("id = ? and status = ?", req.ID, models.ApprovalStatusPending).
+		Updates(map[string]any{
+			"status":      status,
+			"approved_by": approver,
+			"comment":     comment,
+			"decided_at":  &now,
+		})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return fmt.Errorf("审批申请#%d 已被处理", req.ID)
+	}
+	req.Status = status
+	req.ApprovedBy = approver
+	req.Comment = comment
+	req.DecidedAt = &now
+	return nil
+}
+
+func (s *approvalService) notifyDecision(req *models.ApprovalRequest) {
+	policy := &models.ApprovalPolicy{}
+	policy, err := policy.GetOne(nil, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", req.PolicyID)
+	})
+	if err != nil {
+		return
+	}
+	s.notify(policy.Webhooks, req, fmt.Sprintf("审批申请#%d 已由[%s]处理，状态：%s：集群[%s] %s %s", req.ID, req.ApprovedBy, req.Status, req.Cluster, req.Action, s.target(req)))
+}
+
+func (s *approvalService) notify(webhooks string, req *models.ApprovalRequest, msg string) {
+	if strings.TrimSpace(webhooks) == "" {
+		return
+	}
+	receiver := &models.WebhookReceiver{}
+	receivers, _, err := receiver.List(dao.BuildDefaultParams(), func(db *gorm.DB) *gorm.DB {
+		return db.Where("id in ?", strings.Split(webhooks, ","))
+	})
+	if err != nil {
+		klog.Errorf("获取审批通知webhook失败: %v", err)
+		return
+	}
+	go webhook.PushMsgToAllTargets(msg, utils.ToJSON(req), receivers)
+}
+
+func (s *approvalService) target(req *models.ApprovalRequest) string {
+	if req.Namespace != "" {
+		return fmt.Sprintf("%s %s/%s", req.Kind, req.Namespace, req.Name)
+	}
+	return fmt.Sprintf("%s %s", req.Kind, req.Name)
+}
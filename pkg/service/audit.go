@@ -0,0 +1,190 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/audit"
+	"github.com/weibaohui/k8m/pkg/flag"
+	"github.com/weibaohui/k8m/pkg/models"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+type auditSinkRunner struct {
+	config     *models.AuditSink
+	dispatcher *audit.Dispatcher
+}
+
+// auditService 审计日志外发
+// 日志写入数据库成功后再投递到各外发目标，保证外部平台收到的每条日志在数据库中都有对应记录
+type auditService struct {
+	mu      sync.RWMutex
+	runners []*auditSinkRunner
+	host    string
+}
+
+// Init 加载已启用的外发配置并启动推送
+func (s *auditService) Init() {
+	s.host, _ = os.Hostname()
+	if err := s.Reload(); err != nil {
+		klog.Errorf("加载审计日志外发配置失败: %v", err)
+	}
+}
+
+// Reload 重新加载外发配置，配置变更后调用
+// 旧的推送任务在关闭前会推送完已缓冲的日志
+func (s *auditService) Reload() error {
+	m := &models.AuditSink{}
+	list, _, err := m.List(dao.BuildDefaultParams(), func(db *gorm.DB) *gorm.DB {
+		return db.Where("enabled = ?", true)
+	})
+	if err != nil {
+		return err
+	}
+	var runners []*auditSinkRunner
+	for _, item := range list {
+		sink, buildErr := s.BuildSink(item)
+		if buildErr != nil {
+			klog.Errorf("审计日志外发[%s]配置错误，已忽略: %v", item.Name, buildErr)
+			continue
+		}
+		runners = append(runners, &auditSinkRunner{
+			config: item,
+			dispatcher: audit.NewDispatcher(sink, audit.Options{
+				BufferSize:    item.BufferSize,
+				BatchSize:     item.BatchSize,
+				FlushInterval: time.Duration(item.FlushInterval) * time.Second,
+				SpoolPath:     audit.SpoolPath(auditSpoolDir(), item.ID),
+			}),
+		})
+	}
+
+	s.mu.Lock()
+	old := s.runners
+	s.runners = runners
+	s.mu.Unlock()
+
+	for _, r := range old {
+		go r.dispatcher.Close()
+	}
+	klog.V(6).Infof("加载审计日志外发配置 %d 个", len(runners))
+	return nil
+}
+
+// BuildSink 根据配置创建外发目标
+func (s *auditService) BuildSink(m *models.AuditSink) (audit.Sink, error) {
+	switch m.Type {
+	case models.AuditSinkTypeFile:
+		return audit.NewFileSink(m.FilePath, m.MaxSizeMB, m.MaxBackups)
+	case models.AuditSinkTypeSyslog:
+		return audit.NewSyslogSink(m.Network, m.Address, m.AppName)
+	case models.AuditSinkTypeHTTP:
+		return audit.NewHTTPSink(m.URL, parseHeaders(m.Headers))
+	}
+	return nil, fmt.Errorf("不支持的外发类型[%s]", m.Type)
+}
+
+// Test 向外发目标同步发送一条测试日志
+func (s *auditService) Test(m *models.AuditSink, username string) error {
+	sink, err := s.BuildSink(m)
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+	_, err = sink.Write([]*audit.Event{{
+		Source:  "test",
+		Time:    time.Now(),
+		Host:    s.host,
+		User:    username,
+		Action:  "test",
+		Success: true,
+		Record:  "k8m 审计日志外发测试",
+	}})
+	return err
+}
+
+// EmitOperationLog 外发资源操作日志
+func (s *auditService) EmitOperationLog(m *models.OperationLog) {
+	s.emit(&audit.Event{
+		Source:  models.AuditSourceOperation,
+		Time:    eventTime(m.CreatedAt),
+		User:    m.UserName,
+		Cluster: m.Cluster,
+		Action:  m.Action,
+		Success: m.ActionResult == "success",
+		Record:  m,
+	})
+}
+
+// EmitShellLog 外发终端命令日志
+func (s *auditService) EmitShellLog(m *models.ShellLog) {
+	s.emit(&audit.Event{
+		Source:  models.AuditSourceShell,
+		Time:    eventTime(m.CreatedAt),
+		User:    m.UserName,
+		Cluster: m.Cluster,
+		Action:  "exec",
+		Success: true,
+		Record:  m,
+	})
+}
+
+// EmitMCPToolLog 外发MCP工具执行日志
+func (s *auditService) EmitMCPToolLog(m *models.MCPToolLog) {
+	s.emit(&audit.Event{
+		Source:  models.AuditSourceMCP,
+		Time:    eventTime(m.CreatedAt),
+		User:    m.CreatedBy,
+		Action:  m.ToolName,
+		Success: m.Error == "",
+		Record:  m,
+	})
+}
+
+func (s *auditService) emit(e *audit.Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.runners) == 0 {
+		return
+	}
+	e.Host = s.host
+	for _, r := range s.runners {
+		if r.config.AcceptSource(e.Source) {
+			r.dispatcher.Emit(e)
+		}
+	}
+}
+
+// auditSpoolDir 外发溢出文件目录，位于数据库文件所在目录下
+func auditSpoolDir() string {
+	if cfg := flag.Init(); cfg.SqlitePath != "" {
+		return filepath.Join(filepath.Dir(cfg.SqlitePath), "audit-spool")
+	}
+	return filepath.Join("data", "audit-spool")
+}
+
+func eventTime(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Now()
+	}
+	return t
+}
+
+// parseHeaders 解析自定义请求头，每行一个，格式 Key: Value
+func parseHeaders(s string) map[string]string {
+	headers := make(map[string]string)
+	for _, line := range strings.Split(s, "\n") {
+		k, v, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(k) == "" {
+			continue
+		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return headers
+}
@@ -21,7 +21,7 @@ type chatService struct{}
 // getChatStreamBase 是 GetChatStream 和 GetChatStreamWithoutHistory 的通用实现，支持可选的历史清理
 // 参数 clearHistory 表示是否在请求前后都清空历史
 func (c *chatService) getChatStreamBase(ctx context.Context, chat string, clearHistory bool) (*openai.ChatCompletionStream, error) {
-	client, err := AIService().DefaultClient()
+	client, err := AIService().ContextClient(ctx)
 	if err != nil {
 		klog.V(6).Infof("获取AI服务错误 : %v\n", err)
 		return nil, fmt.Errorf("获取AI服务错误 : %v", err)
@@ -45,6 +45,9 @@ func (c *chatService) getChatStreamBase(ctx context.Context, chat string, clearH
 	tools := McpService().GetAllEnabledTools()
 	klog.V(6).Infof("GPTShell 对话携带tools %d", len(tools))
 	client.SetTools(tools)
+
+	// 附带与问题相关的运维手册片段，本轮的多次工具调用均可参考
+	ctx = RunbookService().WithReferences(ctx, chat)
 	stream, err := client.GetStreamCompletionWithTools(ctx, chat)
 	if err != nil {
 		klog.V(6).Infof("ChatCompletion error: %v\n", err)
@@ -63,7 +66,7 @@ func (c *chatService) GetChatStreamWithoutHistory(ctx context.Context, chat stri
 func (c *chatService) RunOneRound(ctx context.Context, chat string, writer io.Writer) error {
 	cfg := flag.Init()
 
-	client, err := AIService().DefaultClient()
+	client, err := AIService().ContextClient(ctx)
 
 	if err != nil {
 		klog.V(6).Infof("获取AI服务错误 : %v\n", err)
@@ -147,6 +150,10 @@ func (c *chatService) RunOneRound(ctx context.Context, chat string, writer io.Wr
 
 			}
 
+			// 最后一个仅含用量的分片没有 choice
+			if len(response.Choices) == 0 {
+				continue
+			}
 			// 发送数据给客户端
 			// 写入outBuffer
 			content := response.Choices[0].Delta.Content
@@ -184,7 +191,7 @@ func (c *chatService) Chat(ctx *gin.Context, chat string) string {
 	return result
 }
 func (c *chatService) ChatWithCtxNoHistory(ctx context.Context, chat string) (string, error) {
-	client, err := AIService().DefaultClient()
+	client, err := AIService().ContextClient(ctx)
 
 	if err != nil {
 		klog.V(2).Infof("获取AI服务错误 : %v\n", err)
@@ -202,7 +209,7 @@ func (c *chatService) ChatWithCtxNoHistory(ctx context.Context, chat string) (st
 	return result, nil
 }
 func (c *chatService) ChatWithCtx(ctx context.Context, chat string) (string, error) {
-	client, err := AIService().DefaultClient()
+	client, err := AIService().ContextClient(ctx)
 
 	if err != nil {
 		klog.V(2).Infof("获取AI服务错误 : %v\n", err)
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/ai"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/models"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+// chatSessionService 持久化的AI对话会话
+// 实现 ai.HistoryStore，ctx 中携带会话ID时，对话历史读写该会话的消息，进程重启及多副本间均可共享
+type chatSessionService struct {
+}
+
+// 会话标题最大长度，未命名的会话以第一条用户消息作为标题
+const chatSessionTitleMaxLen = 50
+
+func init() {
+	ai.RegisterSessionStore(localChatSessionService)
+}
+
+// Create 为用户创建会话
+func (s *chatSessionService) Create(username, title, cluster, namespace string) (*models.ChatSession, error) {
+	session := &models.ChatSession{
+		UserName:  username,
+		Title:     title,
+		Cluster:   cluster,
+		Namespace: namespace,
+	}
+	if err := dao.DB().Create(session).Error; err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetOwned 获取属于该用户的会话
+func (s *chatSessionService) GetOwned(id uint, username string) (*models.ChatSession, error) {
+	var session models.ChatSession
+	err := dao.DB().Where("id = ? and user_name = ?", id, username).First(&session).Error
+	if err != nil {
+		return nil, fmt.Errorf("会话[%d]不存在", id)
+	}
+	return &session, nil
+}
+
+// Resolve 获取要使用的会话
+// 指定了会话ID时校验归属；未指定时使用用户最近的会话，不存在则按集群/命名空间创建一个
+func (s *chatSessionService) Resolve(id uint, username, cluster, namespace string) (*models.ChatSession, error) {
+	if id > 0 {
+		return s.GetOwned(id, username)
+	}
+	var session models.ChatSession
+	err := dao.DB().Where("user_name = ?", username).Order("updated_at desc").First(&session).Error
+	if err == nil {
+		return &session, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return s.Create(username, "", cluster, namespace)
+}
+
+// Rename 重命名会话
+func (s *chatSessionService) Rename(id uint, username, title string) error {
+	session, err := s.GetOwned(id, username)
+	if err != nil {
+		return err
+	}
+	return dao.DB().Model(session).Update("title", title).Error
+}
+
+// Delete 删除会话及其全部消息
+func (s *chatSessionService) Delete(id uint, username string) error {
+	session, err := s.GetOwned(id, username)
+	if err != nil {
+		return err
+	}
+	return dao.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ?", session.ID).Delete(&models.ChatMessage{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(session).Error
+	})
+}
+
+// Messages 获取会话的消息，limit 大于0时仅返回最近 limit 条，按时间正序
+func (s *chatSessionService) Messages(id uint, limit int) ([]*models.ChatMessage, error) {
+	var list []*models.ChatMessage
+	query := dao.DB().Where("session_id = ?", id).Order("id desc")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&list).Error; err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	return list, nil
+}
+
+// Export 导出会话，format 支持 json、markdown
+func (s *chatSessionService) Export(id uint, username, format string) (string, error) {
+	session, err := s.GetOwned(id, username)
+	if err != nil {
+		return "", err
+	}
+	list, err := s.Messages(session.ID, 0)
+	if err != nil {
+		return "", err
+	}
+	switch format {
+	case "json":
+		bytes, err := json.MarshalIndent(map[string]any{
+			"session":  session,
+			"messages": list,
+		}, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(bytes), nil
+	case "markdown", "md", "":
+		var sb strings.Builder
+		title := session.Title
+		if title == "" {
+			title = fmt.Sprintf("会话 %d", session.ID)
+		}
+		sb.WriteString(fmt.Sprintf("# %s\n\n", title))
+		if session.Cluster != "" {
+			sb.WriteString(fmt.Sprintf("- 集群：%s\n", session.Cluster))
+		}
+		if session.Namespace != "" {
+			sb.WriteString(fmt.Sprintf("- 命名空间：%s\n", session.Namespace))
+		}
+		sb.WriteString(fmt.Sprintf("- 创建时间：%s\n\n", session.CreatedAt.Format(time.DateTime)))
+		for _, m := range list {
+			if m.Role == openai.ChatMessageRoleSystem {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("## %s · %s\n\n", m.Role, m.CreatedAt.Format(time.DateTime)))
+			sb.WriteString(m.Content)
+			sb.WriteString("\n\n")
+		}
+		return sb.String(), nil
+	default:
+		return "", fmt.Errorf("不支持的导出格式: %s", format)
+	}
+}
+
+// GetHistory 获取 ctx 中会话的对话历史
+func (s *chatSessionService) GetHistory(ctx context.Context, limit int) []openai.ChatCompletionMessage {
+	list, err := s.Messages(ai.SessionIDFromContext(ctx), limit)
+	if err != nil {
+		klog.Errorf("读取会话历史失败: %v", err)
+		return nil
+	}
+	history := make([]openai.ChatCompletionMessage, 0, len(list))
+	for _, m := range list {
+		msg := openai.ChatCompletionMessage{
+			Role:       m.Role,
+			Content:    m.Content,
+			Name:       m.Name,
+			ToolCallID: m.ToolCallID,
+		}
+		if m.ToolCalls != "" {
+			_ = json.Unmarshal([]byte(m.ToolCalls), &msg.ToolCalls)
+		}
+		history = append(history, msg)
+	}
+	return history
+}
+
+// AppendHistory 向 ctx 中的会话追加消息，会话未命名时以第一条用户消息作为标题
+func (s *chatSessionService) AppendHistory(ctx context.Context, msgs ...openai.ChatCompletionMessage) {
+	id := ai.SessionIDFromContext(ctx)
+	if id == 0 || len(msgs) == 0 {
+		return
+	}
+	rows := make([]*models.ChatMessage, 0, len(msgs))
+	title := ""
+	for _, m := range msgs {
+		row := &models.ChatMessage{
+			SessionID:  id,
+			Role:       m.Role,
+			Content:    m.Content,
+			Name:       m.Name,
+			ToolCallID: m.ToolCallID,
+		}
+		if len(m.ToolCalls) > 0 {
+			row.ToolCalls = utils.ToJSON(m.ToolCalls)
+		}
+		if title == "" && m.Role == openai.ChatMessageRoleUser {
+			title = m.Content
+		}
+		rows = append(rows, row)
+	}
+	err := dao.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&rows).Error; err != nil {
+			return err
+		}
+		updates := map[string]any{"updated_at": time.Now()}
+		if err := tx.Model(&models.ChatSession{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		if title == "" {
+			return nil
+		}
+		title = strings.TrimPrefix(strings.Trim(strings.TrimSpace(title), `"`), "/no_think")
+		if runes := []rune(title); len(runes) > chatSessionTitleMaxLen {
+			title = string(runes[:chatSessionTitleMaxLen])
+		}
+		return tx.Model(&models.ChatSession{}).Where("id = ? and (title = '' or title is null)", id).Update("title", title).Error
+	})
+	if err != nil {
+		klog.Errorf("保存会话[%d]历史失败: %v", id, err)
+	}
+}
+
+// ClearHistory 清空 ctx 中会话的消息，保留会话本身
+func (s *chatSessionService) ClearHistory(ctx context.Context) error {
+	return dao.DB().Where("session_id = ?", ai.SessionIDFromContext(ctx)).Delete(&models.ChatMessage{}).Error
+}
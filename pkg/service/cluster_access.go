@@ -0,0 +1,144 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+// MaxAccessRequestHours 临时授权申请的最长时长（小时）
+const MaxAccessRequestHours = 72
+
+type clusterAccessService struct {
+	mu          sync.Mutex
+	cleanupCron *cron.Cron
+}
+
+// Approve 批准临时授权申请，生成自批准时刻起、有效期为申请时长的集群授权
+func (s *clusterAccessService) Approve(req *models.ClusterAccessRequest, approver, comment string) error {
+	if req.Status != models.AccessRequestStatusPending {
+		return fmt.Errorf("申请#%d 当前状态为[%s]，不可重复审批", req.ID, req.Status)
+	}
+	now := time.Now()
+	until := now.Add(time.Duration(req.Hours) * time.Hour)
+	grant := &models.ClusterUserRole{
+		Cluster:           req.Cluster,
+		Username:          req.Username,
+		Role:              req.Role,
+		Namespaces:        req.Namespaces,
+		AuthorizationType: constants.ClusterAuthorizationTypeUser,
+		ValidFrom:         &now,
+		ValidUntil:        &until,
+	}
+
+	err := dao.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(grant).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.ClusterAccessRequest{}).
+			Where("id = ? and status = ?", req.ID, models.AccessRequestStatusPending).
+			Updates(map[string]any{
+				"status":      models.AccessRequestStatusApproved,
+				"approved_by": approver,
+				"comment":     comment,
+				"grant_id":    grant.ID,
+				"valid_until": &until,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("申请#%d 已被处理", req.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	UserService().ClearCacheByKey("cluster")
+	return nil
+}
+
+// Reject 驳回临时授权申请
+func (s *clusterAccessService) Reject(req *models.ClusterAccessRequest, approver, comment string) error {
+	result := dao.DB().Model(&models.ClusterAccessRequest{}).
+		Where("id = ? and status = ?", req.ID, models.AccessRequestStatusPending).
+		Updates(map[string]any{
+			"status":      models.AccessRequestStatusRejected,
+			"approved_by": approver,
+			"comment":     comment,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("申请#%d 已被处理", req.ID)
+	}
+	return nil
+}
+
+// CleanupExpiredGrants 删除已过期的集群授权，并将对应的临时授权申请标记为已到期
+func (s *clusterAccessService) CleanupExpiredGrants() {
+	now := time.Now()
+	var expired []*models.ClusterUserRole
+	if err := dao.DB().Where("valid_until is not null and valid_until <= ?", now).Find(&expired).Error; err != nil {
+		klog.Errorf("查询过期集群授权失败: %v", err)
+		return
+	}
+	if len(expired) == 0 {
+		return
+	}
+	var ids []uint
+	for _, item := range expired {
+		ids = append(ids, item.ID)
+		klog.V(4).Infof("回收过期集群授权: 用户[%s] 集群[%s] 角色[%s]", item.Username, item.Cluster, item.Role)
+	}
+	if err := dao.DB().Where("id in ?", ids).Delete(&models.ClusterUserRole{}).Error; err != nil {
+		klog.Errorf("删除过期集群授权失败: %v", err)
+		return
+	}
+	if err := dao.DB().Model(&models.ClusterAccessRequest{}).
+		Where("grant_id in ? and status = ?", ids, models.AccessRequestStatusApproved).
+		Update("status", models.AccessRequestStatusExpired).Error; err != nil {
+		klog.Errorf("更新临时授权申请状态失败: %v", err)
+	}
+	UserService().ClearCacheByKey("cluster")
+}
+
+// StartCleanupInBackground 启动过期授权回收定时任务，仅在Leader节点运行
+func (s *clusterAccessService) StartCleanupInBackground() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cleanupCron != nil {
+		s.cleanupCron.Stop()
+	}
+	inst := cron.New()
+	_, err := inst.AddFunc("@every 1m", s.CleanupExpiredGrants)
+	if err != nil {
+		klog.Errorf("新增过期授权回收定时任务失败: %v", err)
+		return
+	}
+	inst.Start()
+	s.cleanupCron = inst
+	klog.V(6).Infof("启动过期授权回收定时任务")
+}
+
+// StopCleanupInBackground 停止过期授权回收定时任务
+func (s *clusterAccessService) StopCleanupInBackground() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cleanupCron != nil {
+		klog.V(6).Infof("停止过期授权回收定时任务")
+		s.cleanupCron.Stop()
+		s.cleanupCron = nil
+	}
+}
@@ -78,6 +78,10 @@ func (s *configService) UpdateFlagFromDBConfig() error {
 		cfg.ApiKey = modelConfig.ApiKey
 		cfg.ApiModel = modelConfig.ApiModel
 		cfg.ApiURL = modelConfig.ApiURL
+		cfg.ApiProvider = modelConfig.Provider
+		cfg.ApiVersion = modelConfig.ApiVersion
+		cfg.ApiDeployment = modelConfig.Deployment
+		cfg.ApiModelConfigID = modelConfig.ID
 		cfg.Think = modelConfig.Think
 		if modelConfig.Temperature > 0 {
 			cfg.Temperature = modelConfig.Temperature
@@ -135,6 +139,10 @@ func (s *configService) UpdateFlagFromDBConfig() error {
 		cfg.MaxRetryAttempts = m.MaxRetryAttempts
 	}
 
+	if m.SensitiveKeys != "" {
+		cfg.SensitiveKeys = m.SensitiveKeys
+	}
+
 	// JwtTokenSecret 暂不启用，因为前端也要处理
 	// cfg.JwtTokenSecret = m.JwtTokenSecret
 	// LoginType 暂不启用，因为就一种password
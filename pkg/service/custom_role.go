@@ -0,0 +1,41 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/models"
+	"gorm.io/gorm"
+)
+
+type customRoleService struct {
+}
+
+// GetByName 按名称获取自定义角色，结果缓存5分钟
+func (s *customRoleService) GetByName(name string) (*models.CustomRole, error) {
+	cacheKey := s.cacheKey(name)
+	return utils.GetOrSetCache(CacheService().CacheInstance(), cacheKey, 5*time.Minute, func() (*models.CustomRole, error) {
+		m := &models.CustomRole{}
+		return m.GetOne(nil, func(db *gorm.DB) *gorm.DB {
+			return db.Where("name = ?", name)
+		})
+	})
+}
+
+// List 获取全部自定义角色
+func (s *customRoleService) List() ([]*models.CustomRole, error) {
+	m := &models.CustomRole{}
+	list, _, err := m.List(dao.BuildDefaultParams())
+	return list, err
+}
+
+// ClearCache 清除自定义角色缓存，角色定义变更后调用
+func (s *customRoleService) ClearCache(name string) {
+	utils.ClearCacheByKey(CacheService().CacheInstance(), s.cacheKey(name))
+}
+
+func (s *customRoleService) cacheKey(name string) string {
+	return fmt.Sprintf("custom_role:%s", name)
+}
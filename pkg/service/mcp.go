@@ -20,6 +20,7 @@ type mcpService struct {
 func (m *mcpService) Init() {
 	if m.host == nil {
 		m.host = NewMCPHost()
+		m.host.StartHealthCheck(time.Minute)
 	}
 	m.Start()
 }
@@ -28,12 +29,7 @@ func (m *mcpService) Host() *MCPHost {
 }
 func (m *mcpService) AddServer(server models.MCPServerConfig) {
 	// 将server转换为mcp.ServerConfig
-	serverConfig := ServerConfig{
-		ID:      server.ID,
-		Name:    server.Name,
-		URL:     server.URL,
-		Enabled: server.Enabled,
-	}
+	serverConfig := NewServerConfig(server)
 	err := m.host.AddServer(serverConfig)
 	if err != nil {
 		klog.V(6).Infof("Failed to add server %s: %v", server.Name, err)
@@ -55,12 +51,7 @@ func (m *mcpService) AddServer(server models.MCPServerConfig) {
 func (m *mcpService) AddServers(servers []models.MCPServerConfig) {
 	for _, server := range servers {
 		// 将server转换为mcp.ServerConfig
-		serverConfig := ServerConfig{
-			ID:      server.ID,
-			Name:    server.Name,
-			URL:     server.URL,
-			Enabled: server.Enabled,
-		}
+		serverConfig := NewServerConfig(server)
 		err := m.host.AddServer(serverConfig)
 		if err != nil {
 			klog.V(6).Infof("Failed to add server %s: %v", server.Name, err)
@@ -82,11 +73,7 @@ func (m *mcpService) AddServers(servers []models.MCPServerConfig) {
 }
 func (m *mcpService) RemoveServer(server models.MCPServerConfig) {
 	// 将server转换为mcp.ServerConfig
-	serverConfig := ServerConfig{
-		Name:    server.Name,
-		URL:     server.URL,
-		Enabled: server.Enabled,
-	}
+	serverConfig := NewServerConfig(server)
 	m.host.RemoveServer(serverConfig)
 }
 func (m *mcpService) Start() {
@@ -109,7 +96,8 @@ func (m *mcpService) UpdateServer(entity models.MCPServerConfig) {
 }
 
 func (m *mcpService) GetTools(entity models.MCPServerConfig) ([]mcp2.Tool, error) {
-	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(context.Background(), entity.CallTimeout())
+	defer cancel()
 	return m.Host().GetTools(ctx, entity.Name)
 }
 
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"k8s.io/klog/v2"
+)
+
+// 工具调用确认结果
+const (
+	ToolConfirmApproved = "approved"
+	ToolConfirmEdited   = "edited"
+	ToolConfirmRejected = "rejected"
+	ToolConfirmTimeout  = "timeout"
+)
+
+// 确认消息类型
+const (
+	ToolConfirmPendingType = "pending_tool_call"
+	ToolConfirmReplyType   = "tool_call_reply"
+)
+
+// k8mServerName 内置 k8m MCP 服务器的名称，见 models.AddInnerMCPServer
+const k8mServerName = "k8m"
+
+// readOnlyTools 内置 k8m MCP 服务器中已知的只读工具，不在列表中的工具一律视为变更类
+// 在容器内执行命令（exec）的工具即使只是读取也不在列表中，如 list_pod_files、get_pod_linked_env
+var readOnlyTools = map[string]bool{
+	"list_clusters":                true,
+	"get_k8s_resource":             true,
+	"list_k8s_resource":            true,
+	"describe_k8s_resource":        true,
+	"list_k8s_event":               true,
+	"get_pod_logs":                 true,
+	"get_pod_linked_service":       true,
+	"get_pod_linked_endpoints":     true,
+	"get_pod_linked_ingress":       true,
+	"get_pod_linked_pv":            true,
+	"get_pod_linked_pvc":           true,
+	"get_pod_linked_env_from_yaml": true,
+	"get_pod_resource_usage":       true,
+	"get_node_resource_usage":      true,
+	"get_node_ip_usage":            true,
+	"get_node_pod_count":           true,
+	"get_storageclass_pv_count":    true,
+	"get_storageclass_pvc_count":   true,
+	"list_deployment_pods":         true,
+	"hpa_list_deployment":          true,
+	"rollout_history_deployment":   true,
+	"rollout_status_deployment":    true,
+	"list_helm_release":            true,
+	"get_helm_release":             true,
+	"list_helm_repository":         true,
+}
+
+// IsReadOnlyTool 判断工具是否为内置 k8m MCP 服务器的已知只读工具，非只读工具执行前需要用户确认
+// 外部MCP服务器提供的工具即使同名也视为变更类
+func IsReadOnlyTool(serverName, toolName string) bool {
+	return serverName == k8mServerName && readOnlyTools[toolName]
+}
+
+// ToolConfirmRequest 发送给前端的待确认工具调用
+type ToolConfirmRequest struct {
+	Type       string         `json:"type"`
+	ID         string         `json:"id"`
+	ToolName   string         `json:"tool_name"`
+	ServerName string         `json:"server_name"`
+	Parameters map[string]any `json:"parameters"`
+}
+
+// ToolConfirmReply 前端回复的确认结果
+// Action 为 approve、edit、reject，edit 时使用 Parameters 替换原参数执行
+type ToolConfirmReply struct {
+	Type       string         `json:"type"`
+	ID         string         `json:"id"`
+	Action     string         `json:"action"`
+	Parameters map[string]any `json:"parameters,omitempty"`
+}
+
+// ToolConfirmer 变更类工具调用确认器，每个对话连接一个
+// 执行变更类工具前通过 send 发送待确认消息，阻塞等待前端回复、超时或 ctx 结束
+type ToolConfirmer struct {
+	send    func(data []byte) error
+	timeout time.Duration
+	mu      sync.Mutex
+	pending map[string]chan ToolConfirmReply
+}
+
+// NewToolConfirmer 创建工具调用确认器，timeout 为等待用户确认的最长时间
+func NewToolConfirmer(send func(data []byte) error, timeout time.Duration) *ToolConfirmer {
+	return &ToolConfirmer{
+		send:    send,
+		timeout: timeout,
+		pending: make(map[string]chan ToolConfirmReply),
+	}
+}
+
+// WithToolConfirmer 在 ctx 中携带工具调用确认器
+func WithToolConfirmer(ctx context.Context, confirmer *ToolConfirmer) context.Context {
+	return context.WithValue(ctx, constants.ToolConfirmer, confirmer)
+}
+
+// ToolConfirmerFromContext 获取 ctx 中的工具调用确认器，未携带时返回nil
+func ToolConfirmerFromContext(ctx context.Context) *ToolConfirmer {
+	confirmer, _ := ctx.Value(constants.ToolConfirmer).(*ToolConfirmer)
+	return confirmer
+}
+
+// Confirm 请求用户确认工具调用，返回确认结果及最终执行参数
+func (t *ToolConfirmer) Confirm(ctx context.Context, toolName, serverName string, args map[string]any) (string, map[string]any) {
+	id := utils.RandNLengthString(12)
+	ch := make(chan ToolConfirmReply, 1)
+	t.mu.Lock()
+	t.pending[id] = ch
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, id)
+		t.mu.Unlock()
+	}()
+
+	req := ToolConfirmRequest{
+		Type:       ToolConfirmPendingType,
+		ID:         id,
+		ToolName:   toolName,
+		ServerName: serverName,
+		Parameters: args,
+	}
+	if err := t.send([]byte(utils.ToJSON(req))); err != nil {
+		klog.V(6).Infof("发送工具调用确认请求失败: %v", err)
+		return ToolConfirmRejected, args
+	}
+
+	timer := time.NewTimer(t.timeout)
+	defer timer.Stop()
+	select {
+	case reply := <-ch:
+		switch reply.Action {
+		case "approve":
+			return ToolConfirmApproved, args
+		case "edit":
+			// 修改参数时必须提供新的参数，否则按拒绝处理
+			if reply.Parameters == nil {
+				return ToolConfirmRejected, args
+			}
+			return ToolConfirmEdited, reply.Parameters
+		default:
+			return ToolConfirmRejected, args
+		}
+	case <-timer.C:
+		return ToolConfirmTimeout, args
+	case <-ctx.Done():
+		return ToolConfirmRejected, args
+	}
+}
+
+// Reply 处理前端发来的消息，是确认回复时交给等待中的调用并返回true，否则返回false
+func (t *ToolConfirmer) Reply(data []byte) bool {
+	var reply ToolConfirmReply
+	if err := json.Unmarshal(data, &reply); err != nil || reply.Type != ToolConfirmReplyType {
+		return false
+	}
+	t.mu.Lock()
+	ch, ok := t.pending[reply.ID]
+	t.mu.Unlock()
+	if !ok {
+		klog.V(6).Infof("工具调用确认请求[%s]不存在或已超时", reply.ID)
+		return true
+	}
+	select {
+	case ch <- reply:
+	default:
+	}
+	return true
+}
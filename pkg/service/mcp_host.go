@@ -1,8 +1,10 @@
 package service
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/sashabaranov/go-openai"
 	"github.com/weibaohui/k8m/internal/dao"
@@ -22,10 +25,51 @@ import (
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	ID      uint   `json:"id"`
-	URL     string `json:"url,omitempty"`
-	Name    string `json:"name,omitempty"`
-	Enabled bool   `json:"enabled,omitempty"`
+	ID        uint              `json:"id"`
+	URL       string            `json:"url,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Enabled   bool              `json:"enabled,omitempty"`
+	Transport string            `json:"transport,omitempty"`
+	Headers   map[string]string `json:"-"`
+	Token     string            `json:"-"`
+	Command   string            `json:"command,omitempty"`
+	Args      []string          `json:"args,omitempty"`
+	Env       []string          `json:"-"`
+	Timeout   time.Duration     `json:"timeout,omitempty"`
+}
+
+// NewServerConfig 将数据库中的MCP服务器配置转换为运行时配置
+func NewServerConfig(server models.MCPServerConfig) ServerConfig {
+	return ServerConfig{
+		ID:        server.ID,
+		Name:      server.Name,
+		URL:       server.URL,
+		Enabled:   server.Enabled,
+		Transport: server.Transport,
+		Headers:   server.HeaderMap(),
+		Token:     server.Token,
+		Command:   server.Command,
+		Args:      server.ArgList(),
+		Env:       server.EnvList(),
+		Timeout:   server.CallTimeout(),
+	}
+}
+
+// 服务器连接状态
+const (
+	MCPServerStatusConnected = "connected"
+	MCPServerStatusError     = "error"
+	MCPServerStatusDisabled  = "disabled"
+)
+
+// ServerStatus 服务器健康状态，由连接、调用及定期健康检查更新
+type ServerStatus struct {
+	Status        string    `json:"status"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastCheck     time.Time `json:"last_check,omitempty"`
+	LastConnected time.Time `json:"last_connected,omitempty"`
+	Failures      int       `json:"failures"` // 连续失败次数
+	Reconnects    int       `json:"reconnects"`
 }
 
 // MCPHost MCP服务器管理器
@@ -38,6 +82,11 @@ type MCPHost struct {
 	Resources map[string][]mcp.Resource
 	// 记录每个服务器的提示能力
 	Prompts map[string][]mcp.Prompt
+	// 记录每个服务器的健康状态
+	status map[string]*ServerStatus
+	// stdio 服务器为本地子进程，常驻复用，不随每次调用启停
+	stdioClients map[string]*client.Client
+	stdioMux     sync.Mutex
 
 	buffer    []*models.MCPToolLog
 	bufferMux sync.Mutex
@@ -50,6 +99,7 @@ type MCPServer struct {
 	Tools             []mcp.Tool            `json:"tools,omitempty"`
 	Resources         []mcp.Resource        `json:"resources,omitempty"`
 	Prompts           []mcp.Prompt          `json:"prompts,omitempty"`
+	Status            *ServerStatus         `json:"status,omitempty"`
 	InitializeResults *mcp.InitializeResult `json:"initialize_results,omitempty"`
 }
 
@@ -61,6 +111,9 @@ func NewMCPHost() *MCPHost {
 		Resources: make(map[string][]mcp.Resource),
 		Prompts:   make(map[string][]mcp.Prompt),
 
+		status:       make(map[string]*ServerStatus),
+		stdioClients: make(map[string]*client.Client),
+
 		buffer:   make([]*models.MCPToolLog, 0, 100),
 		ticker:   time.NewTicker(2 * time.Second),
 		stopChan: make(chan bool),
@@ -81,6 +134,7 @@ func (m *MCPHost) ListServers() []MCPServer {
 			Tools:        m.Tools[name],
 			Resources:    m.Resources[name],
 			Prompts:      m.Prompts[name],
+			Status:       m.GetStatus(name),
 		}
 		servers = append(servers, server)
 	}
@@ -104,9 +158,9 @@ func (m *MCPHost) AddServer(config ServerConfig) error {
 func (m *MCPHost) SyncServerCapabilities(ctx context.Context, serverName string) error {
 
 	// 获取服务器能力
-	tools, err := m.GetTools(ctx, serverName)
-	if err != nil {
-		klog.V(6).Infof("failed to get tools for %s: %v", serverName, err)
+	tools, toolsErr := m.GetTools(ctx, serverName)
+	if toolsErr != nil {
+		klog.V(6).Infof("failed to get tools for %s: %v", serverName, toolsErr)
 	}
 
 	resources, err := m.GetResources(ctx, serverName)
@@ -119,6 +173,14 @@ func (m *MCPHost) SyncServerCapabilities(ctx context.Context, serverName string)
 		klog.V(6).Infof("failed to get prompts for %s: %v", serverName, err)
 	}
 
+	m.markStatus(serverName, toolsErr)
+	if tools == nil && toolsErr != nil {
+		// 工具获取失败时保留上次同步的结果
+		m.mutex.RLock()
+		tools = m.Tools[serverName]
+		m.mutex.RUnlock()
+	}
+
 	// 只在更新共享资源时加锁
 	m.mutex.Lock()
 	m.Tools[serverName] = tools
@@ -126,72 +188,283 @@ func (m *MCPHost) SyncServerCapabilities(ctx context.Context, serverName string)
 	m.Prompts[serverName] = prompts
 	m.mutex.Unlock()
 	klog.V(6).Infof("同步服务器能力 [%s] 工具:%d 资源:%d 提示:%d", serverName, len(tools), len(resources), len(prompts))
-	return nil
+	return toolsErr
 }
 
 // ConnectServer 连接到指定服务器
 func (m *MCPHost) ConnectServer(ctx context.Context, serverName string) error {
-	config, exists := m.configs[serverName]
+	config, exists := m.getConfig(serverName)
 
 	if !exists {
 		return fmt.Errorf("server config not found: %s", serverName)
 	}
 
 	if !config.Enabled {
+		m.setStatus(serverName, &ServerStatus{Status: MCPServerStatusDisabled, LastCheck: time.Now()})
 		return fmt.Errorf("server is disabled: %s", serverName)
 	}
 
 	// 在锁外同步服务器能力
-	if err := m.SyncServerCapabilities(context.Background(), serverName); err != nil {
+	if err := m.SyncServerCapabilities(ctx, serverName); err != nil {
 		return fmt.Errorf("failed to sync server capabilities for %s: %v", serverName, err)
 	}
 
 	return nil
 }
 
-// GetClient 获取指定服务器的客户端
+// GetClient 获取指定服务器的客户端，使用完毕后需调用 ReleaseClient 释放
 func (m *MCPHost) GetClient(ctx context.Context, serverName string) (*client.Client, error) {
 
 	// 获取配置信息
-	config, exists := m.configs[serverName]
+	config, exists := m.getConfig(serverName)
 	if !exists {
 		return nil, fmt.Errorf("server config not found: %s", serverName)
 	}
 
-	username := m.getUserFromMCPCtx(ctx)
-	jwt, err := UserService().GenerateJWTTokenOnlyUserName(username, time.Hour*1)
+	if config.Transport == models.MCPTransportStdio {
+		cli, err := m.getStdioClient(ctx, config)
+		if err != nil {
+			m.markStatus(serverName, err)
+		}
+		return cli, err
+	}
+
+	headers, err := m.buildHeaders(ctx, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate JWT token for %s: %v", serverName, err)
+		return nil, err
+	}
+
+	var newCli *client.Client
+	switch config.Transport {
+	case models.MCPTransportStreamableHTTP:
+		newCli, err = client.NewStreamableHttpClient(config.URL,
+			transport.WithHTTPHeaders(headers),
+			transport.WithHTTPTimeout(config.Timeout),
+		)
+	default:
+		newCli, err = client.NewSSEMCPClient(config.URL, client.WithHeaders(headers))
 	}
-	// 执行时携带用户名、角色信息
-	newCli, err := client.NewSSEMCPClient(config.URL, client.WithHeaders(map[string]string{
-		"Authorization": jwt,
-	}))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new client for %s: %v", serverName, err)
 	}
 
 	if err = newCli.Start(ctx); err != nil {
 		newCli.Close()
+		m.markStatus(serverName, err)
 		return nil, fmt.Errorf("failed to start new client for %s: %v", serverName, err)
 	}
 
-	//  初始化客户端
+	if err = m.initialize(ctx, newCli); err != nil {
+		newCli.Close()
+		m.markStatus(serverName, err)
+		return nil, fmt.Errorf("failed to initialize new client for %s: %v", serverName, err)
+	}
+
+	return newCli, nil
+
+}
+
+// ReleaseClient 释放 GetClient 获取的客户端，stdio 客户端常驻复用，不关闭
+func (m *MCPHost) ReleaseClient(serverName string, cli *client.Client) {
+	m.stdioMux.Lock()
+	shared := m.stdioClients[serverName] == cli
+	m.stdioMux.Unlock()
+	if !shared {
+		_ = cli.Close()
+	}
+}
+
+// buildHeaders 构造请求头，默认携带当前用户的令牌，配置了 Token 时使用 Bearer 令牌，自定义请求头优先
+func (m *MCPHost) buildHeaders(ctx context.Context, config ServerConfig) (map[string]string, error) {
+	headers := map[string]string{}
+	if config.Token != "" {
+		headers["Authorization"] = "Bearer " + config.Token
+	} else {
+		username := m.getUserFromMCPCtx(ctx)
+		jwt, err := UserService().GenerateJWTTokenOnlyUserName(username, time.Hour*1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate JWT token for %s: %v", config.Name, err)
+		}
+		// 执行时携带用户名、角色信息
+		headers["Authorization"] = jwt
+	}
+	for k, v := range config.Headers {
+		headers[k] = v
+	}
+	return headers, nil
+}
+
+// initialize 初始化客户端
+func (m *MCPHost) initialize(ctx context.Context, cli *client.Client) error {
 	initRequest := mcp.InitializeRequest{}
 	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
 	initRequest.Params.ClientInfo = mcp.Implementation{
 		Name:    "multi-server-client",
 		Version: "1.0.0",
 	}
+	_, err := cli.Initialize(ctx, initRequest)
+	return err
+}
+
+// getStdioClient 获取 stdio 服务器的常驻客户端，子进程未启动或已被回收时重新启动
+func (m *MCPHost) getStdioClient(ctx context.Context, config ServerConfig) (*client.Client, error) {
+	m.stdioMux.Lock()
+	defer m.stdioMux.Unlock()
+	if cli, ok := m.stdioClients[config.Name]; ok {
+		return cli, nil
+	}
 
-	_, err = newCli.Initialize(ctx, initRequest)
+	cli, err := client.NewStdioMCPClient(config.Command, config.Env, config.Args...)
 	if err != nil {
-		newCli.Close()
-		return nil, fmt.Errorf("failed to initialize new client for %s: %v", serverName, err)
+		return nil, fmt.Errorf("failed to start stdio server %s: %v", config.Name, err)
+	}
+	// 持续读取子进程的标准错误输出，避免管道写满阻塞子进程
+	if stderr, ok := client.GetStderr(cli); ok {
+		go func() {
+			scanner := bufio.NewScanner(stderr)
+			for scanner.Scan() {
+				klog.V(6).Infof("MCP stdio [%s]: %s", config.Name, scanner.Text())
+			}
+		}()
+	}
+	if err = m.initialize(ctx, cli); err != nil {
+		_ = cli.Close()
+		return nil, fmt.Errorf("failed to initialize stdio server %s: %v", config.Name, err)
 	}
+	m.stdioClients[config.Name] = cli
+	return cli, nil
+}
 
-	return newCli, nil
+// closeStdioClient 关闭 stdio 服务器子进程，下次使用时重新启动
+func (m *MCPHost) closeStdioClient(serverName string) {
+	m.stdioMux.Lock()
+	cli, ok := m.stdioClients[serverName]
+	delete(m.stdioClients, serverName)
+	m.stdioMux.Unlock()
+	if ok {
+		_ = cli.Close()
+	}
+}
+
+func (m *MCPHost) getConfig(serverName string) (ServerConfig, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	config, ok := m.configs[serverName]
+	return config, ok
+}
+
+// GetStatus 获取服务器健康状态
+func (m *MCPHost) GetStatus(serverName string) *ServerStatus {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	if st, ok := m.status[serverName]; ok {
+		cp := *st
+		return &cp
+	}
+	return nil
+}
+
+// GetStatuses 获取所有服务器的健康状态
+func (m *MCPHost) GetStatuses() map[string]*ServerStatus {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	result := make(map[string]*ServerStatus, len(m.status))
+	for name, st := range m.status {
+		cp := *st
+		result[name] = &cp
+	}
+	return result
+}
 
+func (m *MCPHost) setStatus(serverName string, st *ServerStatus) {
+	m.mutex.Lock()
+	m.status[serverName] = st
+	m.mutex.Unlock()
+}
+
+// markStatus 按连接或调用结果更新服务器健康状态，返回此次是否由失败恢复
+func (m *MCPHost) markStatus(serverName string, err error) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	st, ok := m.status[serverName]
+	if !ok {
+		st = &ServerStatus{}
+		m.status[serverName] = st
+	}
+	now := time.Now()
+	st.LastCheck = now
+	if err != nil {
+		st.Status = MCPServerStatusError
+		st.LastError = err.Error()
+		st.Failures++
+		return false
+	}
+	recovered := st.Status == MCPServerStatusError
+	if recovered {
+		st.Reconnects++
+	}
+	st.Status = MCPServerStatusConnected
+	st.LastError = ""
+	st.Failures = 0
+	st.LastConnected = now
+	return recovered
+}
+
+// StartHealthCheck 定期检查已启用服务器的连通性，异常的 stdio 服务器会被重启，
+// 服务器恢复后重新同步其工具、资源和提示能力
+func (m *MCPHost) StartHealthCheck(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.checkHealth()
+			case <-m.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+func (m *MCPHost) checkHealth() {
+	m.mutex.RLock()
+	configs := make([]ServerConfig, 0, len(m.configs))
+	for _, config := range m.configs {
+		if config.Enabled {
+			configs = append(configs, config)
+		}
+	}
+	m.mutex.RUnlock()
+
+	for _, config := range configs {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := m.ping(ctx, config.Name)
+		cancel()
+		if err != nil {
+			klog.V(6).Infof("MCP服务器 [%s] 健康检查失败: %v", config.Name, err)
+			if config.Transport == models.MCPTransportStdio {
+				m.closeStdioClient(config.Name)
+			}
+			m.markStatus(config.Name, err)
+			continue
+		}
+		if m.markStatus(config.Name, nil) {
+			klog.V(6).Infof("MCP服务器 [%s] 已恢复连接，重新同步能力", config.Name)
+			ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+			_ = m.SyncServerCapabilities(ctx, config.Name)
+			cancel()
+		}
+	}
+}
+
+func (m *MCPHost) ping(ctx context.Context, serverName string) error {
+	cli, err := m.GetClient(ctx, serverName)
+	if err != nil {
+		return err
+	}
+	defer m.ReleaseClient(serverName, cli)
+	return cli.Ping(ctx)
 }
 
 func (m *MCPHost) getUserFromMCPCtx(ctx context.Context) string {
@@ -237,6 +510,7 @@ func (m *MCPHost) GetTools(ctx context.Context, serverName string) ([]mcp.Tool,
 	if err != nil {
 		return nil, err
 	}
+	defer m.ReleaseClient(serverName, cli)
 
 	toolsRequest := mcp.ListToolsRequest{}
 	toolsResult, err := cli.ListTools(ctx, toolsRequest)
@@ -253,6 +527,7 @@ func (m *MCPHost) GetResources(ctx context.Context, serverName string) ([]mcp.Re
 	if err != nil {
 		return nil, err
 	}
+	defer m.ReleaseClient(serverName, cli)
 	req := mcp.ListResourcesRequest{}
 	result, err := cli.ListResources(ctx, req)
 	if err != nil {
@@ -268,6 +543,7 @@ func (m *MCPHost) GetPrompts(ctx context.Context, serverName string) ([]mcp.Prom
 	if err != nil {
 		return nil, err
 	}
+	defer m.ReleaseClient(serverName, cli)
 	req := mcp.ListPromptsRequest{}
 	result, err := cli.ListPrompts(ctx, req)
 	if err != nil {
@@ -286,15 +562,23 @@ func (m *MCPHost) RemoveServer(config ServerConfig) {
 	delete(m.Tools, config.Name)
 	delete(m.Resources, config.Name)
 	delete(m.Prompts, config.Name)
+	delete(m.status, config.Name)
 	m.mutex.Unlock()
+	m.closeStdioClient(config.Name)
 }
 
 func (m *MCPHost) RemoveServerById(id uint) {
+	m.mutex.RLock()
+	var matched []ServerConfig
 	for _, cfg := range m.configs {
 		if cfg.ID == id {
-			m.RemoveServer(cfg)
+			matched = append(matched, cfg)
 		}
 	}
+	m.mutex.RUnlock()
+	for _, cfg := range matched {
+		m.RemoveServer(cfg)
+	}
 }
 
 // GetServerNameByToolName 根据工具名称获取对应的服务器名称
@@ -316,13 +600,14 @@ func (m *MCPHost) GetServerNameByToolName(toolName string) string {
 func (m *MCPHost) LogToolExecution(ctx context.Context, toolName, serverName string, parameters any, result models.MCPToolCallResult, executeTime int64) {
 
 	log := &models.MCPToolLog{
-		ToolName:    toolName,
-		ServerName:  serverName,
-		Parameters:  utils.ToJSON(parameters),
-		Result:      result.Result,
-		ExecuteTime: executeTime,
-		CreatedAt:   time.Now(),
-		Error:       result.Error,
+		ToolName:     toolName,
+		ServerName:   serverName,
+		Parameters:   utils.ToJSON(parameters),
+		Result:       result.Result,
+		ExecuteTime:  executeTime,
+		CreatedAt:    time.Now(),
+		Error:        result.Error,
+		Confirmation: result.Confirmation,
 	}
 
 	username := m.getUserFromMCPCtx(ctx)
@@ -337,7 +622,11 @@ func (m *MCPHost) LogToolExecution(ctx context.Context, toolName, serverName str
 		log.Result = log.Error
 	}
 
-	dao.DB().Create(log)
+	if err := dao.DB().Create(log).Error; err != nil {
+		klog.Errorf("保存MCP工具执行日志失败: %v", err)
+		return
+	}
+	AuditService().EmitMCPToolLog(log)
 }
 
 func (m *MCPHost) ProcessWithOpenAI(ctx context.Context, ai ai.IAI, prompt string) (string, []models.MCPToolCallResult, error) {
@@ -407,22 +696,50 @@ func (m *MCPHost) ExecTools(ctx context.Context, toolCalls []openai.ToolCall) []
 				continue
 			}
 			klog.V(6).Infof("解析ToolName: %s, ServerName: %s\n", toolName, serverName)
+			// 已知只读工具以外的工具需要用户确认后才执行，用户可以修改参数
+			if confirmer := ToolConfirmerFromContext(ctx); confirmer != nil && !IsReadOnlyTool(serverName, toolName) {
+				decision, confirmedArgs := confirmer.Confirm(ctx, fullToolName, serverName, args)
+				result.Confirmation = decision
+				klog.V(6).Infof("工具 %s 确认结果: %s\n", fullToolName, decision)
+				switch decision {
+				case ToolConfirmApproved:
+				case ToolConfirmEdited:
+					args = confirmedArgs
+					result.Parameters = args
+				default:
+					result.Error = fmt.Sprintf("用户未确认执行工具 %s: %s", fullToolName, decision)
+					results = append(results, result)
+					m.LogToolExecution(ctx, toolName, serverName, args, result, 0)
+					continue
+				}
+			}
 			// 执行工具调用
 			callRequest := mcp.CallToolRequest{}
 			callRequest.Params.Name = toolName
 			callRequest.Params.Arguments = args
 			klog.V(6).Infof("执行工具调用: %s\n", utils.ToJSON(callRequest))
-			cli, err = m.GetClient(ctx, serverName)
+			// 按服务器配置的超时时间限制单次调用
+			timeout := models.DefaultMCPCallTimeout
+			if config, ok := m.getConfig(serverName); ok {
+				timeout = config.Timeout
+			}
+			callCtx, cancel := context.WithTimeout(ctx, timeout)
+			cli, err = m.GetClient(callCtx, serverName)
 
 			if err != nil {
+				cancel()
 				klog.V(6).Infof("获取MCP Client 失败: %v\n", err)
 				result.Error = fmt.Sprintf("获取MCP Client 失败: %v", err)
 				results = append(results, result)
 				continue
 			}
 			// 执行工具
-			callResult, err := cli.CallTool(ctx, callRequest)
-			_ = cli.Close()
+			callResult, err := cli.CallTool(callCtx, callRequest)
+			m.ReleaseClient(serverName, cli)
+			if err != nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+				err = fmt.Errorf("调用超时(%s): %v", timeout, err)
+			}
+			cancel()
 			// 记录执行日志
 			executeTime := time.Since(startTime).Milliseconds()
 			if err != nil {
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"gorm.io/gorm"
+)
+
+// WithMcpKey 在 ctx 中携带MCP密钥，后续MCP调用按该密钥的限制执行
+func WithMcpKey(ctx context.Context, key *models.McpKey) context.Context {
+	return context.WithValue(ctx, constants.McpKeyPolicy, key)
+}
+
+// McpKeyFromCtx 获取 ctx 中携带的MCP密钥，未携带时返回 nil。
+// 会话可能持续较长时间，每次都重新加载密钥，使停用、过期及限制的修改即时生效。
+func McpKeyFromCtx(ctx context.Context) (*models.McpKey, error) {
+	key, ok := ctx.Value(constants.McpKeyPolicy).(*models.McpKey)
+	if !ok || key == nil {
+		return nil, nil
+	}
+	return UserService().GetMcpKey(func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", key.ID)
+	})
+}
+
+// CheckMcpKeyToolCall 按 ctx 中MCP密钥的限制检查内置 k8m MCP 服务器的工具调用，未携带密钥时不做限制
+func CheckMcpKeyToolCall(ctx context.Context, toolName string, args map[string]any) error {
+	key, err := McpKeyFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return nil
+	}
+	if err = key.CheckTool(toolName); err != nil {
+		return err
+	}
+	if key.ReadOnly && !IsReadOnlyTool(k8mServerName, toolName) {
+		return fmt.Errorf("只读MCP密钥只能调用查询类工具，不能调用 %s", toolName)
+	}
+	if len(key.ClusterList()) == 0 {
+		return nil
+	}
+	cluster, _ := args["cluster"].(string)
+	if cluster == "" {
+		return errors.New("MCP密钥限制了可访问的集群，请在工具参数中指定 cluster")
+	}
+	if !key.AllowCluster(cluster) {
+		return fmt.Errorf("MCP密钥未授权访问集群 %s", cluster)
+	}
+	return nil
+}
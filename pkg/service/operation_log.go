@@ -7,6 +7,7 @@ import (
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/models"
+	"k8s.io/klog/v2"
 )
 
 type operationLogService struct {
@@ -61,7 +62,13 @@ func (s *operationLogService) flushBuffer() {
 	if len(s.buffer) == 0 {
 		return
 	}
-	dao.DB().CreateInBatches(s.buffer, 100)
+	if err := dao.DB().CreateInBatches(s.buffer, 100).Error; err != nil {
+		klog.Errorf("保存操作日志失败: %v", err)
+	} else {
+		for _, m := range s.buffer {
+			AuditService().EmitOperationLog(m)
+		}
+	}
 	s.buffer = s.buffer[:0]
 }
 
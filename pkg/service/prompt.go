@@ -9,6 +9,7 @@ import (
 	"github.com/weibaohui/k8m/pkg/constants"
 	"github.com/weibaohui/k8m/pkg/models"
 	"gorm.io/gorm"
+	"k8s.io/klog/v2"
 )
 
 // promptService 提示词服务结构体
@@ -18,42 +19,115 @@ type promptService struct {
 
 // GetPrompt 根据提示词类型获取提示词内容
 // 参数:
-//   - ctx: 上下文对象
+//   - ctx: 上下文对象，携带用户及集群时按其选择提示词变体
 //   - promptType: 提示词类型
 //
 // 返回值:
 //   - string: 提示词内容
 //   - error: 错误信息
 func (p *promptService) GetPrompt(ctx context.Context, promptType constants.AIPromptType) (string, error) {
+	prompt, err := p.SelectPrompt(ctx, promptType)
+	if err != nil {
+		return "", err
+	}
+	return prompt.Content, nil
+}
+
+// SelectPrompt 选择生效的提示词变体
+// 同一类型可启用多个变体，按 ctx 中的集群及用户所在用户组筛选，取匹配最具体、优先级最高的一个
+func (p *promptService) SelectPrompt(ctx context.Context, promptType constants.AIPromptType) (*models.AIPrompt, error) {
 	// 验证输入参数
 	if promptType == "" {
-		return "", errors.New("提示词类型不能为空")
+		return nil, errors.New("提示词类型不能为空")
 	}
 
-	// 创建查询参数，直接根据 prompt_type 字段查询
-	params := dao.Params{
-		Queries: map[string]any{
-			"prompt_type": string(promptType),
-		},
-		UserName: "", // 不匹配创建人，也就是不管谁创建的
+	var list []*models.AIPrompt
+	err := dao.DB().Where("prompt_type = ? AND is_enabled = ?", promptType, true).Order("id").Find(&list).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询提示词失败: %v", err)
 	}
 
-	// 查询数据库中匹配该类型的第一个提示词
-	var prompt models.AIPrompt
-	queryFunc := func(db *gorm.DB) *gorm.DB {
-		return db.Where("prompt_type = ? AND is_enabled = ?", promptType, true)
+	cluster, _ := ctx.Value(constants.AICluster).(string)
+	var groups []string
+	if username, _ := ctx.Value(constants.JwtUserName).(string); username != "" {
+		groups, _ = UserService().GetGroupNames(username)
+	}
+	var selected *models.AIPrompt
+	best := -1
+	for _, item := range list {
+		score := item.MatchScore(cluster, groups)
+		if score < 0 {
+			continue
+		}
+		if score > best || (score == best && item.Priority > selected.Priority) {
+			selected, best = item, score
+		}
+	}
+	if selected == nil {
+		return nil, fmt.Errorf("未找到类型为 '%s' 的提示词", promptType)
 	}
+	return selected, nil
+}
 
-	result, err := prompt.GetOne(&params, queryFunc)
+// RenderPrompt 选择提示词变体并使用变量值渲染，没有可用的提示词时使用内置提示词
+func (p *promptService) RenderPrompt(ctx context.Context, promptType constants.AIPromptType, values map[string]any) (string, error) {
+	prompt, err := p.SelectPrompt(ctx, promptType)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", fmt.Errorf("未找到类型为 '%s' 的提示词", promptType)
+		klog.Errorf("获取%s prompt模板失败: %v", promptType, err)
+		prompt = &models.AIPrompt{PromptType: promptType, Content: models.GetBuiltinPromptContent(promptType)}
+	}
+	return prompt.Render(values)
+}
+
+// SavePrompt 保存提示词，内容有变化时版本号加一并保存快照
+func (p *promptService) SavePrompt(params *dao.Params, prompt *models.AIPrompt, comment string) error {
+	if _, err := prompt.GetVariables(); err != nil {
+		return err
+	}
+	if prompt.ID == 0 {
+		prompt.Version = 1
+	} else {
+		existing, err := (&models.AIPrompt{}).GetOne(params, func(db *gorm.DB) *gorm.DB {
+			return db.Where("id = ?", prompt.ID)
+		})
+		if err != nil {
+			return err
 		}
-		return "", fmt.Errorf("查询提示词失败: %v", err)
+		prompt.Version = existing.Version
+		if models.NewAIPromptVersion(existing, "").SameContent(prompt) {
+			return prompt.Save(params)
+		}
+		prompt.Version = existing.Version + 1
+	}
+	if err := prompt.Save(params); err != nil {
+		return err
 	}
+	version := models.NewAIPromptVersion(prompt, comment)
+	version.CreatedBy = params.UserName
+	return dao.DB().Create(version).Error
+}
 
-	// 返回提示词内容
-	return result.Content, nil
+// Versions 获取提示词的历史版本，新版本在前
+func (p *promptService) Versions(promptID uint) ([]*models.AIPromptVersion, error) {
+	var list []*models.AIPromptVersion
+	err := dao.DB().Where("prompt_id = ?", promptID).Order("version desc").Find(&list).Error
+	return list, err
+}
+
+// Rollback 将提示词回滚到指定版本，回滚结果作为新版本保存
+func (p *promptService) Rollback(params *dao.Params, promptID uint, version int) error {
+	var snapshot models.AIPromptVersion
+	if err := dao.DB().Where("prompt_id = ? AND version = ?", promptID, version).First(&snapshot).Error; err != nil {
+		return fmt.Errorf("提示词版本 %d 不存在", version)
+	}
+	prompt, err := (&models.AIPrompt{}).GetOne(params, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", promptID)
+	})
+	if err != nil {
+		return err
+	}
+	snapshot.ApplyTo(prompt)
+	return p.SavePrompt(params, prompt, fmt.Sprintf("回滚到版本 %d", version))
 }
 
 // ListPrompts 获取提示词列表
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/ai"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/redact"
+	"k8s.io/klog/v2"
+)
+
+// redactionService 发送给大模型前的内容脱敏
+// 实现 ai.Redaction，启用的规则编译后缓存，规则变更后调用 Reset 重新加载
+type redactionService struct {
+	mu     sync.RWMutex
+	rules  []*redact.Rule
+	loaded bool
+}
+
+func init() {
+	ai.RegisterRedaction(localRedactionService)
+}
+
+// NewRedactor 按启用的规则创建脱敏器，没有启用的规则时返回nil
+func (s *redactionService) NewRedactor() *redact.Redactor {
+	rules := s.Rules()
+	if len(rules) == 0 {
+		return nil
+	}
+	return redact.New(rules)
+}
+
+// WithRedactor 在 ctx 中携带脱敏器，同一对话中的多次请求共用，使同一敏感值的占位符保持一致
+func (s *redactionService) WithRedactor(ctx context.Context) context.Context {
+	r := s.NewRedactor()
+	if r == nil {
+		return ctx
+	}
+	return ai.WithRedactor(ctx, r)
+}
+
+// Rules 获取编译后的启用规则，无效的规则跳过
+func (s *redactionService) Rules() []*redact.Rule {
+	s.mu.RLock()
+	if s.loaded {
+		defer s.mu.RUnlock()
+		return s.rules
+	}
+	s.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded {
+		return s.rules
+	}
+	var list []*models.RedactionRule
+	if err := dao.DB().Where("enabled = ?", true).Order("id").Find(&list).Error; err != nil {
+		klog.Errorf("读取脱敏规则失败: %v", err)
+		return nil
+	}
+	var rules []*redact.Rule
+	for _, m := range list {
+		rule, err := s.Compile(m)
+		if err != nil {
+			klog.Warningf("脱敏规则 %s 无效，已跳过: %v", m.Name, err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	s.rules = rules
+	s.loaded = true
+	return rules
+}
+
+// Compile 编译脱敏规则
+func (s *redactionService) Compile(m *models.RedactionRule) (*redact.Rule, error) {
+	if m.RuleType != models.RedactionRuleTypeBuiltin {
+		return redact.Compile(m.Name, m.RuleType, m.Pattern, m.Category)
+	}
+	rule := redact.Builtin(m.Pattern)
+	if rule == nil {
+		return nil, fmt.Errorf("未知的内置规则: %s", m.Pattern)
+	}
+	return rule, nil
+}
+
+// Reset 清除规则缓存，下次请求时重新加载
+func (s *redactionService) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = nil
+	s.loaded = false
+}
+
+// Audit 记录一次请求中各类别的脱敏次数
+func (s *redactionService) Audit(ctx context.Context, stats map[string]int) {
+	username, _ := ctx.Value(constants.JwtUserName).(string)
+	feature, _ := ctx.Value(constants.AIFeature).(constants.AIPromptType)
+	cluster, _ := ctx.Value(constants.AICluster).(string)
+	total := 0
+	for _, n := range stats {
+		total += n
+	}
+	log := &models.RedactionLog{
+		UserName:   username,
+		Feature:    string(feature),
+		Cluster:    cluster,
+		Categories: utils.ToJSON(stats),
+		Total:      total,
+	}
+	if err := dao.DB().Create(log).Error; err != nil {
+		klog.Errorf("保存脱敏审计失败: %v", err)
+	}
+}
+
+// Preview 使用当前规则脱敏文本，用于验证规则效果
+func (s *redactionService) Preview(text string) (string, map[string]int) {
+	stats := make(map[string]int)
+	r := s.NewRedactor()
+	if r == nil {
+		return text, stats
+	}
+	return r.Redact(text, stats), stats
+}
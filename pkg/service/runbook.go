@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/ai"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/rag"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+// runbookTopK AI 对话时附带的运维手册片段数量
+const runbookTopK = 3
+
+// runbookMaxQuery 检索时使用的问题最大长度（字符数），日志等长文本只取开头部分
+const runbookMaxQuery = 4000
+
+// runbookService 运维手册管理及检索
+// 启用的手册分块后建立本地 BM25 索引，索引在首次检索时构建，手册变更后重建
+type runbookService struct {
+	mu     sync.RWMutex
+	index  *rag.Index
+	chunks map[uint]*RunbookHit // 分块ID -> 分块
+}
+
+// RunbookHit 检索到的运维手册片段
+type RunbookHit struct {
+	RunbookID uint    `json:"runbook_id"`
+	ChunkID   uint    `json:"chunk_id"`
+	Title     string  `json:"title"`
+	Heading   string  `json:"heading"`
+	Content   string  `json:"content"`
+	Score     float64 `json:"score"`
+}
+
+// Save 保存运维手册，重新分块并重建索引
+func (s *runbookService) Save(params *dao.Params, m *models.Runbook) error {
+	if strings.TrimSpace(m.Title) == "" {
+		return fmt.Errorf("标题不能为空")
+	}
+	if strings.TrimSpace(m.Content) == "" {
+		return fmt.Errorf("内容不能为空")
+	}
+	if m.Format != models.RunbookFormatText {
+		m.Format = models.RunbookFormatMarkdown
+	}
+	chunks := rag.Split(m.Content, m.Format == models.RunbookFormatMarkdown, rag.DefaultChunkSize)
+	m.ChunkCount = len(chunks)
+	if err := m.Save(params); err != nil {
+		return err
+	}
+
+	err := dao.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("runbook_id = ?", m.ID).Delete(&models.RunbookChunk{}).Error; err != nil {
+			return err
+		}
+		var rows []*models.RunbookChunk
+		for i, c := range chunks {
+			rows = append(rows, &models.RunbookChunk{RunbookID: m.ID, Seq: i, Heading: c.Heading, Content: c.Content})
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, 100).Error
+	})
+	s.Reset()
+	return err
+}
+
+// Delete 删除运维手册及其分块
+func (s *runbookService) Delete(params *dao.Params, ids string) error {
+	m := &models.Runbook{}
+	if err := m.Delete(params, ids); err != nil {
+		return err
+	}
+	err := dao.DB().Where("runbook_id in ?", utils.ToInt64Slice(ids)).Delete(&models.RunbookChunk{}).Error
+	s.Reset()
+	return err
+}
+
+// Reset 清除索引，下次检索时重建
+func (s *runbookService) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index = nil
+	s.chunks = nil
+}
+
+// load 从启用的运维手册构建索引
+func (s *runbookService) load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index != nil {
+		return nil
+	}
+	var rows []*RunbookHit
+	err := dao.DB().Table("runbook_chunks").
+		Select("runbook_chunks.id as chunk_id, runbook_chunks.runbook_id, runbook_chunks.heading, runbook_chunks.content, runbooks.title").
+		Joins("join runbooks on runbooks.id = runbook_chunks.runbook_id").
+		Where("runbooks.enabled = ?", true).
+		Order("runbook_chunks.runbook_id, runbook_chunks.seq").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	index := rag.NewIndex()
+	chunks := make(map[uint]*RunbookHit, len(rows))
+	for _, row := range rows {
+		// 标题及章节同样参与检索
+		index.Add(row.ChunkID, row.Title+"\n"+row.Heading+"\n"+row.Content)
+		chunks[row.ChunkID] = row
+	}
+	s.index = index
+	s.chunks = chunks
+	klog.V(6).Infof("运维手册索引已构建，共 %d 个分块", len(rows))
+	return nil
+}
+
+// Search 检索与问题最相关的 k 个运维手册片段
+func (s *runbookService) Search(query string, k int) ([]*RunbookHit, error) {
+	if r := []rune(query); len(r) > runbookMaxQuery {
+		query = string(r[:runbookMaxQuery])
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []*RunbookHit
+	for _, hit := range s.index.Search(query, k) {
+		if c, ok := s.chunks[hit.ID]; ok {
+			item := *c
+			item.Score = hit.Score
+			result = append(result, &item)
+		}
+	}
+	return result, nil
+}
+
+// References 检索相关片段，生成附在系统提示后的参考资料，要求模型回答时标注引用；没有相关片段时返回空
+func (s *runbookService) References(query string) string {
+	hits, err := s.Search(query, runbookTopK)
+	if err != nil {
+		klog.Errorf("检索运维手册失败: %v", err)
+		return ""
+	}
+	if len(hits) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("以下是团队运维手册中可能与问题相关的片段，仅在相关时参考。")
+	sb.WriteString("回答中使用了某个片段时，请在相应位置以 [编号] 标注，并在回答末尾列出引用来源（编号、手册标题及章节）。\n")
+	for i, h := range hits {
+		sb.WriteString(fmt.Sprintf("\n[%d] 《%s》", i+1, h.Title))
+		if h.Heading != "" {
+			sb.WriteString(" " + h.Heading)
+		}
+		sb.WriteString("\n" + h.Content + "\n")
+	}
+	return sb.String()
+}
+
+// WithReferences 在 ctx 中携带与问题相关的运维手册片段
+func (s *runbookService) WithReferences(ctx context.Context, query string) context.Context {
+	return ai.WithReferences(ctx, s.References(query))
+}
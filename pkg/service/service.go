@@ -31,6 +31,51 @@ var localShellLogService = &shellLogService{}
 var localAiService = &aiService{}
 var localMcpService = &mcpService{}
 var localPromptService = &promptService{}
+var localCustomRoleService = &customRoleService{}
+var localApprovalService = &approvalService{}
+var localClusterAccessService = &clusterAccessService{}
+var localAuditService = &auditService{}
+var localTerminalSessionService = &terminalSessionService{}
+var localChatSessionService = &chatSessionService{}
+var localAIUsageService = &aiUsageService{}
+var localRedactionService = &redactionService{}
+var localRunbookService = &runbookService{}
+
+func CustomRoleService() *customRoleService {
+	return localCustomRoleService
+}
+
+func ApprovalService() *approvalService {
+	return localApprovalService
+}
+
+func ClusterAccessService() *clusterAccessService {
+	return localClusterAccessService
+}
+
+func AuditService() *auditService {
+	return localAuditService
+}
+
+func TerminalSessionService() *terminalSessionService {
+	return localTerminalSessionService
+}
+
+func AIUsageService() *aiUsageService {
+	return localAIUsageService
+}
+
+func RedactionService() *redactionService {
+	return localRedactionService
+}
+
+func RunbookService() *runbookService {
+	return localRunbookService
+}
+
+func ChatSessionService() *chatSessionService {
+	return localChatSessionService
+}
 
 func PromptService() *promptService {
 	return localPromptService
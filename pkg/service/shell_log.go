@@ -2,11 +2,16 @@ package service
 
 import (
 	"github.com/weibaohui/k8m/pkg/models"
+	"k8s.io/klog/v2"
 )
 
 type shellLogService struct {
 }
 
 func (s *shellLogService) Add(m *models.ShellLog) {
-	_ = m.Save(nil)
+	if err := m.Save(nil); err != nil {
+		klog.Errorf("保存终端命令日志失败: %v", err)
+		return
+	}
+	AuditService().EmitShellLog(m)
 }
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/xterm"
+	"github.com/weibaohui/k8m/pkg/flag"
+	"github.com/weibaohui/k8m/pkg/models"
+	"k8s.io/klog/v2"
+)
+
+type terminalSessionService struct {
+	live       sync.Map // 进行中的终端会话，仅为当前实例上的会话
+	nodeShells sync.Map // 通过节点终端接口创建的 Pod -> nodeShell，会话记录中的节点名称以此为准
+}
+
+// nodeShell 节点终端 Pod 对应的节点
+type nodeShell struct {
+	nodeName  string
+	createdAt time.Time
+}
+
+// nodeShellTTL 节点终端 Pod 登记的保留时间，超过后视为已清理
+const nodeShellTTL = 24 * time.Hour
+
+func nodeShellKey(cluster, ns, podName string) string {
+	return cluster + "/" + ns + "/" + podName
+}
+
+// RegisterNodeShell 登记由节点终端接口创建的 Pod 及其所在节点，供终端会话记录节点名称，不信任客户端传入的节点
+func (s *terminalSessionService) RegisterNodeShell(cluster, ns, podName, nodeName string) {
+	now := time.Now()
+	s.nodeShells.Range(func(key, value any) bool {
+		if now.Sub(value.(nodeShell).createdAt) > nodeShellTTL {
+			s.nodeShells.Delete(key)
+		}
+		return true
+	})
+	s.nodeShells.Store(nodeShellKey(cluster, ns, podName), nodeShell{nodeName: nodeName, createdAt: now})
+}
+
+// NodeShellNode 获取节点终端 Pod 所在的节点，非节点终端时返回空
+func (s *terminalSessionService) NodeShellNode(cluster, ns, podName string) string {
+	v, ok := s.nodeShells.Load(nodeShellKey(cluster, ns, podName))
+	if !ok || time.Since(v.(nodeShell).createdAt) > nodeShellTTL {
+		return ""
+	}
+	return v.(nodeShell).nodeName
+}
+
+// TerminalRecording 进行中的终端会话录像
+// 所有方法均可在 nil 上调用，未开启录像时调用方无需判断
+type TerminalRecording struct {
+	session  *models.TerminalSession
+	file     *os.File
+	recorder *xterm.Recorder
+}
+
+// Start 创建终端会话记录并开始录像，未配置录像目录或创建失败时返回nil
+// 录像文件按日期分目录保存：<terminal-record-path>/<yyyyMMdd>/<HHmmss>-<随机串>.cast
+func (s *terminalSessionService) Start(session *models.TerminalSession) *TerminalRecording {
+	root := flag.Init().TerminalRecordPath
+	if root == "" {
+		return nil
+	}
+	now := time.Now()
+	dir := filepath.Join(root, now.Format("20060102"))
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		klog.Errorf("创建终端录像目录失败: %v", err)
+		return nil
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.cast", now.Format("150405"), utils.RandNLengthString(8)))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o640)
+	if err != nil {
+		klog.Errorf("创建终端录像文件失败: %v", err)
+		return nil
+	}
+	session.ID = 0
+	session.FilePath = path
+	if err = session.Save(nil); err != nil {
+		klog.Errorf("保存终端会话记录失败: %v", err)
+		_ = file.Close()
+		_ = os.Remove(path)
+		return nil
+	}
+	title := fmt.Sprintf("%s %s/%s", session.Cluster, session.Namespace, session.PodName)
+	if session.NodeName != "" {
+		title = fmt.Sprintf("%s node/%s", session.Cluster, session.NodeName)
+	}
+	return &TerminalRecording{
+		session:  session,
+		file:     file,
+		recorder: xterm.NewRecorder(file, title),
+	}
+}
+
+// Output 记录终端输出
+func (r *TerminalRecording) Output(data []byte) {
+	if r != nil {
+		r.recorder.Output(data)
+	}
+}
+
+// Input 记录用户输入
+func (r *TerminalRecording) Input(data []byte) {
+	if r != nil {
+		r.recorder.Input(data)
+	}
+}
+
+// Resize 记录窗口大小调整
+func (r *TerminalRecording) Resize(cols, rows uint16) {
+	if r != nil {
+		r.recorder.Resize(cols, rows)
+	}
+}
+
+// Finish 结束录像，回写会话时长、文件大小及录制错误
+func (r *TerminalRecording) Finish() {
+	if r == nil {
+		return
+	}
+	now := time.Now()
+	updates := map[string]any{
+		"ended_at": &now,
+		"duration": r.recorder.Duration().Milliseconds(),
+	}
+	if err := r.recorder.Err(); err != nil {
+		updates["error"] = err.Error()
+	}
+	if err := r.file.Close(); err != nil {
+		klog.Errorf("关闭终端录像文件失败: %v", err)
+	}
+	if info, err := os.Stat(r.session.FilePath); err == nil {
+		updates["size"] = info.Size()
+	}
+	if err := dao.DB().Model(&models.TerminalSession{}).Where("id = ?", r.session.ID).Updates(updates).Error; err != nil {
+		klog.Errorf("更新终端会话记录#%d 失败: %v", r.session.ID, err)
+	}
+}
+
+// Open 打开终端会话的录像文件
+func (s *terminalSessionService) Open(session *models.TerminalSession) (*os.File, error) {
+	if session.FilePath == "" {
+		return nil, fmt.Errorf("终端会话#%d 无录像文件", session.ID)
+	}
+	file, err := os.Open(session.FilePath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("终端会话#%d 的录像文件已被清理", session.ID)
+	}
+	return file, err
+}
+
+// StartLive 登记进行中的终端会话，kill 为强制结束会话的方法
+func (s *terminalSessionService) StartLive(info xterm.LiveSessionInfo, kill func(reason string)) *xterm.LiveSession {
+	info.ID = utils.RandNLengthString(16)
+	ls := xterm.NewLiveSession(info, kill)
+	s.live.Store(info.ID, ls)
+	return ls
+}
+
+// EndLive 会话结束，移出登记并断开所有观察者
+func (s *terminalSessionService) EndLive(ls *xterm.LiveSession) {
+	s.live.Delete(ls.ID)
+	ls.Close()
+}
+
+// ListLive 当前实例上进行中的终端会话，按开始时间倒序
+func (s *terminalSessionService) ListLive() []xterm.LiveSessionInfo {
+	list := make([]xterm.LiveSessionInfo, 0)
+	s.live.Range(func(_, value any) bool {
+		list = append(list, value.(*xterm.LiveSession).Snapshot())
+		return true
+	})
+	slices.SortFunc(list, func(a, b xterm.LiveSessionInfo) int {
+		return b.StartedAt.Compare(a.StartedAt)
+	})
+	return list
+}
+
+// GetLive 获取进行中的终端会话
+func (s *terminalSessionService) GetLive(id string) (*xterm.LiveSession, error) {
+	v, ok := s.live.Load(id)
+	if !ok {
+		return nil, fmt.Errorf("终端会话[%s]不存在或已结束", id)
+	}
+	return v.(*xterm.LiveSession), nil
+}
+
+// KillLive 强制结束终端会话，并记录操作日志
+func (s *terminalSessionService) KillLive(id string, operator string) error {
+	ls, err := s.GetLive(id)
+	if err != nil {
+		return err
+	}
+	ls.Kill(fmt.Sprintf("会话已被管理员[%s]强制终止", operator))
+	s.audit(ls, operator, "kill_terminal")
+	return nil
+}
+
+// RecordObserve 记录管理员观察终端会话的操作日志
+func (s *terminalSessionService) RecordObserve(ls *xterm.LiveSession, operator string) {
+	s.audit(ls, operator, "observe_terminal")
+}
+
+func (s *terminalSessionService) audit(ls *xterm.LiveSession, operator string, action string) {
+	log := &models.OperationLog{
+		Action:       action,
+		Cluster:      ls.Cluster,
+		Kind:         "Pod",
+		Name:         ls.PodName,
+		Namespace:    ls.Namespace,
+		UserName:     operator,
+		Params:       fmt.Sprintf("终端会话[%s]，会话用户[%s]，容器[%s]，节点[%s]", ls.ID, ls.UserName, ls.ContainerName, ls.NodeName),
+		ActionResult: "success",
+	}
+	if roles, err := UserService().GetRolesByUserName(operator); err == nil {
+		log.Role = strings.Join(roles, ",")
+	}
+	OperationLogService().Add(log)
+}
@@ -195,6 +195,7 @@ func (u *userService) GetClusterNames(username string) ([]string, error) {
 // 最终结果包含两种情况：
 // 1. 用户授权类型为用户
 // 2. 用户授权类型为用户组,当前用户所在的用户组，如果有授权，那么也提取出来
+// 不在有效期内（未生效或已过期）的授权不会返回
 func (u *userService) GetClusters(username string) ([]*models.ClusterUserRole, error) {
 	cacheKey := u.formatCacheKey("user:clusters:%s", username)
 
@@ -225,8 +226,19 @@ func (u *userService) GetClusters(username string) ([]*models.ClusterUserRole, e
 		}
 		return items, nil
 	})
+	if err != nil {
+		return result, err
+	}
 
-	return result, err
+	// 缓存中保存的是全部授权，每次读取时按有效期过滤，避免到期的临时授权在缓存期内仍然生效
+	now := time.Now()
+	active := make([]*models.ClusterUserRole, 0, len(result))
+	for _, item := range result {
+		if item.IsActive(now) {
+			active = append(active, item)
+		}
+	}
+	return active, nil
 }
 
 // GenerateJWTTokenOnlyUserName  生成 Token，仅包含Username
@@ -282,30 +294,44 @@ func (u *userService) GetGroupNames(username string) ([]string, error) {
 }
 
 func (u *userService) GetUserByMCPKey(mcpKey string) (string, error) {
+	item, err := u.GetMcpKey(func(db *gorm.DB) *gorm.DB {
+		return db.Where(" mcp_key = ?", mcpKey)
+	})
+	if err != nil {
+		return "", err
+	}
+	return item.Username, nil
+}
+
+// GetMcpKey 查询MCP密钥，并校验密钥是否启用、未过期且所属用户未被禁用
+func (u *userService) GetMcpKey(queryFunc func(db *gorm.DB) *gorm.DB) (*models.McpKey, error) {
 	params := &dao.Params{}
 	m := &models.McpKey{}
-	queryFunc := func(db *gorm.DB) *gorm.DB {
-		return db.Select("username").Where(" mcp_key = ?", mcpKey)
-	}
 	item, err := m.GetOne(params, queryFunc)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if item.Username == "" {
-		return "", errors.New("username is empty")
+		return nil, errors.New("username is empty")
+	}
+	if !item.Enabled {
+		return nil, errors.New("MCP密钥已停用")
+	}
+	if item.Expired() {
+		return nil, errors.New("MCP密钥已过期")
 	}
 
 	// 检测用户是否被禁用
 	user := &models.User{}
 	disabled, err := user.IsDisabled(item.Username)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if disabled {
-		return "", fmt.Errorf("用户[%s]被禁用", item.Username)
+		return nil, fmt.Errorf("用户[%s]被禁用", item.Username)
 	}
-	return item.Username, nil
+	return item, nil
 }
 
 // CheckAndCreateUser 检查用户是否存在，如果不存在则创建一个新用户